@@ -13,9 +13,14 @@ import (
 
 func main() {
 	cfg := config.Load()
+	loc, err := cfg.Location()
+	if err != nil {
+		log.Fatalf("Invalid time zone: %v", err)
+	}
 
 	// allow overriding offers path via flag
 	offersPath := flag.String("offers", cfg.OffersDataPath, "Path to offers CSV")
+	migrate := flag.Bool("migrate-offers", false, "Recreate a legacy offers table (without offerid) before importing; existing rows are dropped")
 	flag.Parse()
 
 	// connect to scylla
@@ -25,8 +30,14 @@ func main() {
 	}
 	defer session.Close()
 
+	if *migrate {
+		if err := importer.MigrateOffersTable(session); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+	}
+
 	// ensure schema keyspace is active (handled by session setup keyspace)
-	imp := importer.NewDataImporter("", *offersPath)
+	imp := importer.NewDataImporter("", *offersPath, loc)
 	start := time.Now()
 	fmt.Printf("Starting offers import to Scylla from %s...\n", *offersPath)
 	if err := imp.ImportOffersToScylla(session); err != nil {
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"time"
 
+	"holiday-coding-challenge/backend/internal/alerts"
+	"holiday-coding-challenge/backend/internal/apierror"
 	"holiday-coding-challenge/backend/internal/config"
+	"holiday-coding-challenge/backend/internal/currency"
 	"holiday-coding-challenge/backend/internal/handlers"
 	"holiday-coding-challenge/backend/internal/importer"
 	"holiday-coding-challenge/backend/internal/storage"
@@ -22,6 +27,15 @@ import (
 func main() {
 	// Konfiguration laden
 	cfg := config.Load()
+	// Zeitzone für Tage (Datumsgrenzen der Suche, Preiskalender, CSV-Zeiten ohne Offset)
+	loc, err := cfg.Location()
+	if err != nil {
+		log.Fatalf("Ungültige Zeitzone: %v", err)
+	}
+
+	// Fehler als Problem Details (RFC 9457) mit stabilem Code, übersetzt nach Accept-Language;
+	// muss vor dem Registrieren der Routen gesetzt sein, damit die OpenAPI-Beschreibung passt
+	huma.NewError = apierror.NewHumaError
 
 	// Fiber App erstellen
 	app := fiber.New(fiber.Config{
@@ -30,9 +44,10 @@ func main() {
 			if e, ok := err.(*fiber.Error); ok {
 				code = e.Code
 			}
-			return c.Status(code).JSON(fiber.Map{
-				"error": err.Error(),
-			})
+			lang := apierror.Negotiate(c.Get(fiber.HeaderAcceptLanguage))
+			problem := apierror.FromStatus(code, err.Error()).Localize(lang, c.Path())
+			c.Set(fiber.HeaderContentLanguage, lang)
+			return c.Status(code).JSON(problem, apierror.ContentType)
 		},
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
@@ -42,48 +57,77 @@ func main() {
 	app.Use(logger.New())
 	app.Use(recover.New())
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
+		AllowOrigins:  "*",
+		AllowMethods:  "GET,POST,PUT,DELETE,OPTIONS",
+		AllowHeaders:  "Origin,Content-Type,Accept,Authorization",
+		ExposeHeaders: "X-Total-Count,X-Currency,X-Exchange-Rate,X-Exchange-Rate-Date",
 	}))
 
-	// Scylla Storage initialisieren
-	session, err := storage.NewScyllaSession()
-	if err != nil {
-		log.Fatalf("Scylla Verbindung fehlgeschlagen: %v", err)
-	}
-	defer session.Close()
-	scy := storage.NewScyllaStorage(session)
-
-	// Sicherstellen, dass Hotels in DB sind (kleine Tabelle); importiere bei Bedarf aus CSV
-	var hotelsCount int64
-	if err := session.Query(`SELECT COUNT(*) FROM hotels`).Scan(&hotelsCount); err != nil {
-		log.Printf("Warnung: COUNT(*) hotels fehlgeschlagen: %v", err)
-	}
-	if hotelsCount == 0 {
-		fmt.Println("Hotels-Tabelle leer, importiere aus CSV...")
-		di := importer.NewDataImporter(cfg.HotelsDataPath, cfg.OffersDataPath)
-		hotels, err := di.LoadHotels()
+	// Storage initialisieren (Scylla oder In-Memory, siehe STORAGE_BACKEND)
+	var (
+		store      storage.Storage
+		shortlists storage.ShortlistStore
+		alertStore storage.AlertStore
+		bookings   storage.BookingStore
+	)
+	switch cfg.StorageBackend {
+	case config.BackendScylla:
+		session, err := storage.NewScyllaSession()
 		if err != nil {
-			log.Fatalf("Fehler beim Laden der Hotel-Daten: %v", err)
+			log.Fatalf("Scylla Verbindung fehlgeschlagen: %v", err)
 		}
-		// batch insert
-		b := session.NewBatch(gocql.UnloggedBatch)
-		for _, h := range hotels {
-			b.Query(`INSERT INTO hotels (hotelid, hotelname, hotelstars) VALUES (?,?,?)`, h.ID, h.Name, h.Stars)
+		defer session.Close()
+		ensureHotelsInScylla(cfg, loc, session)
+		store = storage.NewScyllaStorage(session, loc)
+		if shortlists, err = storage.NewScyllaShortlistStore(session); err != nil {
+			log.Printf("Warnung: Merkzettel werden nur im Speicher gehalten: %v", err)
+			shortlists = storage.NewMemoryShortlistStore()
 		}
-		if err := session.ExecuteBatch(b); err != nil {
-			log.Fatalf("Fehler beim Import der Hotels: %v", err)
+		if alertStore, err = storage.NewScyllaAlertStore(session); err != nil {
+			log.Printf("Warnung: Preisalarme werden nur im Speicher gehalten: %v", err)
+			alertStore = storage.NewMemoryAlertStore()
 		}
-		fmt.Printf("✓ %d Hotels in Scylla importiert\n", len(hotels))
+		if bookings, err = storage.NewScyllaBookingStore(session); err != nil {
+			log.Printf("Warnung: Buchungen werden nur im Speicher gehalten: %v", err)
+			bookings = storage.NewMemoryBookingStore()
+		}
+	case config.BackendMemory:
+		store = newMemoryStorage(cfg, loc)
+		shortlists = storage.NewMemoryShortlistStore()
+		alertStore = storage.NewMemoryAlertStore()
+		bookings = storage.NewMemoryBookingStore()
+	default:
+		log.Fatalf("Unbekanntes STORAGE_BACKEND %q (erlaubt: %s, %s)", cfg.StorageBackend, config.BackendScylla, config.BackendMemory)
+	}
+
+	// Wechselkurse laden
+	rates := currency.NewRates(cfg.RatesFile)
+	if err := rates.Load(); err != nil {
+		log.Fatalf("Fehler beim Laden der Wechselkurse: %v", err)
 	}
 
 	// Handler initialisieren
-	hotelHandler := handlers.NewHotelHandler(scy)
+	hotelHandler := handlers.NewHotelHandler(store, rates, loc)
+	shortlistHandler := handlers.NewShortlistHandler(store, shortlists, rates)
+	ratesHandler := handlers.NewRatesHandler(rates, cfg.AdminToken)
+
+	// Preisalarme im Hintergrund auswerten
+	// Webhooks nur an öffentliche Adressen, außer für lokale Tests (ALERTS_ALLOW_PRIVATE_WEBHOOKS)
+	if cfg.AlertsAllowPrivateWebhooks {
+		log.Printf("alerts: webhooks to private addresses allowed")
+	}
+	webhookClient := alerts.NewWebhookClient(10*time.Second, cfg.AlertsAllowPrivateWebhooks)
+	evaluator := alerts.NewEvaluator(store, alertStore, alerts.NewNotifier(webhookClient, cfg.AlertsWebhookAttempts), cfg.AlertsInterval, cfg.AlertsPoll, loc)
+	alertCtx, stopAlerts := context.WithCancel(context.Background())
+	defer stopAlerts()
+	go evaluator.Run(alertCtx)
+	alertHandler := handlers.NewAlertHandler(alertStore, evaluator, loc, cfg.AlertsAllowPrivateWebhooks)
+	bookingHandler := handlers.NewBookingHandler(storage.NewBookingService(store, bookings, cfg.BookingHold), rates)
 
 	// Huma API konfigurieren
 	config := huma.DefaultConfig("Holiday Coding Challenge API", "1.0.0")
 	config.OpenAPI.Info.Description = "API für Hotel-Suche und Angebote"
+	config.Transformers = append([]huma.Transformer{apierror.Transform}, config.Transformers...)
 	config.OpenAPI.Servers = []*huma.Server{
 		{URL: "http://localhost:8090", Description: "Development server"},
 	}
@@ -95,10 +139,38 @@ func main() {
 		Method:      "GET",
 		Path:        "/bestOffersByHotel",
 		Summary:     "Get best offers by hotel",
-		Description: "Get the best (i.e. cheapest) offer for every hotel that has at least one available offer for a given search",
+		Description: "Get the best (i.e. cheapest) offer for every hotel that has at least one available offer for a given search. countAvailableOffers stops at the server's count limit (SEARCH_COUNT_LIMIT, Scylla only); countCapped marks such lower bounds.",
 		Tags:        []string{"hotels"},
 	}, hotelHandler.HumaGetHotelsWithBestOffers)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "getBestOffersByHotelFacets",
+		Method:      "GET",
+		Path:        "/bestOffersByHotel/facets",
+		Summary:     "Get search facets",
+		Description: "Get hotel counts and minimum prices per departure airport, meal type, room type, ocean view, stars and duration for a given search. Each facet ignores its own filter. With Scylla, departureAirports, countAdults and duration are required (422 search_too_broad otherwise).",
+		Tags:        []string{"hotels"},
+	}, hotelHandler.HumaGetFacets)
+
+	// vor /hotels/{hotelId} registrieren, sonst wird "search" als Hotel-ID interpretiert
+	huma.Register(api, huma.Operation{
+		OperationID: "searchHotels",
+		Method:      "GET",
+		Path:        "/hotels/search",
+		Summary:     "Search hotels by name",
+		Description: "Autocomplete and fuzzy search on hotel names: prefix, infix and typo-tolerant matching, accents are ignored",
+		Tags:        []string{"hotels"},
+	}, hotelHandler.HumaSearchHotels)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getHotel",
+		Method:      "GET",
+		Path:        "/hotels/{hotelId}",
+		Summary:     "Get hotel details",
+		Description: "Get a hotel with summary statistics over all of its offers",
+		Tags:        []string{"hotels"},
+	}, hotelHandler.HumaGetHotel)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "GetHotelOffers",
 		Method:      "GET",
@@ -108,12 +180,200 @@ func main() {
 		Tags:        []string{"hotels", "offers"},
 	}, hotelHandler.HumaGetOffersByHotel)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "getOffer",
+		Method:      "GET",
+		Path:        "/offers/{offerId}",
+		Summary:     "Get offer",
+		Description: "Get a single offer by its stable id, e.g. for deep links",
+		Tags:        []string{"offers"},
+	}, hotelHandler.HumaGetOffer)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getHotelPriceCalendar",
+		Method:      "GET",
+		Path:        "/hotels/{hotelId}/priceCalendar",
+		Summary:     "Get hotel price calendar",
+		Description: "Get the cheapest matching offer of a hotel per departure day, week or month",
+		Tags:        []string{"hotels", "offers"},
+	}, hotelHandler.HumaGetHotelPriceCalendar)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getPriceCalendar",
+		Method:      "GET",
+		Path:        "/priceCalendar",
+		Summary:     "Get price calendar",
+		Description: "Get the cheapest matching offer across all hotels per departure day, week or month. With Scylla, departureAirports, countAdults and duration are required (422 search_too_broad otherwise).",
+		Tags:        []string{"offers"},
+	}, hotelHandler.HumaGetPriceCalendar)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getPriceHistogram",
+		Method:      "GET",
+		Path:        "/priceHistogram",
+		Summary:     "Get price histogram",
+		Description: "Get the price distribution of a search, counting matching offers or each hotel's best offer per price bucket. With Scylla, mode=offers requires departureAirports, countAdults and duration (422 search_too_broad otherwise).",
+		Tags:        []string{"offers"},
+	}, hotelHandler.HumaGetPriceHistogram)
+
+	// Merkzettel
+	huma.Register(api, huma.Operation{
+		OperationID: "listShortlistItems",
+		Method:      "GET",
+		Path:        "/shortlists/{id}/items",
+		Summary:     "List shortlist items",
+		Description: "List the hotels and offers on a shortlist with their current price and availability",
+		Tags:        []string{"shortlists"},
+	}, shortlistHandler.HumaListShortlistItems)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "addShortlistItem",
+		Method:      "POST",
+		Path:        "/shortlists/{id}/items",
+		Summary:     "Add shortlist item",
+		Description: "Remember a hotel or a specific offer; adding the same hotel or offer again replaces the item",
+		Tags:        []string{"shortlists"},
+	}, shortlistHandler.HumaAddShortlistItem)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getShortlistItem",
+		Method:      "GET",
+		Path:        "/shortlists/{id}/items/{itemId}",
+		Summary:     "Get shortlist item",
+		Description: "Get a shortlist item with its current price and availability",
+		Tags:        []string{"shortlists"},
+	}, shortlistHandler.HumaGetShortlistItem)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "updateShortlistItem",
+		Method:      "PUT",
+		Path:        "/shortlists/{id}/items/{itemId}",
+		Summary:     "Update shortlist item",
+		Description: "Change the note of a shortlist item",
+		Tags:        []string{"shortlists"},
+	}, shortlistHandler.HumaUpdateShortlistItem)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "deleteShortlistItem",
+		Method:        "DELETE",
+		Path:          "/shortlists/{id}/items/{itemId}",
+		Summary:       "Delete shortlist item",
+		Description:   "Remove an item from a shortlist",
+		Tags:          []string{"shortlists"},
+		DefaultStatus: http.StatusNoContent,
+	}, shortlistHandler.HumaDeleteShortlistItem)
+
+	// Preisalarme
+	huma.Register(api, huma.Operation{
+		OperationID:   "createAlert",
+		Method:        "POST",
+		Path:          "/alerts",
+		Summary:       "Create price alert",
+		Description:   "Save a search with a target price; the webhook receives a signed POST whenever a hotel of the search drops below it",
+		Tags:          []string{"alerts"},
+		DefaultStatus: http.StatusCreated,
+	}, alertHandler.HumaCreateAlert)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getAlert",
+		Method:      "GET",
+		Path:        "/alerts/{alertId}",
+		Summary:     "Get price alert",
+		Description: "Get a price alert without its webhook secret",
+		Tags:        []string{"alerts"},
+	}, alertHandler.HumaGetAlert)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "deleteAlert",
+		Method:        "DELETE",
+		Path:          "/alerts/{alertId}",
+		Summary:       "Delete price alert",
+		Description:   "Delete a price alert and its delivery log",
+		Tags:          []string{"alerts"},
+		DefaultStatus: http.StatusNoContent,
+	}, alertHandler.HumaDeleteAlert)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "listAlertDeliveries",
+		Method:      "GET",
+		Path:        "/alerts/{alertId}/deliveries",
+		Summary:     "List alert deliveries",
+		Description: "List the latest webhook deliveries of a price alert, newest first",
+		Tags:        []string{"alerts"},
+	}, alertHandler.HumaListAlertDeliveries)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "evaluateAlert",
+		Method:      "POST",
+		Path:        "/alerts/{alertId}/evaluate",
+		Summary:     "Evaluate price alert",
+		Description: "Evaluate a price alert now instead of waiting for the background evaluator and return its delivery log. Requires Authorization: Bearer <secret> with the alert's webhook secret.",
+		Tags:        []string{"alerts"},
+	}, alertHandler.HumaEvaluateAlert)
+
+	// Reservierungen und Buchungen
+	huma.Register(api, huma.Operation{
+		OperationID:   "holdBooking",
+		Method:        "POST",
+		Path:          "/bookings",
+		Summary:       "Hold offer",
+		Description:   "Reserve an offer at its current price for a limited time; 409 if the offer is gone or its price differs from expectedPrice",
+		Tags:          []string{"bookings"},
+		DefaultStatus: http.StatusCreated,
+	}, bookingHandler.HumaHoldBooking)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getBooking",
+		Method:      "GET",
+		Path:        "/bookings/{bookingId}",
+		Summary:     "Get booking",
+		Description: "Get a hold or booking with the offer's current price",
+		Tags:        []string{"bookings"},
+	}, bookingHandler.HumaGetBooking)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "confirmBooking",
+		Method:      "POST",
+		Path:        "/bookings/{bookingId}/confirm",
+		Summary:     "Confirm booking",
+		Description: "Confirm a hold; 409 if it expired, was cancelled, or the offer is gone or its price changed",
+		Tags:        []string{"bookings"},
+	}, bookingHandler.HumaConfirmBooking)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "cancelBooking",
+		Method:      "POST",
+		Path:        "/bookings/{bookingId}/cancel",
+		Summary:     "Cancel booking",
+		Description: "Cancel a hold or a confirmed booking",
+		Tags:        []string{"bookings"},
+	}, bookingHandler.HumaCancelBooking)
+
+	// Wechselkurse
+	huma.Register(api, huma.Operation{
+		OperationID: "getRates",
+		Method:      "GET",
+		Path:        "/rates",
+		Summary:     "Get exchange rates",
+		Description: "Get the exchange rates available for the currency parameter (units per Euro) and their date",
+		Tags:        []string{"rates"},
+	}, ratesHandler.HumaGetRates)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "replaceRates",
+		Method:      "PUT",
+		Path:        "/admin/rates",
+		Summary:     "Replace exchange rates",
+		Description: "Replace the exchange rate table; requires Authorization: Bearer <ADMIN_TOKEN>, 403 if no admin token is configured",
+		Tags:        []string{"rates"},
+	}, ratesHandler.HumaReplaceRates)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "getStats",
 		Method:      "GET",
 		Path:        "/api/stats",
 		Summary:     "Get statistics",
-		Description: "Retrieve data statistics",
+		Description: "Retrieve data statistics. Each offer id (hash of all offer columns except the price) names exactly one offer: of several rows with the same id only the cheapest is kept, duplicate_offers counts the dropped rows.",
 		Tags:        []string{"stats"},
 	}, hotelHandler.HumaGetStats)
 
@@ -163,3 +423,64 @@ func main() {
 
 	log.Fatal(app.Listen(":" + cfg.Port))
 }
+
+// ensureHotelsInScylla stellt sicher, dass Hotels in der DB sind (kleine Tabelle); importiert bei Bedarf aus CSV
+func ensureHotelsInScylla(cfg *config.Config, loc *time.Location, session *gocql.Session) {
+	var hotelsCount int64
+	if err := session.Query(`SELECT COUNT(*) FROM hotels`).Scan(&hotelsCount); err != nil {
+		log.Printf("Warnung: COUNT(*) hotels fehlgeschlagen: %v", err)
+	}
+	if hotelsCount != 0 {
+		return
+	}
+	fmt.Println("Hotels-Tabelle leer, importiere aus CSV...")
+	di := importer.NewDataImporter(cfg.HotelsDataPath, cfg.OffersDataPath, loc)
+	hotels, err := di.LoadHotels()
+	if err != nil {
+		log.Fatalf("Fehler beim Laden der Hotel-Daten: %v", err)
+	}
+	// batch insert
+	b := session.NewBatch(gocql.UnloggedBatch)
+	for _, h := range hotels {
+		b.Query(`INSERT INTO hotels (hotelid, hotelname, hotelstars) VALUES (?,?,?)`, h.ID, h.Name, h.Stars)
+	}
+	if err := session.ExecuteBatch(b); err != nil {
+		log.Fatalf("Fehler beim Import der Hotels: %v", err)
+	}
+	fmt.Printf("✓ %d Hotels in Scylla importiert\n", len(hotels))
+}
+
+// newMemoryStorage lädt Hotels aus der CSV und alle Angebote (CSV oder Scylla) in den spaltenbasierten Index
+func newMemoryStorage(cfg *config.Config, loc *time.Location) *storage.MemoryStorage {
+	start := time.Now()
+	di := importer.NewDataImporter(cfg.HotelsDataPath, cfg.OffersDataPath, loc)
+	hotels, err := di.LoadHotels()
+	if err != nil {
+		log.Fatalf("Fehler beim Laden der Hotel-Daten: %v", err)
+	}
+	mem := storage.NewMemoryStorage(hotels)
+
+	switch cfg.MemorySource {
+	case config.SourceCSV:
+		fmt.Printf("Lade Angebote aus %s in den Speicher...\n", cfg.OffersDataPath)
+		if err := di.StreamOffersFromCSV(mem.AddOffers); err != nil {
+			log.Fatalf("Fehler beim Laden der Angebote: %v", err)
+		}
+	case config.SourceScylla:
+		session, err := storage.NewScyllaSession()
+		if err != nil {
+			log.Fatalf("Scylla Verbindung fehlgeschlagen: %v", err)
+		}
+		// Session wird nur für den initialen Scan benötigt
+		defer session.Close()
+		if err := mem.LoadFromScylla(session); err != nil {
+			log.Fatalf("Fehler beim Laden der Angebote aus Scylla: %v", err)
+		}
+	default:
+		log.Fatalf("Unbekannte MEMORY_SOURCE %q (erlaubt: %s, %s)", cfg.MemorySource, config.SourceCSV, config.SourceScylla)
+	}
+
+	mem.Build()
+	fmt.Printf("✓ In-Memory-Index bereit in %s\n", time.Since(start))
+	return mem
+}
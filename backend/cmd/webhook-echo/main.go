@@ -0,0 +1,60 @@
+// Command webhook-echo is a local stand-in for price alert webhooks: it prints every delivery,
+// verifies its signature if -secret is given and can fail the first requests to exercise retries.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"holiday-coding-challenge/backend/internal/alerts"
+)
+
+func main() {
+	addr := flag.String("addr", ":8095", "Listen address")
+	secret := flag.String("secret", "", "Alert secret to verify signatures with (empty = do not verify)")
+	fail := flag.Int("fail", 0, "Answer the first n requests with 503 to test retries")
+	flag.Parse()
+
+	var requests atomic.Int64
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("#%d %s %s alert=%s delivery=%s", n, r.Method, r.URL.Path, r.Header.Get(alerts.HeaderAlertID), r.Header.Get(alerts.HeaderDelivery))
+
+		if *secret != "" {
+			timestamp, _ := strconv.ParseInt(r.Header.Get(alerts.HeaderTimestamp), 10, 64)
+			if !alerts.VerifySignature(*secret, timestamp, body, r.Header.Get(alerts.HeaderSignature)) {
+				log.Printf("#%d invalid signature", n)
+				http.Error(w, "invalid signature", http.StatusUnauthorized)
+				return
+			}
+			log.Printf("#%d signature ok", n)
+		}
+		if n <= int64(*fail) {
+			log.Printf("#%d failing on purpose", n)
+			http.Error(w, "failing on purpose", http.StatusServiceUnavailable)
+			return
+		}
+
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, body, "", "  ") != nil {
+			pretty.Write(body)
+		}
+		fmt.Println(pretty.String())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Printf("webhook-echo listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,278 @@
+package alerts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/apierror"
+	"holiday-coding-challenge/backend/internal/models"
+	"holiday-coding-challenge/backend/internal/storage"
+)
+
+// maxWebhookHotels begrenzt die Hotels je Webhook; weitere werden bei der nächsten Auswertung gemeldet
+const maxWebhookHotels = 50
+
+// evaluateParallel ist die Anzahl gleichzeitig ausgewerteter Alarme
+const evaluateParallel = 4
+
+// NewAlert prüft die Eingabe (Datumsangaben der Suche in loc) und erstellt einen Alarm mit neuer
+// ID und neuem Secret. Webhook-URLs mit Loopback- oder privater Adresse nur mit allowPrivate.
+func NewAlert(input models.ApiAlertInput, loc *time.Location, allowPrivate bool) (*models.Alert, error) {
+	if _, err := input.Search.ToSearchParams(loc); err != nil {
+		return nil, apierror.Wrap(apierror.CodeInvalidSearchParams, err)
+	}
+	u, err := url.Parse(input.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (!allowPrivate && checkWebhookHost(u.Hostname()) != nil) {
+		return nil, apierror.New(apierror.CodeInvalidWebhookURL)
+	}
+	return &models.Alert{
+		ID:          randomHex(16),
+		Search:      input.Search,
+		TargetPrice: input.TargetPrice,
+		WebhookURL:  input.WebhookURL,
+		Secret:      randomHex(32),
+		CreatedAt:   time.Now().UTC(),
+		Notified:    map[int]float64{},
+	}, nil
+}
+
+// randomHex liefert n zufällige Bytes hexkodiert. Ohne Zufallsquelle gäbe es keine sicheren IDs
+// und Secrets, daher bricht ein Fehler ab.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("alerts: crypto/rand: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// Evaluator wertet alle Alarme regelmäßig sowie nach jedem abgeschlossenen Angebotsimport aus
+// (erkannt an Storage.DataVersion) und benachrichtigt per Webhook
+type Evaluator struct {
+	storage  storage.Storage
+	alerts   storage.AlertStore
+	notifier *Notifier
+	// interval zwischen zwei regulären Auswertungen (0 = nur nach Importen), poll für DataVersion
+	interval time.Duration
+	poll     time.Duration
+	// loc ist die Zeitzone für reine Datumsangaben der gespeicherten Suchen
+	loc *time.Location
+	// locks serialisiert die Auswertungen je Alarm (Hintergrund und /evaluate)
+	locks alertLocks
+}
+
+// alertLocks hält je gerade ausgewerteten Alarm eine Sperre; Einträge werden mit der letzten
+// Freigabe entfernt
+type alertLocks struct {
+	mu    sync.Mutex
+	locks map[string]*alertLock
+}
+
+type alertLock struct {
+	ch   chan struct{} // Kapazität 1: belegt = gesperrt
+	refs int
+}
+
+// lock sperrt den Alarm id; wartet höchstens bis ctx endet. unlock gibt die Sperre frei.
+func (l *alertLocks) lock(ctx context.Context, id string) (unlock func(), err error) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*alertLock)
+	}
+	al := l.locks[id]
+	if al == nil {
+		al = &alertLock{ch: make(chan struct{}, 1)}
+		l.locks[id] = al
+	}
+	al.refs++
+	l.mu.Unlock()
+
+	release := func() {
+		l.mu.Lock()
+		if al.refs--; al.refs == 0 {
+			delete(l.locks, id)
+		}
+		l.mu.Unlock()
+	}
+	select {
+	case al.ch <- struct{}{}:
+		return func() { <-al.ch; release() }, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+}
+
+// NewEvaluator erstellt einen Evaluator; gestartet wird er mit Run
+func NewEvaluator(s storage.Storage, alerts storage.AlertStore, notifier *Notifier, interval, poll time.Duration, loc *time.Location) *Evaluator {
+	return &Evaluator{
+		storage:  s,
+		alerts:   alerts,
+		notifier: notifier,
+		interval: interval,
+		poll:     poll,
+		loc:      loc,
+	}
+}
+
+// Run wertet beim Start, danach alle interval und bei jeder neuen DataVersion aus, bis ctx endet
+func (e *Evaluator) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.poll)
+	defer ticker.Stop()
+
+	var lastRun, lastVersion time.Time
+	for {
+		version, err := e.storage.DataVersion(ctx)
+		if err != nil {
+			log.Printf("alerts: data version: %v", err)
+			version = lastVersion
+		}
+		imported := !version.Equal(lastVersion)
+		due := lastRun.IsZero() || (e.interval > 0 && time.Since(lastRun) >= e.interval)
+		if imported || due {
+			start := time.Now()
+			n, err := e.EvaluateAll(ctx)
+			if err != nil {
+				log.Printf("alerts: evaluation failed: %v", err)
+			} else {
+				log.Printf("alerts: evaluated %d alerts in %s; data version %s", n, time.Since(start), version.Format(time.RFC3339))
+			}
+			lastRun, lastVersion = time.Now(), version
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EvaluateAll wertet alle Alarme aus und liefert ihre Anzahl. Fehler einzelner Alarme werden
+// protokolliert und brechen die Auswertung der übrigen nicht ab.
+func (e *Evaluator) EvaluateAll(ctx context.Context) (int, error) {
+	all, err := e.alerts.ListAlerts(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	sem := make(chan struct{}, evaluateParallel)
+	for i := range all {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return i, ctx.Err()
+		}
+		wg.Add(1)
+		go func(alert *models.Alert) {
+			defer func() { <-sem; wg.Done() }()
+			if err := e.Evaluate(ctx, alert); err != nil {
+				log.Printf("alerts: alert %s: %v", alert.ID, err)
+			}
+		}(&all[i])
+	}
+	return len(all), ctx.Err()
+}
+
+// Evaluate sucht die Hotels des Alarms unter dem Zielpreis und meldet neue Treffer sowie Hotels,
+// deren Preis seit der letzten Meldung weiter gefallen ist. Schlägt die Zustellung fehl, gelten die
+// Treffer als nicht gemeldet und werden bei der nächsten Auswertung erneut gesendet.
+// Auswertungen desselben Alarms laufen nacheinander und lesen den Alarm unter der Sperre neu, damit
+// ein Treffer nicht doppelt gemeldet wird; das gilt innerhalb eines Server-Prozesses.
+func (e *Evaluator) Evaluate(ctx context.Context, alert *models.Alert) error {
+	unlock, err := e.locks.lock(ctx, alert.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	alert, err = e.alerts.GetAlert(ctx, alert.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		// inzwischen gelöscht
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	params, err := alert.Search.ToSearchParams(e.loc)
+	if err != nil {
+		return fmt.Errorf("saved search: %w", err)
+	}
+	if params.MaxPrice == 0 || params.MaxPrice > alert.TargetPrice {
+		params.MaxPrice = alert.TargetPrice
+	}
+	results, err := e.storage.GetHotelsWithBestOffers(ctx, params)
+	if err != nil {
+		return err
+	}
+	results = models.SelectHotels(results, models.HotelOrder{SortBy: models.SortByPrice})
+
+	now := time.Now().UTC()
+	payload := models.AlertWebhookPayload{
+		DeliveryID:  randomHex(16),
+		AlertID:     alert.ID,
+		TargetPrice: alert.TargetPrice,
+		EvaluatedAt: now,
+	}
+	// Hotels über dem Zielpreis fallen heraus und werden beim nächsten Unterschreiten wieder gemeldet
+	notified := make(map[int]float64, len(alert.Notified))
+	for _, r := range results {
+		price := r.BestOffer.Price
+		if price >= alert.TargetPrice {
+			continue
+		}
+		previous, seen := alert.Notified[r.Hotel.ID]
+		if seen && price >= previous {
+			notified[r.Hotel.ID] = previous
+			continue
+		}
+		if len(payload.Hotels) == maxWebhookHotels {
+			if seen {
+				notified[r.Hotel.ID] = previous
+			}
+			continue
+		}
+		hit := models.AlertHotel{
+			Hotel:         r.Hotel,
+			OfferID:       r.BestOffer.ID,
+			Price:         price,
+			DepartureDate: r.BestOffer.DepartureDate.In(e.loc).Format("2006-01-02"),
+			ReturnDate:    r.BestOffer.ReturnDate.In(e.loc).Format("2006-01-02"),
+		}
+		if seen {
+			hit.PreviousPrice = previous
+		}
+		payload.Hotels = append(payload.Hotels, hit)
+		notified[r.Hotel.ID] = price
+	}
+
+	if len(payload.Hotels) > 0 {
+		delivery := e.notifier.Deliver(ctx, alert, payload)
+		if err := e.alerts.AddDelivery(ctx, delivery); err != nil {
+			log.Printf("alerts: delivery log of alert %s: %v", alert.ID, err)
+		}
+		if !delivery.Delivered {
+			for _, hit := range payload.Hotels {
+				if previous, seen := alert.Notified[hit.Hotel.ID]; seen {
+					notified[hit.Hotel.ID] = previous
+				} else {
+					delete(notified, hit.Hotel.ID)
+				}
+			}
+		}
+	}
+
+	err = e.alerts.SetNotified(ctx, alert.ID, notified, now)
+	if errors.Is(err, storage.ErrNotFound) {
+		// während der Auswertung gelöscht
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package alerts
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+	"holiday-coding-challenge/backend/internal/storage"
+)
+
+// evaluatorOffer ist ein 7-Nächte-Angebot von hotel zum Preis price
+func evaluatorOffer(hotel int, price float64) models.Offer {
+	dep := time.Date(2025, 8, 1, 6, 0, 0, 0, time.UTC)
+	ret := dep.AddDate(0, 0, 7)
+	return models.Offer{
+		HotelID:                  hotel,
+		DepartureDate:            dep,
+		ReturnDate:               ret,
+		CountAdults:              2,
+		Price:                    price,
+		OutboundDepartureAirport: "FRA",
+		OutboundArrivalAirport:   "PMI",
+		OutboundArrivalDateTime:  dep.Add(2 * time.Hour),
+		InboundDepartureAirport:  "PMI",
+		InboundArrivalAirport:    "FRA",
+		InboundArrivalDateTime:   ret.Add(2 * time.Hour),
+		RoomType:                 "double",
+	}
+}
+
+// evaluatorSetup legt drei Hotels an (bestes Angebot 300, 450 und 800) und einen Alarm mit
+// Zielpreis 500, dessen Webhook receiver erreicht
+func evaluatorSetup(t *testing.T, receiver *webhookReceiver, maxAttempts int) (*Evaluator, *storage.MemoryAlertStore, *models.Alert) {
+	s := storage.NewMemoryStorage([]models.Hotel{{ID: 1, Name: "Eins", Stars: 3}, {ID: 2, Name: "Zwei", Stars: 4}, {ID: 3, Name: "Drei", Stars: 5}})
+	s.AddOffers([]models.Offer{evaluatorOffer(1, 300), evaluatorOffer(2, 800), evaluatorOffer(3, 450)})
+	s.Build()
+
+	srv := httptest.NewServer(receiver)
+	t.Cleanup(srv.Close)
+	alert, err := NewAlert(models.ApiAlertInput{TargetPrice: 500, WebhookURL: srv.URL}, time.UTC, true)
+	if err != nil {
+		t.Fatalf("new alert: %v", err)
+	}
+	receiver.secret = alert.Secret
+	alerts := storage.NewMemoryAlertStore()
+	if err := alerts.SaveAlert(context.Background(), *alert); err != nil {
+		t.Fatalf("save alert: %v", err)
+	}
+	n, _ := testNotifier(maxAttempts, 5*time.Second)
+	return NewEvaluator(s, alerts, n, 0, time.Minute, time.UTC), alerts, alert
+}
+
+func TestEvaluateDeliversOnce(t *testing.T) {
+	receiver := &webhookReceiver{t: t}
+	e, alerts, alert := evaluatorSetup(t, receiver, 3)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := e.Evaluate(ctx, alert); err != nil {
+			t.Fatalf("evaluation %d: %v", i, err)
+		}
+	}
+	if receiver.count() != 1 {
+		t.Fatalf("receiver saw %d webhooks, want 1: a delivered hotel is not sent again", receiver.count())
+	}
+	var hotels []int
+	for _, h := range receiver.requests[0].Hotels {
+		hotels = append(hotels, h.Hotel.ID)
+	}
+	if !reflect.DeepEqual(hotels, []int{1, 3}) {
+		t.Errorf("webhook hotels %v, want [1 3] below the target price, cheapest first", hotels)
+	}
+
+	deliveries, err := alerts.ListDeliveries(ctx, alert.ID, 10)
+	if err != nil {
+		t.Fatalf("deliveries: %v", err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("deliveries %+v, want exactly one", deliveries)
+	}
+	if d := deliveries[0]; !d.Delivered || d.Attempts != 1 || d.StatusCode != http.StatusOK || d.ID != receiver.requests[0].DeliveryID ||
+		!reflect.DeepEqual(d.HotelIDs, []int{1, 3}) {
+		t.Errorf("delivery %+v does not record the webhook", d)
+	}
+	stored, err := alerts.GetAlert(ctx, alert.ID)
+	if err != nil {
+		t.Fatalf("get alert: %v", err)
+	}
+	if !reflect.DeepEqual(stored.Notified, map[int]float64{1: 300, 3: 450}) || stored.LastEvaluatedAt == nil {
+		t.Errorf("alert notified %v at %v, want hotels 1 and 3 with their prices", stored.Notified, stored.LastEvaluatedAt)
+	}
+}
+
+func TestEvaluateRedeliversAfterFailure(t *testing.T) {
+	receiver := &webhookReceiver{t: t, responses: []response{{status: http.StatusServiceUnavailable}}}
+	e, alerts, alert := evaluatorSetup(t, receiver, 1)
+	ctx := context.Background()
+
+	if err := e.Evaluate(ctx, alert); err != nil {
+		t.Fatalf("first evaluation: %v", err)
+	}
+	stored, _ := alerts.GetAlert(ctx, alert.ID)
+	if len(stored.Notified) != 0 {
+		t.Errorf("notified %v after a failed delivery, want none", stored.Notified)
+	}
+	if err := e.Evaluate(ctx, alert); err != nil {
+		t.Fatalf("second evaluation: %v", err)
+	}
+	if err := e.Evaluate(ctx, alert); err != nil {
+		t.Fatalf("third evaluation: %v", err)
+	}
+	if receiver.count() != 2 {
+		t.Errorf("receiver saw %d webhooks, want the failed one and one redelivery", receiver.count())
+	}
+
+	deliveries, _ := alerts.ListDeliveries(ctx, alert.ID, 10)
+	var delivered []bool
+	for _, d := range deliveries {
+		delivered = append(delivered, d.Delivered)
+	}
+	// neueste zuerst
+	if !reflect.DeepEqual(delivered, []bool{true, false}) {
+		t.Errorf("deliveries %+v, want a failed and then a successful one", deliveries)
+	}
+	if deliveries[0].ID == deliveries[1].ID {
+		t.Errorf("the redelivery reuses delivery id %s", deliveries[0].ID)
+	}
+}
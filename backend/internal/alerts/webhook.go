@@ -0,0 +1,224 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// Header der Webhook-Requests. Die Signatur ist "sha256=" + hex(HMAC-SHA256(secret, "<timestamp>.<body>")).
+const (
+	HeaderAlertID   = "X-Alert-Id"
+	HeaderDelivery  = "X-Alert-Delivery"
+	HeaderTimestamp = "X-Alert-Timestamp"
+	HeaderSignature = "X-Alert-Signature"
+)
+
+// Notifier stellt Webhooks zu und wiederholt fehlgeschlagene Zustellungen mit exponentiellem Backoff
+type Notifier struct {
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	// wait wartet d zwischen zwei Versuchen oder bis ctx endet; austauschbar für Tests
+	wait func(ctx context.Context, d time.Duration) error
+}
+
+// NewNotifier erstellt einen Notifier. client ist austauschbar (z. B. für Tests gegen einen lokalen
+// Server); nil verwendet NewWebhookClient mit 10s Timeout, der nur öffentliche Adressen erreicht.
+func NewNotifier(client *http.Client, maxAttempts int) *Notifier {
+	if client == nil {
+		client = NewWebhookClient(10*time.Second, false)
+	}
+	return &Notifier{
+		client:      client,
+		maxAttempts: max(maxAttempts, 1),
+		backoff:     time.Second,
+		maxBackoff:  time.Minute,
+		wait:        sleep,
+	}
+}
+
+// sleep wartet d oder bis ctx endet
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// errForbiddenAddress meldet ein Webhook-Ziel im lokalen oder privaten Netz
+var errForbiddenAddress = errors.New("webhook address is not public")
+
+// publicAddress meldet, ob ip ein zulässiges Webhook-Ziel ist: nicht Loopback, privat, link-local,
+// unspezifiziert oder Multicast
+func publicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsUnspecified() && !ip.IsMulticast()
+}
+
+// checkWebhookHost prüft den Host einer Webhook-URL ohne DNS-Auflösung: IP-Literale müssen
+// öffentlich sein, "localhost" ist verboten. Aufgelöste Namen prüft erst webhookClient beim Verbinden.
+func checkWebhookHost(host string) error {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return errForbiddenAddress
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !publicAddress(ip) {
+		return errForbiddenAddress
+	}
+	return nil
+}
+
+// NewWebhookClient erstellt den HTTP-Client der Zustellung. Die Zieladresse wird beim Verbinden
+// geprüft (net.Dialer.Control sieht die aufgelöste IP), sodass auch ein DNS-Rebinding nach dem
+// Anlegen des Alarms kein internes Ziel erreicht; das gilt auch für Weiterleitungen. Proxys aus der
+// Umgebung werden nicht verwendet, da sonst nur die Proxy-Adresse geprüft würde. allowPrivate
+// schaltet die Prüfung ab (nur für lokale Tests, siehe ALERTS_ALLOW_PRIVATE_WEBHOOKS).
+func NewWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !publicAddress(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", errForbiddenAddress, address)
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			if allowPrivate {
+				return nil
+			}
+			return checkWebhookHost(req.URL.Hostname())
+		},
+	}
+}
+
+// Sign berechnet die Signatur eines Webhook-Bodys
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature prüft eine Signatur in konstanter Zeit
+func VerifySignature(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Deliver sendet payload an den Webhook des Alarms. Netzwerkfehler, 408, 429 und 5xx werden bis zu
+// maxAttempts-mal wiederholt; das Ergebnis ist der Eintrag fürs Zustellprotokoll.
+func (n *Notifier) Deliver(ctx context.Context, alert *models.Alert, payload models.AlertWebhookPayload) models.AlertDelivery {
+	delivery := models.AlertDelivery{
+		ID:        payload.DeliveryID,
+		AlertID:   alert.ID,
+		CreatedAt: payload.EvaluatedAt,
+		HotelIDs:  make([]int, len(payload.Hotels)),
+	}
+	for i, h := range payload.Hotels {
+		delivery.HotelIDs[i] = h.Hotel.ID
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+
+	wait := n.backoff
+	for delivery.Attempts < n.maxAttempts {
+		delivery.Attempts++
+		status, retryAfter, err := n.post(ctx, alert, payload.DeliveryID, body)
+		delivery.StatusCode = status
+		delivery.Error = ""
+		if err != nil {
+			delivery.Error = err.Error()
+		} else if status < 200 || status > 299 {
+			delivery.Error = http.StatusText(status)
+		}
+		if delivery.Error == "" {
+			delivery.Delivered = true
+			return delivery
+		}
+		if !retryable(status, err) || delivery.Attempts == n.maxAttempts {
+			break
+		}
+
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		if err := n.wait(ctx, min(wait, n.maxBackoff)); err != nil {
+			delivery.Error = err.Error()
+			return delivery
+		}
+		wait *= 2
+	}
+	return delivery
+}
+
+// post sendet einen Zustellversuch; retryAfter ist ein vom Empfänger per Retry-After verlangter Abstand
+func (n *Notifier) post(ctx context.Context, alert *models.Alert, deliveryID string, body []byte) (status int, retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, alert.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "holiday-alerts/1.0")
+	req.Header.Set(HeaderAlertID, alert.ID)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(alert.Secret, timestamp, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	// Body verwerfen, damit die Verbindung wiederverwendet werden kann
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	return resp.StatusCode, retryAfter, nil
+}
+
+// retryable meldet, ob ein fehlgeschlagener Versuch wiederholt werden soll
+func retryable(status int, err error) bool {
+	if err != nil {
+		return true
+	}
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
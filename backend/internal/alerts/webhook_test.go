@@ -0,0 +1,323 @@
+package alerts
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"alertId":"a"}`)
+	// unabhängig berechnet: HMAC-SHA256(secret, "<timestamp>.<body>")
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := Sign("secret", 1700000000, body); got != want {
+		t.Fatalf("Sign = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{name: "valid", secret: "secret", timestamp: 1700000000, body: body, signature: want, want: true},
+		{name: "other secret", secret: "other", timestamp: 1700000000, body: body, signature: want},
+		{name: "other timestamp", secret: "secret", timestamp: 1700000001, body: body, signature: want},
+		{name: "other body", secret: "secret", timestamp: 1700000000, body: []byte(`{"alertId":"b"}`), signature: want},
+		{name: "without prefix", secret: "secret", timestamp: 1700000000, body: body, signature: strings.TrimPrefix(want, "sha256=")},
+		{name: "empty", secret: "secret", timestamp: 1700000000, body: body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifySignature(tt.secret, tt.timestamp, tt.body, tt.signature); got != tt.want {
+				t.Errorf("VerifySignature = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// response ist die Antwort des Testempfängers auf einen Zustellversuch
+type response struct {
+	status     int
+	retryAfter int
+	delay      time.Duration
+}
+
+// webhookReceiver antwortet der Reihe nach mit responses (danach 200) und prüft Header und Signatur
+// jedes Versuchs gegen secret
+type webhookReceiver struct {
+	t         *testing.T
+	secret    string
+	responses []response
+
+	mu       sync.Mutex
+	requests []models.AlertWebhookPayload
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	timestamp, err := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil || !VerifySignature(r.secret, timestamp, body, req.Header.Get(HeaderSignature)) {
+		r.t.Errorf("attempt with invalid signature %q (timestamp %q)", req.Header.Get(HeaderSignature), req.Header.Get(HeaderTimestamp))
+	}
+	var payload models.AlertWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		r.t.Errorf("body: %v", err)
+	}
+	if req.Header.Get(HeaderAlertID) != payload.AlertID || req.Header.Get(HeaderDelivery) != payload.DeliveryID {
+		r.t.Errorf("headers %v do not match payload %+v", req.Header, payload)
+	}
+
+	r.mu.Lock()
+	n := len(r.requests)
+	r.requests = append(r.requests, payload)
+	r.mu.Unlock()
+
+	resp := response{status: http.StatusOK}
+	if n < len(r.responses) {
+		resp = r.responses[n]
+	}
+	if resp.delay > 0 {
+		select {
+		case <-time.After(resp.delay):
+		case <-req.Context().Done():
+		}
+	}
+	if resp.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(resp.retryAfter))
+	}
+	w.WriteHeader(resp.status)
+}
+
+func (r *webhookReceiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+// testNotifier stellt ohne Adressprüfung zu und zeichnet die Wartezeiten auf, statt zu warten
+func testNotifier(maxAttempts int, timeout time.Duration) (*Notifier, *[]time.Duration) {
+	n := NewNotifier(NewWebhookClient(timeout, true), maxAttempts)
+	var waits []time.Duration
+	n.wait = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	return n, &waits
+}
+
+func testPayload(alert *models.Alert) models.AlertWebhookPayload {
+	return models.AlertWebhookPayload{
+		DeliveryID:  "delivery-1",
+		AlertID:     alert.ID,
+		TargetPrice: alert.TargetPrice,
+		EvaluatedAt: time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC),
+		Hotels:      []models.AlertHotel{{Hotel: models.Hotel{ID: 3, Name: "Drei"}, Price: 450}},
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	fail := func(status, n int) []response {
+		r := make([]response, n)
+		for i := range r {
+			r[i] = response{status: status}
+		}
+		return r
+	}
+	tests := []struct {
+		name        string
+		responses   []response
+		maxAttempts int
+		timeout     time.Duration
+
+		wantAttempts  int
+		wantDelivered bool
+		wantStatus    int
+		wantWaits     []time.Duration
+	}{
+		{name: "delivered at once", responses: []response{{status: http.StatusNoContent}}, maxAttempts: 3,
+			wantAttempts: 1, wantDelivered: true, wantStatus: http.StatusNoContent},
+		{name: "5xx then success", responses: []response{{status: 503}, {status: 500}}, maxAttempts: 5,
+			wantAttempts: 3, wantDelivered: true, wantStatus: 200, wantWaits: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "gives up after max attempts", responses: fail(500, 4), maxAttempts: 4,
+			wantAttempts: 4, wantStatus: 500, wantWaits: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}},
+		{name: "backoff capped", responses: fail(502, 8), maxAttempts: 8, wantAttempts: 8, wantStatus: 502,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second, time.Minute}},
+		{name: "retry-after", responses: []response{{status: 429, retryAfter: 5}}, maxAttempts: 3,
+			wantAttempts: 2, wantDelivered: true, wantStatus: 200, wantWaits: []time.Duration{5 * time.Second}},
+		{name: "retry-after capped", responses: []response{{status: 503, retryAfter: 3600}}, maxAttempts: 3,
+			wantAttempts: 2, wantDelivered: true, wantStatus: 200, wantWaits: []time.Duration{time.Minute}},
+		{name: "no retry on 4xx", responses: []response{{status: 400}}, maxAttempts: 3,
+			wantAttempts: 1, wantStatus: 400},
+		{name: "retries request timeout status", responses: []response{{status: 408}}, maxAttempts: 2,
+			wantAttempts: 2, wantDelivered: true, wantStatus: 200, wantWaits: []time.Duration{time.Second}},
+		{name: "retries client timeout", responses: []response{{status: 200, delay: 2 * time.Second}}, maxAttempts: 2, timeout: 100 * time.Millisecond,
+			wantAttempts: 2, wantDelivered: true, wantStatus: 200, wantWaits: []time.Duration{time.Second}},
+		{name: "client timeout on last attempt", responses: []response{{status: 200, delay: 2 * time.Second}}, maxAttempts: 1, timeout: 100 * time.Millisecond,
+			wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := &models.Alert{ID: "alert-1", Secret: "s3cret", TargetPrice: 500}
+			receiver := &webhookReceiver{t: t, secret: alert.Secret, responses: tt.responses}
+			srv := httptest.NewServer(receiver)
+			defer srv.Close()
+			alert.WebhookURL = srv.URL + "/hook"
+
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = 5 * time.Second
+			}
+			n, waits := testNotifier(tt.maxAttempts, timeout)
+			d := n.Deliver(context.Background(), alert, testPayload(alert))
+
+			if d.Attempts != tt.wantAttempts || d.Delivered != tt.wantDelivered || d.StatusCode != tt.wantStatus {
+				t.Errorf("delivery %+v, want %d attempts, delivered %v, status %d", d, tt.wantAttempts, tt.wantDelivered, tt.wantStatus)
+			}
+			if d.Delivered != (d.Error == "") {
+				t.Errorf("delivered %v with error %q", d.Delivered, d.Error)
+			}
+			if got := receiver.count(); got != tt.wantAttempts {
+				t.Errorf("receiver saw %d attempts, want %d", got, tt.wantAttempts)
+			}
+			if (len(*waits) > 0 || len(tt.wantWaits) > 0) && !reflect.DeepEqual(*waits, tt.wantWaits) {
+				t.Errorf("waits %v, want %v", *waits, tt.wantWaits)
+			}
+			if d.ID != "delivery-1" || d.AlertID != alert.ID || !reflect.DeepEqual(d.HotelIDs, []int{3}) {
+				t.Errorf("delivery %+v does not describe the payload", d)
+			}
+		})
+	}
+}
+
+func TestDeliverStopsWhenCanceled(t *testing.T) {
+	alert := &models.Alert{ID: "alert-1", Secret: "s3cret"}
+	receiver := &webhookReceiver{t: t, secret: alert.Secret, responses: []response{{status: 500}, {status: 500}}}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+	alert.WebhookURL = srv.URL
+
+	n, _ := testNotifier(5, 5*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	n.wait = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return sleep(ctx, d)
+	}
+	d := n.Deliver(ctx, alert, testPayload(alert))
+	if d.Delivered || d.Attempts != 1 || !strings.Contains(d.Error, context.Canceled.Error()) {
+		t.Errorf("delivery %+v, want one attempt ended by the cancellation", d)
+	}
+}
+
+func TestWebhookClientRejectsPrivateAddresses(t *testing.T) {
+	alert := &models.Alert{ID: "alert-1", Secret: "s3cret"}
+	receiver := &webhookReceiver{t: t, secret: alert.Secret}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+	alert.WebhookURL = srv.URL
+
+	// Standard-Client: die Loopback-Adresse des Testservers wird beim Verbinden abgelehnt
+	d := NewNotifier(nil, 1).Deliver(context.Background(), alert, testPayload(alert))
+	if d.Delivered || !strings.Contains(d.Error, errForbiddenAddress.Error()) {
+		t.Errorf("default client: delivery %+v, want %q", d, errForbiddenAddress)
+	}
+	if receiver.count() != 0 {
+		t.Errorf("default client reached the loopback server")
+	}
+
+	// nur ausdrücklich erlaubt
+	d = NewNotifier(NewWebhookClient(5*time.Second, true), 1).Deliver(context.Background(), alert, testPayload(alert))
+	if !d.Delivered || receiver.count() != 1 {
+		t.Errorf("allowPrivate client: delivery %+v, receiver saw %d requests", d, receiver.count())
+	}
+}
+
+func TestWebhookClientRejectsRedirectToPrivateHost(t *testing.T) {
+	client := NewWebhookClient(5*time.Second, false)
+	req := httptest.NewRequest(http.MethodPost, "http://127.0.0.1/hook", nil)
+	via := []*http.Request{httptest.NewRequest(http.MethodPost, "https://hooks.example.com/", nil)}
+	if err := client.CheckRedirect(req, via); !errors.Is(err, errForbiddenAddress) {
+		t.Errorf("redirect to loopback: got %v, want %v", err, errForbiddenAddress)
+	}
+	if err := NewWebhookClient(5*time.Second, true).CheckRedirect(req, via); err != nil {
+		t.Errorf("redirect to loopback with allowPrivate: %v", err)
+	}
+}
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1", false},
+		{"127.8.8.8", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.178.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := publicAddress(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("publicAddress(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAlertWebhookURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{url: "https://hooks.example.com/alert"},
+		{url: "http://127.0.0.1:8080/hook", wantErr: true},
+		{url: "http://127.0.0.1:8080/hook", allowPrivate: true},
+		{url: "http://localhost/hook", wantErr: true},
+		{url: "http://api.localhost/hook", wantErr: true},
+		{url: "http://[::1]/hook", wantErr: true},
+		{url: "http://169.254.169.254/latest", wantErr: true},
+		{url: "ftp://hooks.example.com/", wantErr: true, allowPrivate: true},
+		{url: "/relative", wantErr: true, allowPrivate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			alert, err := NewAlert(models.ApiAlertInput{TargetPrice: 500, WebhookURL: tt.url}, time.UTC, tt.allowPrivate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewAlert(%q, allowPrivate %v): err %v, want error %v", tt.url, tt.allowPrivate, err, tt.wantErr)
+			}
+			if err == nil && (len(alert.ID) != 32 || len(alert.Secret) != 64) {
+				t.Errorf("alert %+v: want a 16-byte id and a 32-byte secret", alert)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package apierror
+
+import "net/http"
+
+// Unterstützte Sprachen; ohne passendes Accept-Language wird DefaultLanguage verwendet
+const (
+	LanguageGerman  = "de"
+	LanguageEnglish = "en"
+	DefaultLanguage = LanguageGerman
+)
+
+// languages sind alle Sprachen des Katalogs
+var languages = []string{LanguageGerman, LanguageEnglish}
+
+// Fehlercodes der API. Sie sind Teil der Schnittstelle und dürfen nicht umbenannt werden.
+const (
+	// Allgemein und von Huma erzeugt
+	CodeValidationFailed   = "validation_failed"
+	CodeInternal           = "internal_server_error"
+	CodeStorageUnavailable = "storage_unavailable"
+	CodeStorageTimeout     = "storage_timeout"
+
+	// Suche
+	CodeInvalidSearchParams = "invalid_search_params"
+	CodeInvalidDate         = "invalid_date"
+	CodeInvalidBoolean      = "invalid_boolean"
+	CodeRangeInverted       = "range_inverted"
+	CodeFlexDaysWithoutDate = "flex_days_without_date"
+	CodeTooManyRooms        = "too_many_rooms"
+	CodeInvalidRoom         = "invalid_room"
+	CodeRoomWithoutAdult    = "room_without_adult"
+	CodeInvalidRoomChildren = "invalid_room_children"
+	CodeRoomsWithTravellers = "rooms_with_travellers"
+	CodeInvalidCursor       = "invalid_cursor"
+	CodeTooManyBuckets      = "too_many_buckets"
+	CodeSearchTooBroad      = "search_too_broad"
+
+	// Ressourcen
+	CodeHotelNotFound         = "hotel_not_found"
+	CodeOfferNotFound         = "offer_not_found"
+	CodeShortlistItemNotFound = "shortlist_item_not_found"
+	CodeAlertNotFound         = "alert_not_found"
+	CodeBookingNotFound       = "booking_not_found"
+
+	// Merkzettel, Preisalarme, Buchungen
+	CodeOfferHotelMismatch = "offer_hotel_mismatch"
+	CodeOfferUnavailable   = "offer_unavailable"
+	CodeInvalidAlert       = "invalid_alert"
+	CodeInvalidWebhookURL  = "invalid_webhook_url"
+	CodeBookingExpired     = "booking_expired"
+	CodePriceChanged       = "price_changed"
+	CodeBookingStatus      = "booking_status"
+
+	// Währungen und Admin
+	CodeUnknownCurrency     = "unknown_currency"
+	CodeInvalidRates        = "invalid_rates"
+	CodeInvalidBaseCurrency = "invalid_base_currency"
+	CodeInvalidRateDate     = "invalid_rate_date"
+	CodeInvalidCurrencyCode = "invalid_currency_code"
+	CodeInvalidRate         = "invalid_rate"
+	CodeInvalidBaseRate     = "invalid_base_rate"
+	CodeAdminDisabled       = "admin_disabled"
+	CodeInvalidAdminToken   = "invalid_admin_token"
+	CodeInvalidAlertSecret  = "invalid_alert_secret"
+)
+
+// messages enthält je Code die Meldung je Sprache; {name} wird durch den Parameter name ersetzt
+var messages = map[string]map[string]string{
+	CodeValidationFailed: {
+		LanguageGerman:  "Ungültige Anfrage, siehe errors",
+		LanguageEnglish: "Invalid request, see errors",
+	},
+	CodeInternal: {
+		LanguageGerman:  "Unerwarteter Fehler",
+		LanguageEnglish: "Unexpected error",
+	},
+	CodeStorageUnavailable: {
+		LanguageGerman:  "Datenquelle nicht verfügbar",
+		LanguageEnglish: "Data source unavailable",
+	},
+	CodeStorageTimeout: {
+		LanguageGerman:  "Zeitüberschreitung bei der Datenabfrage",
+		LanguageEnglish: "Data query timed out",
+	},
+
+	CodeInvalidSearchParams: {
+		LanguageGerman:  "Ungültige Such-Parameter",
+		LanguageEnglish: "Invalid search parameters",
+	},
+	CodeInvalidDate: {
+		LanguageGerman:  "{param}: ungültiges Datum {value}, erwartet YYYY-MM-DD oder RFC 3339",
+		LanguageEnglish: "{param}: invalid date {value}, expected YYYY-MM-DD or RFC 3339",
+	},
+	CodeInvalidBoolean: {
+		LanguageGerman:  "{param}: erwartet true oder false",
+		LanguageEnglish: "{param}: expected true or false",
+	},
+	CodeRangeInverted: {
+		LanguageGerman:  "{min} darf nicht größer als {max} sein",
+		LanguageEnglish: "{min} must not be greater than {max}",
+	},
+	CodeFlexDaysWithoutDate: {
+		LanguageGerman:  "flexDays erfordert earliestDepartureDate",
+		LanguageEnglish: "flexDays requires earliestDepartureDate",
+	},
+	CodeTooManyRooms: {
+		LanguageGerman:  "höchstens {max} Zimmer erlaubt",
+		LanguageEnglish: "at most {max} rooms allowed",
+	},
+	CodeInvalidRoom: {
+		LanguageGerman:  "Zimmer {room}: erwartet Erwachsene:Kinder",
+		LanguageEnglish: "room {room}: expected adults:children",
+	},
+	CodeRoomWithoutAdult: {
+		LanguageGerman:  "Zimmer {room}: mindestens ein Erwachsener",
+		LanguageEnglish: "room {room}: at least one adult required",
+	},
+	CodeInvalidRoomChildren: {
+		LanguageGerman:  "Zimmer {room}: ungültige Anzahl Kinder",
+		LanguageEnglish: "room {room}: invalid number of children",
+	},
+	CodeRoomsWithTravellers: {
+		LanguageGerman:  "rooms kann nicht mit countAdults/countChildren kombiniert werden",
+		LanguageEnglish: "rooms cannot be combined with countAdults/countChildren",
+	},
+	CodeInvalidCursor: {
+		LanguageGerman:  "Ungültiger Cursor oder Filter seit der ersten Seite geändert",
+		LanguageEnglish: "Invalid cursor or filters changed since the first page",
+	},
+	CodeTooManyBuckets: {
+		LanguageGerman:  "Mehr als {max} Preisklassen, bitte bucketWidth erhöhen",
+		LanguageEnglish: "More than {max} price buckets, please increase bucketWidth",
+	},
+	CodeSearchTooBroad: {
+		LanguageGerman:  "Suche zu breit für diese Datenquelle, bitte departureAirports, countAdults und duration angeben",
+		LanguageEnglish: "Search too broad for this data source, please set departureAirports, countAdults and duration",
+	},
+
+	CodeHotelNotFound: {
+		LanguageGerman:  "Hotel nicht gefunden",
+		LanguageEnglish: "Hotel not found",
+	},
+	CodeOfferNotFound: {
+		LanguageGerman:  "Angebot nicht gefunden",
+		LanguageEnglish: "Offer not found",
+	},
+	CodeShortlistItemNotFound: {
+		LanguageGerman:  "Eintrag nicht gefunden",
+		LanguageEnglish: "Shortlist item not found",
+	},
+	CodeAlertNotFound: {
+		LanguageGerman:  "Alarm nicht gefunden",
+		LanguageEnglish: "Alert not found",
+	},
+	CodeBookingNotFound: {
+		LanguageGerman:  "Buchung nicht gefunden",
+		LanguageEnglish: "Booking not found",
+	},
+
+	CodeOfferHotelMismatch: {
+		LanguageGerman:  "Angebot gehört nicht zum angegebenen Hotel",
+		LanguageEnglish: "Offer does not belong to the given hotel",
+	},
+	CodeOfferUnavailable: {
+		LanguageGerman:  "Angebot nicht mehr verfügbar",
+		LanguageEnglish: "Offer no longer available",
+	},
+	CodeInvalidAlert: {
+		LanguageGerman:  "Ungültiger Preisalarm",
+		LanguageEnglish: "Invalid price alert",
+	},
+	CodeInvalidWebhookURL: {
+		LanguageGerman:  "webhookUrl muss eine absolute http(s)-URL mit öffentlicher Adresse sein",
+		LanguageEnglish: "webhookUrl must be an absolute http(s) URL with a public address",
+	},
+	CodeBookingExpired: {
+		LanguageGerman:  "Reservierung abgelaufen",
+		LanguageEnglish: "Hold expired",
+	},
+	CodePriceChanged: {
+		LanguageGerman:  "Preis hat sich geändert: {current} {currency} statt {held} {currency}",
+		LanguageEnglish: "Price changed: {current} {currency} instead of {held} {currency}",
+	},
+	CodeBookingStatus: {
+		LanguageGerman:  "Nicht möglich, Buchung ist {status}",
+		LanguageEnglish: "Not possible, booking is {status}",
+	},
+
+	CodeUnknownCurrency: {
+		LanguageGerman:  "Unbekannte Währung {currency}, siehe /rates",
+		LanguageEnglish: "Unknown currency {currency}, see /rates",
+	},
+	CodeInvalidRates: {
+		LanguageGerman:  "Ungültige Kurstabelle",
+		LanguageEnglish: "Invalid exchange rate table",
+	},
+	CodeInvalidBaseCurrency: {
+		LanguageGerman:  "Basiswährung muss {base} sein",
+		LanguageEnglish: "Base currency must be {base}",
+	},
+	CodeInvalidRateDate: {
+		LanguageGerman:  "ungültiges Kursdatum {date}, erwartet YYYY-MM-DD",
+		LanguageEnglish: "invalid rate date {date}, expected YYYY-MM-DD",
+	},
+	CodeInvalidCurrencyCode: {
+		LanguageGerman:  "ungültiger Währungscode {currency}",
+		LanguageEnglish: "invalid currency code {currency}",
+	},
+	CodeInvalidRate: {
+		LanguageGerman:  "Kurs für {currency} muss größer als 0 sein",
+		LanguageEnglish: "rate for {currency} must be greater than 0",
+	},
+	CodeInvalidBaseRate: {
+		LanguageGerman:  "Kurs für {currency} muss 1 sein",
+		LanguageEnglish: "rate for {currency} must be 1",
+	},
+	CodeAdminDisabled: {
+		LanguageGerman:  "Admin-Endpunkte sind deaktiviert (ADMIN_TOKEN nicht gesetzt)",
+		LanguageEnglish: "Admin endpoints are disabled (ADMIN_TOKEN not set)",
+	},
+	CodeInvalidAdminToken: {
+		LanguageGerman:  "Ungültiges Admin-Token",
+		LanguageEnglish: "Invalid admin token",
+	},
+	CodeInvalidAlertSecret: {
+		LanguageGerman:  "Fehlendes oder falsches Secret des Alarms",
+		LanguageEnglish: "Missing or wrong alert secret",
+	},
+}
+
+// titles sind die Titel der Problem Details je HTTP-Status
+var titles = map[int]map[string]string{
+	http.StatusBadRequest:            {LanguageGerman: "Ungültige Anfrage", LanguageEnglish: "Bad Request"},
+	http.StatusUnauthorized:          {LanguageGerman: "Nicht autorisiert", LanguageEnglish: "Unauthorized"},
+	http.StatusForbidden:             {LanguageGerman: "Verboten", LanguageEnglish: "Forbidden"},
+	http.StatusNotFound:              {LanguageGerman: "Nicht gefunden", LanguageEnglish: "Not Found"},
+	http.StatusMethodNotAllowed:      {LanguageGerman: "Methode nicht erlaubt", LanguageEnglish: "Method Not Allowed"},
+	http.StatusNotAcceptable:         {LanguageGerman: "Nicht akzeptabel", LanguageEnglish: "Not Acceptable"},
+	http.StatusConflict:              {LanguageGerman: "Konflikt", LanguageEnglish: "Conflict"},
+	http.StatusRequestEntityTooLarge: {LanguageGerman: "Anfrage zu groß", LanguageEnglish: "Request Entity Too Large"},
+	http.StatusUnsupportedMediaType:  {LanguageGerman: "Medientyp nicht unterstützt", LanguageEnglish: "Unsupported Media Type"},
+	http.StatusUnprocessableEntity:   {LanguageGerman: "Nicht verarbeitbar", LanguageEnglish: "Unprocessable Entity"},
+	http.StatusInternalServerError:   {LanguageGerman: "Interner Fehler", LanguageEnglish: "Internal Server Error"},
+	http.StatusServiceUnavailable:    {LanguageGerman: "Dienst nicht verfügbar", LanguageEnglish: "Service Unavailable"},
+	http.StatusGatewayTimeout:        {LanguageGerman: "Zeitüberschreitung", LanguageEnglish: "Gateway Timeout"},
+}
+
+// lookup liefert die Meldung zu code in lang, ersatzweise in der Standardsprache oder den Code
+func lookup(code, lang string) string {
+	if msg, ok := messages[code][lang]; ok {
+		return msg
+	}
+	if msg, ok := messages[code][DefaultLanguage]; ok {
+		return msg
+	}
+	return code
+}
+
+// title liefert den Titel zu status in lang, ersatzweise den englischen Status-Text
+func title(status int, lang string) string {
+	if t, ok := titles[status][lang]; ok {
+		return t
+	}
+	return http.StatusText(status)
+}
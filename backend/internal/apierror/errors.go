@@ -0,0 +1,81 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Error ist ein Fehler mit stabilem Code und benannten Parametern. Die Meldung steht im Katalog
+// (siehe catalog.go); Cause wird an die Meldung angehängt.
+type Error struct {
+	Code   string
+	Params map[string]any
+	Cause  error
+}
+
+// New erstellt einen Fehler; params sind abwechselnd Name und Wert
+func New(code string, params ...any) *Error {
+	return &Error{Code: code, Params: pairs(params)}
+}
+
+// Wrap erstellt einen Fehler mit Ursache
+func Wrap(code string, cause error, params ...any) *Error {
+	return &Error{Code: code, Params: pairs(params), Cause: cause}
+}
+
+// Error liefert die Meldung in der Standardsprache (für Logs)
+func (e *Error) Error() string {
+	return e.Message(DefaultLanguage)
+}
+
+// Unwrap liefert die Ursache
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Message liefert die übersetzte Meldung samt Ursache
+func (e *Error) Message(lang string) string {
+	msg := render(lookup(e.Code, lang), e.Params)
+	if e.Cause != nil {
+		msg += ": " + Message(e.Cause, lang)
+	}
+	return msg
+}
+
+// Message übersetzt err, falls es (oder eine Ursache) ein *Error ist; sonst err.Error()
+func Message(err error, lang string) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message(lang)
+	}
+	return err.Error()
+}
+
+func pairs(params []any) map[string]any {
+	if len(params) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(params)/2)
+	for i := 0; i+1 < len(params); i += 2 {
+		m[fmt.Sprint(params[i])] = params[i+1]
+	}
+	return m
+}
+
+// render ersetzt {name} durch den Parameter name; Beträge erscheinen mit zwei Nachkommastellen
+func render(template string, params map[string]any) string {
+	if len(params) == 0 {
+		return template
+	}
+	args := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		s := fmt.Sprint(value)
+		if f, ok := value.(float64); ok {
+			s = strconv.FormatFloat(f, 'f', 2, 64)
+		}
+		args = append(args, "{"+name+"}", s)
+	}
+	return strings.NewReplacer(args...).Replace(template)
+}
@@ -0,0 +1,77 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		params   map[string]any
+		want     string
+	}{
+		{name: "no params", template: "{min} bleibt", want: "{min} bleibt"},
+		{name: "string and int", template: "{param}: {value} (max {max})", params: map[string]any{"param": "rooms", "value": "9:0", "max": 4}, want: "rooms: 9:0 (max 4)"},
+		{name: "amount with two decimals", template: "höchstens {amount}", params: map[string]any{"amount": 12.5}, want: "höchstens 12.50"},
+		{name: "amount rounded", template: "{amount}", params: map[string]any{"amount": 0.125}, want: "0.12"},
+		{name: "unknown placeholder stays", template: "{a} {b}", params: map[string]any{"a": 1}, want: "1 {b}"},
+		{name: "repeated placeholder", template: "{a}-{a}", params: map[string]any{"a": "x"}, want: "x-x"},
+		{name: "value with braces is not expanded", template: "{a} {b}", params: map[string]any{"a": "{b}", "b": "y"}, want: "{b} y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := render(tt.template, tt.params); got != tt.want {
+				t.Errorf("render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		lang string
+		want string
+	}{
+		{name: "german", err: New(CodeRangeInverted, "min", "minPrice", "max", "maxPrice"), lang: LanguageGerman,
+			want: "minPrice darf nicht größer als maxPrice sein"},
+		{name: "english", err: New(CodeRangeInverted, "min", "minPrice", "max", "maxPrice"), lang: LanguageEnglish,
+			want: "minPrice must not be greater than maxPrice"},
+		{name: "unsupported language falls back", err: New(CodeTooManyRooms, "max", 4), lang: "fr",
+			want: "höchstens 4 Zimmer erlaubt"},
+		{name: "unknown code", err: New("no_such_code"), lang: LanguageEnglish, want: "no_such_code"},
+		{name: "cause is appended", err: Wrap(CodeTooManyRooms, errors.New("boom"), "max", 4), lang: LanguageEnglish,
+			want: "at most 4 rooms allowed: boom"},
+		{name: "wrapped error", err: fmt.Errorf("ctx: %w", New(CodeTooManyRooms, "max", 4)), lang: LanguageEnglish,
+			want: "at most 4 rooms allowed"},
+		{name: "plain error", err: errors.New("boom"), lang: LanguageEnglish, want: "boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Message(tt.err, tt.lang); got != tt.want {
+				t.Errorf("Message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCatalogComplete(t *testing.T) {
+	for code, byLang := range messages {
+		for _, lang := range languages {
+			if byLang[lang] == "" {
+				t.Errorf("%s: no %s message", code, lang)
+			}
+		}
+	}
+	for status, byLang := range titles {
+		for _, lang := range languages {
+			if byLang[lang] == "" {
+				t.Errorf("status %d: no %s title", status, lang)
+			}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package apierror
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// ContentType ist der Medientyp von Problem Details
+const ContentType = "application/problem+json"
+
+// Problem ist der Fehler-Body nach RFC 9457. Code ist stabil und maschinenlesbar; Title, Detail
+// und die Meldungen in Errors werden erst beim Schreiben der Antwort übersetzt (siehe Transform).
+type Problem struct {
+	Type     string           `json:"type" doc:"URI reference identifying the problem type: urn:problem:<code>"`
+	Title    string           `json:"title" doc:"Localized summary of the HTTP status"`
+	Status   int              `json:"status" doc:"HTTP status code"`
+	Detail   string           `json:"detail,omitempty" doc:"Localized explanation of this occurrence"`
+	Instance string           `json:"instance,omitempty" doc:"Path of the request"`
+	Code     string           `json:"code" doc:"Stable machine-readable error code"`
+	Params   map[string]any   `json:"params,omitempty" doc:"Values referenced by the message, for localization by the client"`
+	Errors   []*ProblemDetail `json:"errors,omitempty" doc:"Individual errors, e.g. failed validations or the cause of the problem"`
+
+	// err liefert Detail; ein *Error wird übersetzt, andere Fehler erscheinen unverändert
+	err error
+}
+
+// ProblemDetail ist ein einzelner Fehler eines Problems
+type ProblemDetail struct {
+	Code     string         `json:"code,omitempty" doc:"Stable error code, if known"`
+	Message  string         `json:"message" doc:"Error message, localized if code is set"`
+	Location string         `json:"location,omitempty" doc:"Where the error occurred, e.g. query.minPrice or body.rates"`
+	Value    any            `json:"value,omitempty" doc:"The offending value"`
+	Params   map[string]any `json:"params,omitempty" doc:"Values referenced by the message"`
+
+	err *Error
+}
+
+var _ huma.StatusError = (*Problem)(nil)
+
+// NewProblem erstellt ein Problem mit Code und Parametern (abwechselnd Name und Wert)
+func NewProblem(status int, code string, params ...any) *Problem {
+	return FromError(status, New(code, params...))
+}
+
+// WrapProblem erstellt ein Problem mit Code, dessen Ursache cause in Detail und Errors erscheint
+func WrapProblem(status int, code string, cause error, params ...any) *Problem {
+	return FromError(status, Wrap(code, cause, params...))
+}
+
+// FromError erstellt ein Problem aus err. Ist err ein *Error, bestimmt er Code und Parameter und
+// eine Ursache mit eigenem Code erscheint zusätzlich in Errors; sonst gilt der Code des Status.
+func FromError(status int, err error) *Problem {
+	p := &Problem{Status: status, Code: StatusCode(status), err: err}
+	var e *Error
+	if !errors.As(err, &e) {
+		return p
+	}
+	p.Code, p.Params = e.Code, e.Params
+	var cause *Error
+	if e.Cause != nil && errors.As(e.Cause, &cause) {
+		p.Errors = append(p.Errors, &ProblemDetail{Code: cause.Code, Params: cause.Params, err: cause})
+	}
+	return p
+}
+
+// NewHumaError ersetzt huma.NewError, damit auch von Huma erzeugte Fehler (Validierung, unbekannte
+// Fehler) als Problem ausgeliefert werden
+func NewHumaError(status int, msg string, errs ...error) huma.StatusError {
+	return FromStatus(status, msg, errs...)
+}
+
+// FromStatus erstellt ein Problem aus einer unübersetzten Meldung, z. B. von Huma oder Fiber.
+// Validierungsfehler und interne Fehler erhalten eigene Codes; Details zu internen Fehlern werden
+// nicht ausgegeben.
+func FromStatus(status int, msg string, errs ...error) *Problem {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return NewProblem(status, StatusCode(status))
+	case msg == "validation failed":
+		return withDetails(NewProblem(status, CodeValidationFailed), errs)
+	}
+	p := FromError(status, errors.New(msg))
+	return withDetails(p, errs)
+}
+
+func withDetails(p *Problem, errs []error) *Problem {
+	for _, err := range errs {
+		var (
+			detailer huma.ErrorDetailer
+			coded    *Error
+		)
+		switch {
+		case errors.As(err, &detailer):
+			d := detailer.ErrorDetail()
+			p.Errors = append(p.Errors, &ProblemDetail{Message: d.Message, Location: d.Location, Value: d.Value})
+		case errors.As(err, &coded):
+			p.Errors = append(p.Errors, &ProblemDetail{Code: coded.Code, Params: coded.Params, err: coded})
+		default:
+			p.Errors = append(p.Errors, &ProblemDetail{Message: err.Error()})
+		}
+	}
+	return p
+}
+
+// StatusCode ist der Code für Fehler ohne eigenen Code, abgeleitet aus dem HTTP-Status,
+// z. B. not_found oder request_entity_too_large
+func StatusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "http_" + strconv.Itoa(status)
+	}
+	return strings.ToLower(strings.NewReplacer(" ", "_", "-", "_").Replace(text))
+}
+
+// Error liefert die Meldung in der Standardsprache
+func (p *Problem) Error() string {
+	if p.err == nil {
+		return p.Code
+	}
+	return Message(p.err, DefaultLanguage)
+}
+
+// GetStatus liefert den HTTP-Status (huma.StatusError)
+func (p *Problem) GetStatus() int {
+	return p.Status
+}
+
+// ContentType liefert den Medientyp von Problem Details (huma.ContentTypeFilter)
+func (p *Problem) ContentType(string) string {
+	return ContentType
+}
+
+// Localize liefert eine in lang übersetzte Kopie für die Antwort auf instance
+func (p *Problem) Localize(lang, instance string) *Problem {
+	l := *p
+	l.Type = "urn:problem:" + p.Code
+	l.Title = title(p.Status, lang)
+	if p.err != nil {
+		l.Detail = Message(p.err, lang)
+	}
+	l.Instance = instance
+	l.Errors = make([]*ProblemDetail, len(p.Errors))
+	for i, d := range p.Errors {
+		ld := *d
+		if d.err != nil {
+			ld.Message = d.err.Message(lang)
+		}
+		l.Errors[i] = &ld
+	}
+	return &l
+}
+
+// Transform übersetzt Problem-Antworten in die per Accept-Language gewählte Sprache
+// (huma.Transformer); andere Antworten bleiben unverändert
+func Transform(ctx huma.Context, status string, v any) (any, error) {
+	p, ok := v.(*Problem)
+	if !ok {
+		return v, nil
+	}
+	lang := Negotiate(ctx.Header("Accept-Language"))
+	ctx.SetHeader("Content-Language", lang)
+	ctx.AppendHeader("Vary", "Accept-Language")
+	return p.Localize(lang, ctx.URL().Path), nil
+}
+
+// Negotiate wählt anhand eines Accept-Language-Headers (z. B. "en-US,en;q=0.9,de;q=0.8") die
+// bevorzugte unterstützte Sprache; Regionen werden ignoriert, sonst gilt DefaultLanguage
+func Negotiate(header string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if q > 0 && slices.Contains(languages, primary) {
+			candidates = append(candidates, candidate{primary, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+	// bei gleichem q gewinnt die Reihenfolge im Header
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
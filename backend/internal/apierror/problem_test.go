@@ -0,0 +1,80 @@
+package apierror
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", DefaultLanguage},
+		{"en", LanguageEnglish},
+		{"de", LanguageGerman},
+		{"EN-us", LanguageEnglish},
+		{"en-US,en;q=0.9,de;q=0.8", LanguageEnglish},
+		{"de;q=0.8,en;q=0.9", LanguageEnglish},
+		{"fr,en;q=0.5", LanguageEnglish},
+		{"fr,it", DefaultLanguage},
+		{"en;q=0,de;q=0.1", LanguageGerman},
+		{"en;q=0", DefaultLanguage},
+		{"en;q=0.5,de;q=0.5", LanguageEnglish}, // gleiches q: Reihenfolge im Header
+		{"de;q=0.5,en;q=0.5", LanguageGerman},
+		{"en; q=0.4, de ; q=0.6", LanguageGerman},
+		{"*", DefaultLanguage},
+		{" , ;q=1", DefaultLanguage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := Negotiate(tt.header); got != tt.want {
+				t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProblemLocalize(t *testing.T) {
+	p := WrapProblem(http.StatusBadRequest, CodeRangeInverted, New(CodeTooManyRooms, "max", 4), "min", "minPrice", "max", "maxPrice")
+	l := p.Localize(LanguageEnglish, "/api/hotels")
+
+	if l.Type != "urn:problem:"+CodeRangeInverted || l.Code != CodeRangeInverted || l.Instance != "/api/hotels" {
+		t.Errorf("got type %q, code %q, instance %q", l.Type, l.Code, l.Instance)
+	}
+	if want := "minPrice must not be greater than maxPrice: at most 4 rooms allowed"; l.Detail != want {
+		t.Errorf("detail %q, want %q", l.Detail, want)
+	}
+	if len(l.Errors) != 1 || l.Errors[0].Code != CodeTooManyRooms || l.Errors[0].Message != "at most 4 rooms allowed" {
+		t.Errorf("errors %+v", l.Errors)
+	}
+	// das Original bleibt unübersetzt, damit es in andere Sprachen übersetzt werden kann
+	if p.Detail != "" || p.Errors[0].Message != "" {
+		t.Errorf("Localize changed the problem: %+v", p)
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		msg      string
+		wantCode string
+	}{
+		{name: "internal errors hide the message", status: http.StatusInternalServerError, msg: "db password wrong", wantCode: "internal_server_error"},
+		{name: "validation", status: http.StatusUnprocessableEntity, msg: "validation failed", wantCode: CodeValidationFailed},
+		{name: "status code", status: http.StatusNotFound, msg: "no route", wantCode: "not_found"},
+		{name: "unknown status", status: 499, msg: "closed", wantCode: "http_499"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := FromStatus(tt.status, tt.msg)
+			if p.Code != tt.wantCode || p.Status != tt.status {
+				t.Errorf("got %d %q, want %d %q", p.Status, p.Code, tt.status, tt.wantCode)
+			}
+			if tt.status >= http.StatusInternalServerError && p.Localize(LanguageEnglish, "/").Detail == tt.msg {
+				t.Errorf("internal message leaked into detail")
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Zeitzonen auch ohne zoneinfo im Container
 )
 
 // Config enthält die Anwendungskonfiguration
@@ -10,18 +14,72 @@ type Config struct {
 	Port           string
 	HotelsDataPath string
 	OffersDataPath string
+	// StorageBackend wählt die Storage-Implementierung: "scylla" oder "memory"
+	StorageBackend string
+	// MemorySource legt fest, woher der Memory-Backend die Angebote lädt: "csv" oder "scylla"
+	MemorySource string
+	// AlertsInterval ist der Abstand regulärer Preisalarm-Auswertungen (0 = nur nach Importen),
+	// AlertsPoll wie oft auf abgeschlossene Importe geprüft wird
+	AlertsInterval time.Duration
+	AlertsPoll     time.Duration
+	// AlertsWebhookAttempts ist die maximale Anzahl Zustellversuche je Webhook
+	AlertsWebhookAttempts int
+	// AlertsAllowPrivateWebhooks erlaubt Webhooks an Loopback- und private Adressen (nur für lokale Tests)
+	AlertsAllowPrivateWebhooks bool
+	// BookingHold ist die Gültigkeit einer Reservierung bis zur Bestätigung
+	BookingHold time.Duration
+	// RatesFile ist die JSON-Datei mit den Wechselkursen; Änderungen über die Admin-API werden
+	// dorthin zurückgeschrieben
+	RatesFile string
+	// AdminToken schützt die Admin-Endpunkte; leer = Admin-Endpunkte deaktiviert
+	AdminToken string
+	// LocalTimezone ist die IANA-Zeitzone für Tage: reine Datumsgrenzen der Suche, Kalendertage
+	// und Zeiten ohne Offset im CSV-Import
+	LocalTimezone string
 }
 
+// Unterstützte Werte für StorageBackend und MemorySource
+const (
+	BackendScylla = "scylla"
+	BackendMemory = "memory"
+	SourceCSV     = "csv"
+	SourceScylla  = "scylla"
+)
+
 // Load lädt die Konfiguration aus Umgebungsvariablen
 func Load() *Config {
 	config := &Config{
 		Port:           getEnv("PORT", "8090"),
 		HotelsDataPath: getEnv("HOTELS_DATA_PATH", "../data/hotels.csv"),
 		OffersDataPath: getEnv("OFFERS_DATA_PATH", "../data/offers.csv"),
+		StorageBackend: strings.ToLower(getEnv("STORAGE_BACKEND", BackendScylla)),
+		MemorySource:   strings.ToLower(getEnv("MEMORY_SOURCE", SourceCSV)),
+
+		AlertsInterval:        time.Duration(getEnvAsInt("ALERTS_EVAL_INTERVAL_SECONDS", 900)) * time.Second,
+		AlertsPoll:            time.Duration(max(getEnvAsInt("ALERTS_POLL_SECONDS", 30), 1)) * time.Second,
+		AlertsWebhookAttempts: getEnvAsInt("ALERTS_WEBHOOK_MAX_ATTEMPTS", 5),
+
+		AlertsAllowPrivateWebhooks: strings.EqualFold(getEnv("ALERTS_ALLOW_PRIVATE_WEBHOOKS", "false"), "true"),
+
+		BookingHold: time.Duration(getEnvAsInt("BOOKING_HOLD_MINUTES", 15)) * time.Minute,
+
+		RatesFile:  getEnv("RATES_FILE", "../data/rates.json"),
+		AdminToken: os.Getenv("ADMIN_TOKEN"),
+
+		LocalTimezone: getEnv("LOCAL_TIMEZONE", "Europe/Berlin"),
 	}
 	return config
 }
 
+// Location lädt die Zeitzone LocalTimezone
+func (c *Config) Location() (*time.Location, error) {
+	loc, err := time.LoadLocation(c.LocalTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("LOCAL_TIMEZONE %q: %w", c.LocalTimezone, err)
+	}
+	return loc, nil
+}
+
 // getEnv gibt den Wert einer Umgebungsvariablen zurück oder einen Standardwert
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
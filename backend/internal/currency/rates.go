@@ -0,0 +1,161 @@
+package currency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/apierror"
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// ErrUnknownCurrency wird für Währungen ohne Kurs geliefert
+var ErrUnknownCurrency = errors.New("unbekannte Währung")
+
+var codePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Rates hält die aktuelle Kurstabelle. Sie wird aus einer JSON-Datei geladen und kann zur
+// Laufzeit ersetzt werden (Replace); mit Dateipfad wird die neue Tabelle auch dorthin geschrieben.
+type Rates struct {
+	mu    sync.RWMutex
+	table models.ExchangeRates
+	path  string
+}
+
+// NewRates erstellt eine Kurstabelle ohne Fremdwährungen (nur Euro), gespeichert unter path
+// (leer = nur im Speicher)
+func NewRates(path string) *Rates {
+	return &Rates{
+		table: models.ExchangeRates{Base: models.BaseCurrency, Date: time.Now().UTC().Format("2006-01-02"), Rates: map[string]float64{}},
+		path:  path,
+	}
+}
+
+// Load liest die Kurstabelle aus der Datei. Fehlt sie, bleibt die Tabelle leer (nur Euro).
+func (r *Rates) Load() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var table models.ExchangeRates
+	if err := json.Unmarshal(data, &table); err != nil {
+		return fmt.Errorf("%s: %w", r.path, err)
+	}
+	if err := Validate(&table); err != nil {
+		return fmt.Errorf("%s: %w", r.path, err)
+	}
+	r.mu.Lock()
+	r.table = table
+	r.mu.Unlock()
+	return nil
+}
+
+// Table liefert eine Kopie der aktuellen Kurstabelle
+func (r *Rates) Table() models.ExchangeRates {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	table := r.table
+	table.Rates = make(map[string]float64, len(r.table.Rates))
+	for code, rate := range r.table.Rates {
+		table.Rates[code] = rate
+	}
+	return table
+}
+
+// Conversion liefert die Umrechnung in die Währung code (Groß-/Kleinschreibung egal);
+// leer oder EUR ergibt die Identität
+func (r *Rates) Conversion(code string) (models.Conversion, error) {
+	code = strings.ToUpper(code)
+	if code == "" || code == models.BaseCurrency {
+		return models.Conversion{}, nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rate, ok := r.table.Rates[code]
+	if !ok {
+		return models.Conversion{}, fmt.Errorf("%w %s", ErrUnknownCurrency, code)
+	}
+	return models.Conversion{Currency: code, Rate: rate, Date: r.table.Date}, nil
+}
+
+// Replace prüft und übernimmt eine neue Kurstabelle; mit Dateipfad wird sie zuerst gespeichert
+func (r *Rates) Replace(table models.ExchangeRates) error {
+	if err := Validate(&table); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.path != "" {
+		if err := writeFile(r.path, table); err != nil {
+			return fmt.Errorf("Kurstabelle speichern: %w", err)
+		}
+	}
+	r.table = table
+	return nil
+}
+
+// Validate prüft eine Kurstabelle und normalisiert die Währungscodes auf Großbuchstaben.
+// Ein Eintrag für EUR muss 1 sein und wird entfernt.
+func Validate(table *models.ExchangeRates) error {
+	if table.Base == "" {
+		table.Base = models.BaseCurrency
+	}
+	if table.Base != models.BaseCurrency {
+		return apierror.New(apierror.CodeInvalidBaseCurrency, "base", models.BaseCurrency)
+	}
+	if _, err := time.Parse("2006-01-02", table.Date); err != nil {
+		return apierror.New(apierror.CodeInvalidRateDate, "date", table.Date)
+	}
+	rates := make(map[string]float64, len(table.Rates))
+	for code, rate := range table.Rates {
+		code = strings.ToUpper(code)
+		if !codePattern.MatchString(code) {
+			return apierror.New(apierror.CodeInvalidCurrencyCode, "currency", code)
+		}
+		if !(rate > 0) {
+			return apierror.New(apierror.CodeInvalidRate, "currency", code)
+		}
+		if code == models.BaseCurrency {
+			if rate != 1 {
+				return apierror.New(apierror.CodeInvalidBaseRate, "currency", code)
+			}
+			continue
+		}
+		rates[code] = rate
+	}
+	table.Rates = rates
+	return nil
+}
+
+// writeFile schreibt die Tabelle atomar (temporäre Datei und Umbenennen)
+func writeFile(path string, table models.ExchangeRates) error {
+	data, err := json.MarshalIndent(table, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".rates-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/alerts"
+	"holiday-coding-challenge/backend/internal/apierror"
+	"holiday-coding-challenge/backend/internal/models"
+	"holiday-coding-challenge/backend/internal/storage"
+)
+
+// alertDeliveriesLimit ist die Anzahl der gelieferten Einträge des Zustellprotokolls
+const alertDeliveriesLimit = 50
+
+// AlertHandler behandelt die Preisalarm-API
+type AlertHandler struct {
+	alerts    storage.AlertStore
+	evaluator *alerts.Evaluator
+	loc       *time.Location
+	// allowPrivateWebhooks lässt Webhook-URLs mit Loopback- und privaten Adressen zu
+	allowPrivateWebhooks bool
+}
+
+// NewAlertHandler erstellt einen neuen AlertHandler; reine Datumsangaben der Suche gelten in loc
+func NewAlertHandler(store storage.AlertStore, evaluator *alerts.Evaluator, loc *time.Location, allowPrivateWebhooks bool) *AlertHandler {
+	return &AlertHandler{
+		alerts:               store,
+		evaluator:            evaluator,
+		loc:                  loc,
+		allowPrivateWebhooks: allowPrivateWebhooks,
+	}
+}
+
+// HumaCreateAlert legt einen Preisalarm an; nur diese Antwort enthält das Webhook-Secret
+func (h *AlertHandler) HumaCreateAlert(ctx context.Context, input *struct {
+	Body models.ApiAlertInput
+}) (*models.AlertResponse, error) {
+	alert, err := alerts.NewAlert(input.Body, h.loc, h.allowPrivateWebhooks)
+	if err != nil {
+		return nil, apierror.WrapProblem(http.StatusBadRequest, apierror.CodeInvalidAlert, err)
+	}
+	if err := h.alerts.SaveAlert(ctx, *alert); err != nil {
+		return nil, storageError(err)
+	}
+	return &models.AlertResponse{Body: *alert}, nil
+}
+
+// HumaGetAlert liefert einen Alarm ohne Secret
+func (h *AlertHandler) HumaGetAlert(ctx context.Context, input *struct {
+	AlertID string `path:"alertId" maxLength:"64" doc:"Alert id"`
+}) (*models.AlertResponse, error) {
+	alert, err := h.alert(ctx, input.AlertID)
+	if err != nil {
+		return nil, err
+	}
+	alert.Secret = ""
+	return &models.AlertResponse{Body: *alert}, nil
+}
+
+// HumaDeleteAlert löscht einen Alarm samt Zustellprotokoll
+func (h *AlertHandler) HumaDeleteAlert(ctx context.Context, input *struct {
+	AlertID string `path:"alertId" maxLength:"64" doc:"Alert id"`
+}) (*struct{}, error) {
+	err := h.alerts.DeleteAlert(ctx, input.AlertID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apierror.NewProblem(http.StatusNotFound, apierror.CodeAlertNotFound)
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+	return nil, nil
+}
+
+// HumaListAlertDeliveries liefert das Zustellprotokoll, neueste Einträge zuerst
+func (h *AlertHandler) HumaListAlertDeliveries(ctx context.Context, input *struct {
+	AlertID string `path:"alertId" maxLength:"64" doc:"Alert id"`
+}) (*models.AlertDeliveriesResponse, error) {
+	if _, err := h.alert(ctx, input.AlertID); err != nil {
+		return nil, err
+	}
+	return h.deliveries(ctx, input.AlertID)
+}
+
+// HumaEvaluateAlert wertet einen Alarm sofort aus (z. B. zum Testen des Webhooks) und liefert
+// das Zustellprotokoll. Nur mit dem Secret des Alarms, da jede Auswertung einen Webhook auslösen kann.
+func (h *AlertHandler) HumaEvaluateAlert(ctx context.Context, input *struct {
+	AlertID       string `path:"alertId" maxLength:"64" doc:"Alert id"`
+	Authorization string `header:"Authorization" doc:"Bearer <secret> with the secret returned when the alert was created"`
+}) (*models.AlertDeliveriesResponse, error) {
+	alert, err := h.alert(ctx, input.AlertID)
+	if err != nil {
+		return nil, err
+	}
+	secret, ok := strings.CutPrefix(input.Authorization, "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(secret), []byte(alert.Secret)) != 1 {
+		return nil, apierror.NewProblem(http.StatusUnauthorized, apierror.CodeInvalidAlertSecret)
+	}
+	if err := h.evaluator.Evaluate(ctx, alert); err != nil {
+		return nil, storageError(err)
+	}
+	return h.deliveries(ctx, input.AlertID)
+}
+
+func (h *AlertHandler) alert(ctx context.Context, alertID string) (*models.Alert, error) {
+	alert, err := h.alerts.GetAlert(ctx, alertID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apierror.NewProblem(http.StatusNotFound, apierror.CodeAlertNotFound)
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+	return alert, nil
+}
+
+func (h *AlertHandler) deliveries(ctx context.Context, alertID string) (*models.AlertDeliveriesResponse, error) {
+	deliveries, err := h.alerts.ListDeliveries(ctx, alertID, alertDeliveriesLimit)
+	if err != nil {
+		return nil, storageError(err)
+	}
+	return &models.AlertDeliveriesResponse{Body: deliveries}, nil
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"holiday-coding-challenge/backend/internal/apierror"
+	"holiday-coding-challenge/backend/internal/currency"
+	"holiday-coding-challenge/backend/internal/models"
+	"holiday-coding-challenge/backend/internal/storage"
+)
+
+// BookingHandler behandelt Reservierungen und Buchungen
+type BookingHandler struct {
+	bookings *storage.BookingService
+	rates    *currency.Rates
+}
+
+// NewBookingHandler erstellt einen neuen BookingHandler
+func NewBookingHandler(bookings *storage.BookingService, rates *currency.Rates) *BookingHandler {
+	return &BookingHandler{bookings: bookings, rates: rates}
+}
+
+// HumaHoldBooking reserviert ein Angebot zum aktuellen Preis
+func (h *BookingHandler) HumaHoldBooking(ctx context.Context, input *struct {
+	Body models.ApiBookingHoldInput
+	models.ApiCurrencyParams
+}) (*models.BookingResponse, error) {
+	conv, err := conversion(h.rates, input.ApiCurrencyParams)
+	if err != nil {
+		return nil, err
+	}
+	booking, err := h.bookings.Hold(ctx, input.Body.OfferID, input.Body.ExpectedPrice, conv)
+	if err != nil {
+		return nil, bookingError(err, conv)
+	}
+	return bookingResponse(booking, conv), nil
+}
+
+// HumaGetBooking liefert eine Buchung mit aktuellem Angebotspreis
+func (h *BookingHandler) HumaGetBooking(ctx context.Context, input *struct {
+	BookingID string `path:"bookingId" maxLength:"64" doc:"Booking id"`
+	models.ApiCurrencyParams
+}) (*models.BookingResponse, error) {
+	conv, err := conversion(h.rates, input.ApiCurrencyParams)
+	if err != nil {
+		return nil, err
+	}
+	booking, err := h.bookings.Get(ctx, input.BookingID)
+	if err != nil {
+		return nil, bookingError(err, conv)
+	}
+	return bookingResponse(booking, conv), nil
+}
+
+// HumaConfirmBooking bestätigt eine Reservierung, sofern Angebot und Preis unverändert sind
+func (h *BookingHandler) HumaConfirmBooking(ctx context.Context, input *struct {
+	BookingID string `path:"bookingId" maxLength:"64" doc:"Booking id"`
+	models.ApiCurrencyParams
+}) (*models.BookingResponse, error) {
+	conv, err := conversion(h.rates, input.ApiCurrencyParams)
+	if err != nil {
+		return nil, err
+	}
+	booking, err := h.bookings.Confirm(ctx, input.BookingID)
+	if err != nil {
+		return nil, bookingError(err, conv)
+	}
+	return bookingResponse(booking, conv), nil
+}
+
+// HumaCancelBooking storniert eine Reservierung oder Buchung
+func (h *BookingHandler) HumaCancelBooking(ctx context.Context, input *struct {
+	BookingID string `path:"bookingId" maxLength:"64" doc:"Booking id"`
+	models.ApiCurrencyParams
+}) (*models.BookingResponse, error) {
+	conv, err := conversion(h.rates, input.ApiCurrencyParams)
+	if err != nil {
+		return nil, err
+	}
+	booking, err := h.bookings.Cancel(ctx, input.BookingID)
+	if err != nil {
+		return nil, bookingError(err, conv)
+	}
+	return bookingResponse(booking, conv), nil
+}
+
+// bookingResponse rechnet die Preise einer Buchung zur Anzeige in die angefragte Währung um
+func bookingResponse(booking *models.Booking, conv models.Conversion) *models.BookingResponse {
+	conv.Booking(booking)
+	return &models.BookingResponse{CurrencyHeaders: conv.Headers(), Body: *booking}
+}
+
+// bookingError ordnet Fehler des Buchungsablaufs einem HTTP-Status zu; Preise werden in der
+// angefragten Währung gemeldet
+func bookingError(err error, conv models.Conversion) error {
+	var (
+		priceErr  *storage.PriceChangedError
+		statusErr *storage.BookingStatusError
+	)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return apierror.NewProblem(http.StatusNotFound, apierror.CodeBookingNotFound)
+	case errors.Is(err, storage.ErrOfferUnavailable):
+		return apierror.NewProblem(http.StatusConflict, apierror.CodeOfferUnavailable)
+	case errors.Is(err, storage.ErrBookingExpired):
+		return apierror.NewProblem(http.StatusConflict, apierror.CodeBookingExpired)
+	case errors.As(err, &priceErr):
+		held, current := priceErr.Held, priceErr.Current
+		if priceErr.Currency == "" {
+			held, current = conv.Price(held), conv.Price(current)
+		}
+		symbol := "€"
+		if !conv.IsBase() {
+			symbol = conv.Currency
+		}
+		return apierror.NewProblem(http.StatusConflict, apierror.CodePriceChanged, "held", held, "current", current, "currency", symbol)
+	case errors.As(err, &statusErr):
+		return apierror.NewProblem(http.StatusConflict, apierror.CodeBookingStatus, "status", statusErr.Status)
+	}
+	return storageError(err)
+}
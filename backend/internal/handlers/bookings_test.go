@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/apierror"
+	"holiday-coding-challenge/backend/internal/currency"
+	"holiday-coding-challenge/backend/internal/models"
+	"holiday-coding-challenge/backend/internal/storage"
+)
+
+func TestBookingError(t *testing.T) {
+	usd := models.Conversion{Currency: "USD", Rate: 1.0833}
+	tests := []struct {
+		name       string
+		err        error
+		conv       models.Conversion
+		wantStatus int
+		wantCode   string
+		wantParams map[string]any
+	}{
+		{name: "unknown booking", err: storage.ErrNotFound, wantStatus: http.StatusNotFound, wantCode: apierror.CodeBookingNotFound},
+		{name: "wrapped not found", err: fmt.Errorf("get booking: %w", storage.ErrNotFound), wantStatus: http.StatusNotFound, wantCode: apierror.CodeBookingNotFound},
+		{name: "offer gone", err: storage.ErrOfferUnavailable, wantStatus: http.StatusConflict, wantCode: apierror.CodeOfferUnavailable},
+		{name: "expired", err: storage.ErrBookingExpired, wantStatus: http.StatusConflict, wantCode: apierror.CodeBookingExpired},
+		{name: "compare-and-set lost", err: &storage.BookingStatusError{Status: models.BookingStatusConfirmed},
+			wantStatus: http.StatusConflict, wantCode: apierror.CodeBookingStatus, wantParams: map[string]any{"status": "confirmed"}},
+		{name: "price changed in euro", err: &storage.PriceChangedError{Held: 19.99, Current: 24.5},
+			wantStatus: http.StatusConflict, wantCode: apierror.CodePriceChanged, wantParams: map[string]any{"held": 19.99, "current": 24.5, "currency": "€"}},
+		// Preise in Euro werden zur Anzeige umgerechnet
+		{name: "euro prices converted", err: &storage.PriceChangedError{Held: 19.99, Current: 24.5}, conv: usd,
+			wantStatus: http.StatusConflict, wantCode: apierror.CodePriceChanged, wantParams: map[string]any{"held": 21.66, "current": 26.54, "currency": "USD"}},
+		// bereits in der angefragten Währung (Hold mit expectedPrice)
+		{name: "converted prices kept", err: &storage.PriceChangedError{Held: 21.65, Current: 21.66, Currency: "USD"}, conv: usd,
+			wantStatus: http.StatusConflict, wantCode: apierror.CodePriceChanged, wantParams: map[string]any{"held": 21.65, "current": 21.66, "currency": "USD"}},
+		{name: "storage failure", err: errors.New("boom"), wantStatus: http.StatusServiceUnavailable, wantCode: apierror.CodeStorageUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p *apierror.Problem
+			if !errors.As(bookingError(tt.err, tt.conv), &p) {
+				t.Fatalf("bookingError(%v) is no problem", tt.err)
+			}
+			if p.Status != tt.wantStatus || p.Code != tt.wantCode {
+				t.Errorf("got %d %s, want %d %s", p.Status, p.Code, tt.wantStatus, tt.wantCode)
+			}
+			if tt.wantParams != nil && !reflect.DeepEqual(p.Params, tt.wantParams) {
+				t.Errorf("params %v, want %v", p.Params, tt.wantParams)
+			}
+		})
+	}
+}
+
+// bookingOffers serves one offer via GetOffer; every other Storage method is unused here
+type bookingOffers struct {
+	storage.Storage
+	offer models.Offer
+}
+
+func (s *bookingOffers) GetOffer(ctx context.Context, offerID string) (*models.Offer, error) {
+	if offerID != s.offer.ID {
+		return nil, storage.ErrNotFound
+	}
+	o := s.offer
+	return &o, nil
+}
+
+func TestBookingHandlerStatus(t *testing.T) {
+	offers := &bookingOffers{offer: models.Offer{ID: "1-00000000000000aa", HotelID: 1, Price: 19.99}}
+	rates := currency.NewRates("")
+	if err := rates.Replace(models.ExchangeRates{Base: models.BaseCurrency, Date: "2025-08-01", Rates: map[string]float64{"USD": 1.0833}}); err != nil {
+		t.Fatalf("rates: %v", err)
+	}
+	h := NewBookingHandler(storage.NewBookingService(offers, storage.NewMemoryBookingStore(), 15*time.Minute), rates)
+	ctx := context.Background()
+
+	hold := func(expected float64, currency string) (*models.BookingResponse, error) {
+		in := &struct {
+			Body models.ApiBookingHoldInput
+			models.ApiCurrencyParams
+		}{Body: models.ApiBookingHoldInput{OfferID: offers.offer.ID, ExpectedPrice: expected}, ApiCurrencyParams: models.ApiCurrencyParams{Currency: currency}}
+		return h.HumaHoldBooking(ctx, in)
+	}
+	confirm := func(id string) error {
+		_, err := h.HumaConfirmBooking(ctx, &struct {
+			BookingID string `path:"bookingId" maxLength:"64" doc:"Booking id"`
+			models.ApiCurrencyParams
+		}{BookingID: id})
+		return err
+	}
+	wantProblem := func(t *testing.T, err error, status int, code string) {
+		t.Helper()
+		var p *apierror.Problem
+		if !errors.As(err, &p) || p.Status != status || p.Code != code {
+			t.Fatalf("got %v, want %d %s", err, status, code)
+		}
+	}
+
+	// Reservierung mit dem in USD angezeigten Preis, abgerechnet in Euro
+	resp, err := hold(21.66, "usd")
+	if err != nil {
+		t.Fatalf("hold: %v", err)
+	}
+	if resp.Body.Price != 21.66 || resp.Body.Status != models.BookingStatusHeld {
+		t.Errorf("hold %+v, want held at 21.66 USD", resp.Body)
+	}
+	_, err = hold(19.99, "USD")
+	wantProblem(t, err, http.StatusConflict, apierror.CodePriceChanged)
+
+	if err := confirm(resp.Body.ID); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	wantProblem(t, confirm(resp.Body.ID), http.StatusConflict, apierror.CodeBookingStatus)
+	wantProblem(t, confirm("unknown"), http.StatusNotFound, apierror.CodeBookingNotFound)
+}
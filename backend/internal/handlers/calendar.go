@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"holiday-coding-challenge/backend/internal/apierror"
+	"holiday-coding-challenge/backend/internal/models"
+	"holiday-coding-challenge/backend/internal/storage"
+)
+
+// HumaGetPriceCalendar liefert den günstigsten Preis je Abflugtag, -woche oder -monat über alle Hotels
+func (h *HotelHandler) HumaGetPriceCalendar(ctx context.Context, input *struct {
+	Granularity string `query:"granularity" enum:"day,week,month" default:"day" doc:"Period per calendar entry"`
+	models.ApiSearchParams
+	models.ApiCurrencyParams
+}) (*models.PriceCalendarResponse, error) {
+	return h.priceCalendar(ctx, 0, input.Granularity, input.ApiSearchParams, input.ApiCurrencyParams)
+}
+
+// HumaGetHotelPriceCalendar liefert den Preiskalender eines Hotels
+func (h *HotelHandler) HumaGetHotelPriceCalendar(ctx context.Context, input *struct {
+	ID          int    `path:"hotelId" doc:"Hotel ID"`
+	Granularity string `query:"granularity" enum:"day,week,month" default:"day" doc:"Period per calendar entry"`
+	models.ApiSearchParams
+	models.ApiCurrencyParams
+}) (*models.PriceCalendarResponse, error) {
+	return h.priceCalendar(ctx, input.ID, input.Granularity, input.ApiSearchParams, input.ApiCurrencyParams)
+}
+
+func (h *HotelHandler) priceCalendar(ctx context.Context, hotelID int, granularity string, apiParams models.ApiSearchParams, apiCurrency models.ApiCurrencyParams) (*models.PriceCalendarResponse, error) {
+	conv, err := conversion(h.rates, apiCurrency)
+	if err != nil {
+		return nil, err
+	}
+	params, err := h.convertSearchParams(apiParams, conv)
+	if err != nil {
+		return nil, apierror.WrapProblem(http.StatusBadRequest, apierror.CodeInvalidSearchParams, err)
+	}
+
+	entries, err := storage.PriceCalendar(ctx, h.storage, params, hotelID, granularity, h.loc)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apierror.NewProblem(http.StatusNotFound, apierror.CodeHotelNotFound)
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	conv.Calendar(entries)
+	resp := &models.PriceCalendarResponse{CurrencyHeaders: conv.Headers()}
+	resp.Body.Granularity = granularity
+	resp.Body.Items = entries
+	return resp, nil
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"holiday-coding-challenge/backend/internal/apierror"
+	"holiday-coding-challenge/backend/internal/models"
+	"holiday-coding-challenge/backend/internal/storage"
+)
+
+// HumaGetPriceHistogram liefert die Preisverteilung einer Suche für den Preis-Slider
+func (h *HotelHandler) HumaGetPriceHistogram(ctx context.Context, input *struct {
+	BucketWidth float64 `query:"bucketWidth" minimum:"0.01" default:"100" doc:"Width of a price bucket in the requested currency; at least one cent, and the prices found may span at most 1000 buckets"`
+	Mode        string  `query:"mode" enum:"offers,hotels" default:"offers" doc:"Count every matching offer (offers) or each hotel's best price (hotels)"`
+	models.ApiSearchParams
+	models.ApiCurrencyParams
+}) (*models.PriceHistogramResponse, error) {
+	conv, err := conversion(h.rates, input.ApiCurrencyParams)
+	if err != nil {
+		return nil, err
+	}
+	params, err := h.convertSearchParams(input.ApiSearchParams, conv)
+	if err != nil {
+		return nil, apierror.WrapProblem(http.StatusBadRequest, apierror.CodeInvalidSearchParams, err)
+	}
+
+	histogram, err := storage.PriceHistogram(ctx, h.storage, params, input.BucketWidth, input.Mode, conv)
+	if errors.Is(err, storage.ErrTooManyBuckets) {
+		return nil, apierror.NewProblem(http.StatusBadRequest, apierror.CodeTooManyBuckets, "max", storage.MaxHistogramBuckets)
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	return &models.PriceHistogramResponse{CurrencyHeaders: conv.Headers(), Body: *histogram}, nil
+}
@@ -2,93 +2,262 @@ package handlers
 
 import (
 	"context"
-	"strconv"
-	"strings"
+	"errors"
+	"log"
+	"net/http"
 	"time"
 
+	"holiday-coding-challenge/backend/internal/apierror"
+	"holiday-coding-challenge/backend/internal/currency"
 	"holiday-coding-challenge/backend/internal/models"
 	"holiday-coding-challenge/backend/internal/storage"
-
-	"github.com/danielgtaylor/huma/v2"
-	"github.com/gofiber/fiber/v2"
 )
 
 // HotelHandler behandelt Hotel-bezogene API-Anfragen
 type HotelHandler struct {
-	storage storage.Storage
+	storage    storage.Storage
+	insights   *storage.InsightsCache
+	hotelIndex *storage.HotelIndex
+	rates      *currency.Rates
+	// loc ist die Zeitzone für reine Datumsangaben der Suche
+	loc *time.Location
 }
 
 // NewHotelHandler erstellt einen neuen HotelHandler
-func NewHotelHandler(storage storage.Storage) *HotelHandler {
+func NewHotelHandler(s storage.Storage, rates *currency.Rates, loc *time.Location) *HotelHandler {
 	return &HotelHandler{
-		storage: storage,
+		storage:    s,
+		insights:   storage.NewInsightsCache(s, loc),
+		hotelIndex: storage.NewHotelIndex(s),
+		rates:      rates,
+		loc:        loc,
 	}
 }
 
 // HumaGetHotelsWithBestOffers - Huma-kompatible Version
 func (h *HotelHandler) HumaGetHotelsWithBestOffers(ctx context.Context, input *struct {
 	models.ApiSearchParams
+	models.ApiRoomsParams
+	models.ApiHotelOrder
+	models.ApiCurrencyParams
 }) (*models.BestOffersByHotelResponse, error) {
-	params, err := h.convertSearchParams(input.ApiSearchParams)
+	conv, err := conversion(h.rates, input.ApiCurrencyParams)
+	if err != nil {
+		return nil, err
+	}
+	params, err := h.convertSearchParams(input.ApiSearchParams, conv)
 	if err != nil {
-		return nil, huma.Error400BadRequest("Ungültige Such-Parameter: " + err.Error())
+		return nil, apierror.WrapProblem(http.StatusBadRequest, apierror.CodeInvalidSearchParams, err)
+	}
+	rooms, err := convertRooms(input.ApiRoomsParams, params)
+	if err != nil {
+		return nil, apierror.WrapProblem(http.StatusBadRequest, apierror.CodeInvalidSearchParams, err)
 	}
 
-	hotels := h.storage.GetHotelsWithBestOffers(params)
+	var results []models.HotelWithBestOffer
+	if len(rooms) > 0 {
+		results, err = storage.MultiRoomBestOffers(ctx, h.storage, params, rooms)
+	} else {
+		results, err = h.storage.GetHotelsWithBestOffers(ctx, params)
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+	hotels := models.SelectHotels(results, convertHotelOrder(input.ApiHotelOrder))
 
 	// Konvertiere zu Frontend-kompatiblem Format
 	bestOffers := make([]models.BestHotelOffer, len(hotels))
 	for i, hotel := range hotels {
+		// Preise je Person/Nacht aus dem umgerechneten Gesamtpreis, wie bei Offer.MarshalJSON
+		best := *hotel.BestOffer
+		conv.Offer(&best)
 		bestOffers[i] = models.BestHotelOffer{
 			Hotel:                hotel.Hotel,
-			MinPrice:             hotel.BestOffer.Price,
-			DepartureDate:        hotel.BestOffer.OutboundArrivalDateTime.Format("2006-01-02"),
-			ReturnDate:           hotel.BestOffer.InboundArrivalDateTime.Format("2006-01-02"),
-			RoomType:             "", // TODO: Falls verfügbar in Offer-Struktur
-			MealType:             "", // TODO: Falls verfügbar in Offer-Struktur
-			CountAdults:          hotel.BestOffer.CountAdults,
-			CountChildren:        hotel.BestOffer.CountChildren,
-			Duration:             hotel.BestOffer.Duration(),
-			CountAvailableOffers: 1, // TODO: Tatsächliche Anzahl berechnen
+			OfferID:              best.ID,
+			MinPrice:             best.Price,
+			PricePerPerson:       models.RoundCents(best.PricePerPerson()),
+			PricePerNight:        models.RoundCents(best.PricePerNight()),
+			DepartureDate:        best.OutboundArrivalDateTime.In(h.loc).Format("2006-01-02"),
+			ReturnDate:           best.InboundArrivalDateTime.In(h.loc).Format("2006-01-02"),
+			RoomType:             best.RoomType,
+			MealType:             best.MealType,
+			CountAdults:          best.CountAdults,
+			CountChildren:        best.CountChildren,
+			Duration:             best.Duration(),
+			CountAvailableOffers: hotel.CountAvailableOffers,
+			CountCapped:          hotel.CountCapped,
+			Rooms:                best.Rooms,
 		}
 	}
 
-	resp := &models.BestOffersByHotelResponse{}
+	resp := &models.BestOffersByHotelResponse{CurrencyHeaders: conv.Headers()}
+	resp.TotalCount = len(results)
 	resp.Body = bestOffers
 
 	return resp, nil
 }
 
+// HumaGetFacets liefert die Facetten (Anzahl Hotels und Mindestpreis je Wert) zu einer Suche
+func (h *HotelHandler) HumaGetFacets(ctx context.Context, input *struct {
+	models.ApiSearchParams
+	models.ApiCurrencyParams
+}) (*models.FacetsResponse, error) {
+	conv, err := conversion(h.rates, input.ApiCurrencyParams)
+	if err != nil {
+		return nil, err
+	}
+	params, err := h.convertSearchParams(input.ApiSearchParams, conv)
+	if err != nil {
+		return nil, apierror.WrapProblem(http.StatusBadRequest, apierror.CodeInvalidSearchParams, err)
+	}
+
+	facets, err := h.storage.GetFacets(ctx, params)
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	conv.Facets(facets)
+	return &models.FacetsResponse{CurrencyHeaders: conv.Headers(), Body: *facets}, nil
+}
+
+// HumaSearchHotels sucht Hotels nach Namen (Präfix, Teilwort, Tippfehler-tolerant, ohne Akzente)
+func (h *HotelHandler) HumaSearchHotels(ctx context.Context, input *struct {
+	Q     string `query:"q" required:"true" minLength:"1" maxLength:"100" doc:"Part of the hotel name, e.g. \"iberostar muro\" or \"cala dor\""`
+	Limit int    `query:"limit" minimum:"1" maximum:"50" default:"10" doc:"Maximum number of results"`
+}) (*models.HotelSearchResponse, error) {
+	results, err := h.hotelIndex.Search(ctx, input.Q, input.Limit)
+	if err != nil {
+		return nil, storageError(err)
+	}
+	return &models.HotelSearchResponse{Body: results}, nil
+}
+
+// HumaGetHotel liefert ein Hotel mit Kennzahlen über alle seine Angebote
+func (h *HotelHandler) HumaGetHotel(ctx context.Context, input *struct {
+	ID int `path:"hotelId" doc:"Hotel ID"`
+	models.ApiCurrencyParams
+}) (*models.HotelDetailResponse, error) {
+	conv, err := conversion(h.rates, input.ApiCurrencyParams)
+	if err != nil {
+		return nil, err
+	}
+	hotel, err := h.storage.GetHotel(ctx, input.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apierror.NewProblem(http.StatusNotFound, apierror.CodeHotelNotFound)
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	insights, err := h.insights.Get(ctx, input.ID)
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	resp := &models.HotelDetailResponse{CurrencyHeaders: conv.Headers()}
+	resp.Body.Hotel = *hotel
+	resp.Body.Insights = *insights // Kopie, der Cache bleibt in Euro
+	conv.Insights(&resp.Body.Insights)
+	return resp, nil
+}
+
 // HumaGetOffersByHotel - Huma-kompatible Version
 func (h *HotelHandler) HumaGetOffersByHotel(ctx context.Context, input *struct {
-	ID int `path:"hotelId" doc:"Hotel ID"`
+	ID     int    `path:"hotelId" doc:"Hotel ID"`
+	Limit  int    `query:"limit" minimum:"0" maximum:"1000" doc:"Maximum number of offers per page; 0 returns all offers"`
+	Cursor string `query:"cursor" doc:"Opaque cursor from nextCursor of the previous page"`
 	models.ApiSearchParams
+	models.ApiRoomsParams
+	models.ApiCurrencyParams
 }) (*models.HotelOffersResponse, error) {
+	conv, err := conversion(h.rates, input.ApiCurrencyParams)
+	if err != nil {
+		return nil, err
+	}
+
 	// Prüfen, ob das Hotel existiert
-	hotel, exists := h.storage.GetHotel(input.ID)
-	if !exists {
-		return nil, huma.Error404NotFound("Hotel nicht gefunden")
+	hotel, err := h.storage.GetHotel(ctx, input.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apierror.NewProblem(http.StatusNotFound, apierror.CodeHotelNotFound)
+	}
+	if err != nil {
+		return nil, storageError(err)
 	}
 
 	// Such-Parameter konvertieren
-	params, err := h.convertSearchParams(input.ApiSearchParams)
+	params, err := h.convertSearchParams(input.ApiSearchParams, conv)
 	if err != nil {
-		return nil, huma.Error400BadRequest("Ungültige Such-Parameter: " + err.Error())
+		return nil, apierror.WrapProblem(http.StatusBadRequest, apierror.CodeInvalidSearchParams, err)
+	}
+	rooms, err := convertRooms(input.ApiRoomsParams, params)
+	if err != nil {
+		return nil, apierror.WrapProblem(http.StatusBadRequest, apierror.CodeInvalidSearchParams, err)
 	}
 
-	// Angebote für das Hotel abrufen
-	offers := h.storage.GetOffersByHotel(input.ID, params)
+	// Angebote (bzw. Mehrzimmer-Pakete) für das Hotel seitenweise abrufen; Hotel-Filter (Sterne)
+	// schließen alle Angebote aus
+	page := &models.OffersPage{Items: []models.Offer{}}
+	if hotel.Matches(params) {
+		if len(rooms) > 0 {
+			page, err = storage.MultiRoomOffersPage(ctx, h.storage, input.ID, params, rooms, input.Limit, input.Cursor)
+		} else {
+			page, err = h.storage.GetOffersPageByHotel(ctx, input.ID, params, input.Limit, input.Cursor)
+		}
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			return nil, apierror.NewProblem(http.StatusBadRequest, apierror.CodeInvalidCursor)
+		}
+		if err != nil {
+			return nil, storageError(err)
+		}
+	}
 
-	resp := &models.HotelOffersResponse{}
+	conv.Offers(page.Items)
+	resp := &models.HotelOffersResponse{CurrencyHeaders: conv.Headers()}
 	resp.Body.Hotel = *hotel // Dereferenziere den Pointer
-	resp.Body.Items = offers
+	resp.Body.Items = page.Items
+	resp.Body.NextCursor = page.NextCursor
+	resp.Body.TotalEstimate = page.TotalEstimate
 
 	return resp, nil
 }
 
+// HumaGetOffer liefert ein einzelnes Angebot anhand seiner stabilen ID
+func (h *HotelHandler) HumaGetOffer(ctx context.Context, input *struct {
+	ID string `path:"offerId" maxLength:"40" doc:"Offer id as returned in offer responses"`
+	models.ApiCurrencyParams
+}) (*models.OfferResponse, error) {
+	conv, err := conversion(h.rates, input.ApiCurrencyParams)
+	if err != nil {
+		return nil, err
+	}
+	offer, err := h.storage.GetOffer(ctx, input.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apierror.NewProblem(http.StatusNotFound, apierror.CodeOfferNotFound)
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+	hotel, err := h.storage.GetHotel(ctx, offer.HotelID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apierror.NewProblem(http.StatusNotFound, apierror.CodeHotelNotFound)
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	resp := &models.OfferResponse{CurrencyHeaders: conv.Headers()}
+	resp.Body.Hotel = *hotel
+	resp.Body.Offer = *offer
+	conv.Offer(&resp.Body.Offer)
+	return resp, nil
+}
+
 // HumaGetStats - Huma-kompatible Version
 func (h *HotelHandler) HumaGetStats(ctx context.Context, input *struct{}) (*models.StatsResponse, error) {
-	stats := h.storage.GetStats()
+	stats, err := h.storage.GetStats(ctx)
+	if err != nil {
+		return nil, storageError(err)
+	}
 
 	resp := &models.StatsResponse{}
 	resp.Body = stats
@@ -98,7 +267,10 @@ func (h *HotelHandler) HumaGetStats(ctx context.Context, input *struct{}) (*mode
 
 // HumaGetAirports returns available outbound departure airports
 func (h *HotelHandler) HumaGetAirports(ctx context.Context, input *struct{}) (*models.AirportsResponse, error) {
-	airports := h.storage.GetAvailableDepartureAirports()
+	airports, err := h.storage.GetAvailableDepartureAirports(ctx)
+	if err != nil {
+		return nil, storageError(err)
+	}
 	// ensure non-nil slice so JSON encodes [] instead of null
 	if airports == nil {
 		airports = []string{}
@@ -108,173 +280,61 @@ func (h *HotelHandler) HumaGetAirports(ctx context.Context, input *struct{}) (*m
 	return resp, nil
 }
 
-// convertSearchParams konvertiert Huma SearchParams zu models.SearchParams
-func (h *HotelHandler) convertSearchParams(params models.ApiSearchParams) (models.SearchParams, error) {
-	var result models.SearchParams
-
-	// Departure Airports
-	result.DepartureAirports = params.DepartureAirports
-
-	// Earliest Departure Date - unterstützt sowohl ISO-8601 DateTime als auch einfache Datums-Formate
-	if params.EarliestDepartureDate != "" {
-		var date time.Time
-		var err error
-
-		// Versuche zuerst ISO-8601 DateTime Format
-		if date, err = time.Parse(time.RFC3339, params.EarliestDepartureDate); err != nil {
-			// Fallback zu einfachem Datum
-			if date, err = time.Parse("2006-01-02", params.EarliestDepartureDate); err != nil {
-				return result, err
-			}
-		}
-		result.EarliestDepartureDate = date
-	}
-
-	// Latest Return Date - unterstützt sowohl ISO-8601 DateTime als auch einfache Datums-Formate
-	if params.LatestReturnDate != "" {
-		var date time.Time
-		var err error
-
-		// Versuche zuerst ISO-8601 DateTime Format
-		if date, err = time.Parse(time.RFC3339, params.LatestReturnDate); err != nil {
-			// Fallback zu einfachem Datum
-			if date, err = time.Parse("2006-01-02", params.LatestReturnDate); err != nil {
-				return result, err
-			}
-		}
-		result.LatestReturnDate = date
+// convertHotelOrder übernimmt die Sortier-Parameter; ohne order gilt die natürliche Richtung des Schlüssels
+func convertHotelOrder(o models.ApiHotelOrder) models.HotelOrder {
+	order := models.HotelOrder{SortBy: o.SortBy, Limit: o.Limit, Offset: o.Offset}
+	if order.SortBy == "" {
+		order.SortBy = models.SortByPrice
 	}
-
-	// Counts and Duration
-	result.CountAdults = params.CountAdults
-	result.CountChildren = params.CountChildren
-	result.Duration = params.Duration
-
-	return result, nil
-}
-
-// GetHotelsWithBestOffers gibt Hotels mit ihren besten Angeboten zurück
-// GET /api/hotels?departureAirports=FRA,MUC&earliestDepartureDate=2025-08-10&latestReturnDate=2025-08-31&countAdults=2&countChildren=0&duration=7
-func (h *HotelHandler) GetHotelsWithBestOffers(c *fiber.Ctx) error {
-	params, err := h.parseSearchParams(c)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Ungültige Such-Parameter: " + err.Error(),
-		})
+	switch o.Order {
+	case "asc":
+		order.Descending = false
+	case "desc":
+		order.Descending = true
+	default:
+		order.Descending = models.DefaultDescending(order.SortBy)
 	}
-
-	hotels := h.storage.GetHotelsWithBestOffers(params)
-
-	return c.JSON(fiber.Map{
-		"hotels": hotels,
-		"total":  len(hotels),
-		"params": params,
-	})
+	return order
 }
 
-// GetOffersByHotel gibt alle Angebote für ein bestimmtes Hotel zurück
-// GET /api/hotels/:id/offers?departureAirports=FRA&earliestDepartureDate=2025-08-10&latestReturnDate=2025-08-31&countAdults=2&countChildren=0&duration=7
-func (h *HotelHandler) GetOffersByHotel(c *fiber.Ctx) error {
-	// Hotel-ID aus der URL extrahieren
-	hotelIDStr := c.Params("id")
-	hotelID, err := strconv.Atoi(hotelIDStr)
+// convertRooms parst den rooms-Parameter; er ersetzt countAdults/countChildren
+func convertRooms(input models.ApiRoomsParams, params models.SearchParams) ([]models.Room, error) {
+	rooms, err := models.ParseRooms(input.Rooms)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Ungültige Hotel-ID",
-		})
+		return nil, err
 	}
-
-	// Prüfen, ob das Hotel existiert
-	hotel, exists := h.storage.GetHotel(hotelID)
-	if !exists {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Hotel nicht gefunden",
-		})
+	if len(rooms) > 0 && (params.CountAdults != 0 || params.CountChildren != 0) {
+		return nil, apierror.New(apierror.CodeRoomsWithTravellers)
 	}
+	return rooms, nil
+}
 
-	// Such-Parameter parsen
-	params, err := h.parseSearchParams(c)
+// convertSearchParams konvertiert Huma SearchParams zu models.SearchParams; Preisfilter werden
+// in der angefragten Währung angegeben und in Euro umgerechnet
+func (h *HotelHandler) convertSearchParams(params models.ApiSearchParams, conv models.Conversion) (models.SearchParams, error) {
+	result, err := params.ToSearchParams(h.loc)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Ungültige Such-Parameter: " + err.Error(),
-		})
+		return result, err
 	}
-
-	// Angebote für das Hotel abrufen
-	offers := h.storage.GetOffersByHotel(hotelID, params)
-
-	return c.JSON(fiber.Map{
-		"hotel":  hotel,
-		"offers": offers,
-		"total":  len(offers),
-		"params": params,
-	})
-}
-
-// GetStats gibt Statistiken über die geladenen Daten zurück
-// GET /api/stats
-func (h *HotelHandler) GetStats(c *fiber.Ctx) error {
-	stats := h.storage.GetStats()
-	return c.JSON(stats)
+	conv.SearchParams(&result)
+	return result, nil
 }
 
-// parseSearchParams parst die Such-Parameter aus der Query
-func (h *HotelHandler) parseSearchParams(c *fiber.Ctx) (models.SearchParams, error) {
-	var params models.SearchParams
-
-	// Departure Airports (komma-getrennt)
-	if departureAirports := c.Query("departureAirports"); departureAirports != "" {
-		airports := strings.Split(departureAirports, ",")
-		for i, airport := range airports {
-			airports[i] = strings.TrimSpace(airport)
-		}
-		params.DepartureAirports = airports
-	}
-
-	// Earliest Departure Date
-	if earliestDate := c.Query("earliestDepartureDate"); earliestDate != "" {
-		date, err := time.Parse("2006-01-02", earliestDate)
-		if err != nil {
-			return params, err
-		}
-		params.EarliestDepartureDate = date
+// storageStatus ordnet einen Storage-Fehler einem HTTP-Status und Fehlercode zu: zu breite
+// Suchen werden zu 422, Zeitüberschreitungen zu 504, alle anderen Ausfälle zu 503
+func storageStatus(err error) (int, string) {
+	if errors.Is(err, storage.ErrUnboundedSearch) {
+		return http.StatusUnprocessableEntity, apierror.CodeSearchTooBroad
 	}
-
-	// Latest Return Date
-	if latestDate := c.Query("latestReturnDate"); latestDate != "" {
-		date, err := time.Parse("2006-01-02", latestDate)
-		if err != nil {
-			return params, err
-		}
-		params.LatestReturnDate = date
-	}
-
-	// Count Adults
-	if countAdults := c.Query("countAdults"); countAdults != "" {
-		count, err := strconv.Atoi(countAdults)
-		if err != nil {
-			return params, err
-		}
-		params.CountAdults = count
-	}
-
-	// Count Children
-	if countChildren := c.Query("countChildren"); countChildren != "" {
-		count, err := strconv.Atoi(countChildren)
-		if err != nil {
-			return params, err
-		}
-		params.CountChildren = count
-	}
-
-	// Duration
-	if duration := c.Query("duration"); duration != "" {
-		dur, err := strconv.Atoi(duration)
-		if err != nil {
-			return params, err
-		}
-		params.Duration = dur
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, storage.ErrTimeout) {
+		return http.StatusGatewayTimeout, apierror.CodeStorageTimeout
 	}
+	return http.StatusServiceUnavailable, apierror.CodeStorageUnavailable
+}
 
-	return params, nil
+// storageError wandelt einen Storage-Fehler in ein Problem um. Details werden nur geloggt.
+func storageError(err error) error {
+	status, code := storageStatus(err)
+	log.Printf("storage: %v", err)
+	return apierror.NewProblem(status, code)
 }
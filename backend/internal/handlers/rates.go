@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"holiday-coding-challenge/backend/internal/apierror"
+	"holiday-coding-challenge/backend/internal/currency"
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// RatesHandler liefert und ersetzt die Wechselkurse
+type RatesHandler struct {
+	rates      *currency.Rates
+	adminToken string
+}
+
+// NewRatesHandler erstellt einen neuen RatesHandler; ohne adminToken ist das Ersetzen gesperrt
+func NewRatesHandler(rates *currency.Rates, adminToken string) *RatesHandler {
+	return &RatesHandler{rates: rates, adminToken: adminToken}
+}
+
+// HumaGetRates liefert die aktuelle Kurstabelle
+func (h *RatesHandler) HumaGetRates(ctx context.Context, input *struct{}) (*models.ExchangeRatesResponse, error) {
+	return &models.ExchangeRatesResponse{Body: h.rates.Table()}, nil
+}
+
+// HumaReplaceRates ersetzt die Kurstabelle (nur mit ADMIN_TOKEN)
+func (h *RatesHandler) HumaReplaceRates(ctx context.Context, input *struct {
+	Authorization string `header:"Authorization" doc:"Bearer <ADMIN_TOKEN>"`
+	Body          models.ExchangeRates
+}) (*models.ExchangeRatesResponse, error) {
+	if h.adminToken == "" {
+		return nil, apierror.NewProblem(http.StatusForbidden, apierror.CodeAdminDisabled)
+	}
+	token, ok := strings.CutPrefix(input.Authorization, "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+		return nil, apierror.NewProblem(http.StatusUnauthorized, apierror.CodeInvalidAdminToken)
+	}
+	err := h.rates.Replace(input.Body)
+	var invalid *apierror.Error
+	if errors.As(err, &invalid) {
+		return nil, apierror.WrapProblem(http.StatusBadRequest, apierror.CodeInvalidRates, err)
+	}
+	if err != nil {
+		log.Printf("rates: %v", err)
+		return nil, apierror.NewProblem(http.StatusInternalServerError, apierror.CodeInternal)
+	}
+	return &models.ExchangeRatesResponse{Body: h.rates.Table()}, nil
+}
+
+// conversion liefert die Umrechnung für den currency-Parameter; unbekannte Währungen ergeben 400
+func conversion(rates *currency.Rates, input models.ApiCurrencyParams) (models.Conversion, error) {
+	conv, err := rates.Conversion(input.Currency)
+	if errors.Is(err, currency.ErrUnknownCurrency) {
+		return conv, apierror.NewProblem(http.StatusBadRequest, apierror.CodeUnknownCurrency, "currency", strings.ToUpper(input.Currency))
+	}
+	return conv, err
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"holiday-coding-challenge/backend/internal/apierror"
+	"holiday-coding-challenge/backend/internal/currency"
+	"holiday-coding-challenge/backend/internal/models"
+	"holiday-coding-challenge/backend/internal/storage"
+)
+
+// ShortlistHandler behandelt die Merkzettel-API
+type ShortlistHandler struct {
+	storage    storage.Storage
+	shortlists storage.ShortlistStore
+	rates      *currency.Rates
+}
+
+// NewShortlistHandler erstellt einen neuen ShortlistHandler
+func NewShortlistHandler(s storage.Storage, shortlists storage.ShortlistStore, rates *currency.Rates) *ShortlistHandler {
+	return &ShortlistHandler{
+		storage:    s,
+		shortlists: shortlists,
+		rates:      rates,
+	}
+}
+
+// HumaListShortlistItems liefert alle Einträge mit aktuellem Preis und Status
+func (h *ShortlistHandler) HumaListShortlistItems(ctx context.Context, input *struct {
+	ListID string `path:"id" pattern:"^[A-Za-z0-9_-]{1,64}$" doc:"Shortlist id chosen by the client, e.g. a UUID"`
+	models.ApiCurrencyParams
+}) (*models.ShortlistItemsResponse, error) {
+	conv, err := conversion(h.rates, input.ApiCurrencyParams)
+	if err != nil {
+		return nil, err
+	}
+	items, err := h.shortlists.ListItems(ctx, input.ListID)
+	if err != nil {
+		return nil, storageError(err)
+	}
+	if err := storage.RefreshShortlistItems(ctx, h.storage, items); err != nil {
+		return nil, storageError(err)
+	}
+	conv.ShortlistItems(items)
+	return &models.ShortlistItemsResponse{CurrencyHeaders: conv.Headers(), Body: items}, nil
+}
+
+// HumaGetShortlistItem liefert einen Eintrag mit aktuellem Preis und Status
+func (h *ShortlistHandler) HumaGetShortlistItem(ctx context.Context, input *struct {
+	ListID string `path:"id" pattern:"^[A-Za-z0-9_-]{1,64}$" doc:"Shortlist id chosen by the client, e.g. a UUID"`
+	ItemID string `path:"itemId" doc:"Item id"`
+	models.ApiCurrencyParams
+}) (*models.ShortlistItemResponse, error) {
+	return h.shortlistItem(ctx, input.ListID, input.ItemID, input.ApiCurrencyParams)
+}
+
+func (h *ShortlistHandler) shortlistItem(ctx context.Context, listID, itemID string, apiCurrency models.ApiCurrencyParams) (*models.ShortlistItemResponse, error) {
+	conv, err := conversion(h.rates, apiCurrency)
+	if err != nil {
+		return nil, err
+	}
+	item, err := h.shortlists.GetItem(ctx, listID, itemID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apierror.NewProblem(http.StatusNotFound, apierror.CodeShortlistItemNotFound)
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+	items := []models.ShortlistItem{*item}
+	if err := storage.RefreshShortlistItems(ctx, h.storage, items); err != nil {
+		return nil, storageError(err)
+	}
+	return shortlistItemResponse(items[0], conv), nil
+}
+
+// HumaAddShortlistItem merkt ein Hotel oder ein Angebot; erneutes Merken überschreibt den Eintrag
+func (h *ShortlistHandler) HumaAddShortlistItem(ctx context.Context, input *struct {
+	ListID string `path:"id" pattern:"^[A-Za-z0-9_-]{1,64}$" doc:"Shortlist id chosen by the client, e.g. a UUID"`
+	Body   models.ApiShortlistItemInput
+	models.ApiCurrencyParams
+}) (*models.ShortlistItemResponse, error) {
+	conv, err := conversion(h.rates, input.ApiCurrencyParams)
+	if err != nil {
+		return nil, err
+	}
+	offer := input.Body.Offer
+	if offer != nil {
+		if offer.HotelID == 0 {
+			offer.HotelID = input.Body.HotelID
+		}
+		if offer.HotelID != input.Body.HotelID {
+			return nil, apierror.NewProblem(http.StatusBadRequest, apierror.CodeOfferHotelMismatch)
+		}
+	}
+	if _, err := h.storage.GetHotel(ctx, input.Body.HotelID); errors.Is(err, storage.ErrNotFound) {
+		return nil, apierror.NewProblem(http.StatusNotFound, apierror.CodeHotelNotFound)
+	} else if err != nil {
+		return nil, storageError(err)
+	}
+
+	item, err := storage.NewShortlistItem(ctx, h.storage, input.Body.HotelID, offer, input.Body.Note)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apierror.NewProblem(http.StatusConflict, apierror.CodeOfferUnavailable)
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+	if err := h.shortlists.SaveItem(ctx, input.ListID, *item); err != nil {
+		return nil, storageError(err)
+	}
+
+	items := []models.ShortlistItem{*item}
+	if err := storage.RefreshShortlistItems(ctx, h.storage, items); err != nil {
+		return nil, storageError(err)
+	}
+	return shortlistItemResponse(items[0], conv), nil
+}
+
+// HumaUpdateShortlistItem ändert die Notiz eines Eintrags
+func (h *ShortlistHandler) HumaUpdateShortlistItem(ctx context.Context, input *struct {
+	ListID string `path:"id" pattern:"^[A-Za-z0-9_-]{1,64}$" doc:"Shortlist id chosen by the client, e.g. a UUID"`
+	ItemID string `path:"itemId" doc:"Item id"`
+	Body   models.ApiShortlistNoteInput
+	models.ApiCurrencyParams
+}) (*models.ShortlistItemResponse, error) {
+	// Währung vor dem Speichern prüfen
+	if _, err := conversion(h.rates, input.ApiCurrencyParams); err != nil {
+		return nil, err
+	}
+	err := h.shortlists.UpdateNote(ctx, input.ListID, input.ItemID, input.Body.Note)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apierror.NewProblem(http.StatusNotFound, apierror.CodeShortlistItemNotFound)
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+	return h.shortlistItem(ctx, input.ListID, input.ItemID, input.ApiCurrencyParams)
+}
+
+// HumaDeleteShortlistItem entfernt einen Eintrag
+func (h *ShortlistHandler) HumaDeleteShortlistItem(ctx context.Context, input *struct {
+	ListID string `path:"id" pattern:"^[A-Za-z0-9_-]{1,64}$" doc:"Shortlist id chosen by the client, e.g. a UUID"`
+	ItemID string `path:"itemId" doc:"Item id"`
+}) (*struct{}, error) {
+	err := h.shortlists.DeleteItem(ctx, input.ListID, input.ItemID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apierror.NewProblem(http.StatusNotFound, apierror.CodeShortlistItemNotFound)
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+	return nil, nil
+}
+
+// shortlistItemResponse rechnet die Preise eines Eintrags in die angefragte Währung um
+func shortlistItemResponse(item models.ShortlistItem, conv models.Conversion) *models.ShortlistItemResponse {
+	items := []models.ShortlistItem{item}
+	conv.ShortlistItems(items)
+	return &models.ShortlistItemResponse{CurrencyHeaders: conv.Headers(), Body: items[0]}
+}
@@ -20,13 +20,16 @@ import (
 type DataImporter struct {
 	hotelsPath string
 	offersPath string
+	// loc ist die Zeitzone für Zeitangaben ohne Offset
+	loc *time.Location
 }
 
-// NewDataImporter erstellt einen neuen DataImporter
-func NewDataImporter(hotelsPath, offersPath string) *DataImporter {
+// NewDataImporter erstellt einen neuen DataImporter; Zeitangaben ohne Offset gelten in loc
+func NewDataImporter(hotelsPath, offersPath string, loc *time.Location) *DataImporter {
 	return &DataImporter{
 		hotelsPath: hotelsPath,
 		offersPath: offersPath,
+		loc:        loc,
 	}
 }
 
@@ -141,10 +144,29 @@ func (d *DataImporter) generateSampleOffers() []models.Offer {
 	return offers
 }
 
+// LoadOffersFromCSV lädt alle Angebote aus der CSV-Datei in einen Slice
 func (d *DataImporter) LoadOffersFromCSV() ([]models.Offer, error) {
+	var allOffers []models.Offer
+	err := d.StreamOffersFromCSV(func(batch []models.Offer) {
+		allOffers = append(allOffers, batch...)
+		// Regelmäßige Garbage Collection
+		if len(allOffers)%50000 == 0 {
+			runtime.GC()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allOffers, nil
+}
+
+// StreamOffersFromCSV parst die Angebots-Datei parallel und übergibt die Ergebnisse
+// batchweise an fn. fn wird nur aus einer Goroutine aufgerufen und muss daher nicht
+// synchronisiert sein; die Batches dürfen vom Aufrufer behalten werden.
+func (d *DataImporter) StreamOffersFromCSV(fn func(batch []models.Offer)) error {
 	file, err := os.Open(d.offersPath)
 	if err != nil {
-		return nil, fmt.Errorf("fehler beim Öffnen der Angebots-Datei: %w", err)
+		return fmt.Errorf("fehler beim Öffnen der Angebots-Datei: %w", err)
 	}
 	defer file.Close()
 
@@ -156,7 +178,7 @@ func (d *DataImporter) LoadOffersFromCSV() ([]models.Offer, error) {
 	// Header überspringen
 	_, err = reader.Read()
 	if err != nil {
-		return nil, fmt.Errorf("fehler beim Lesen des Headers: %w", err)
+		return fmt.Errorf("fehler beim Lesen des Headers: %w", err)
 	}
 
 	// Kleinere Batches für große Dateien
@@ -200,7 +222,6 @@ func (d *DataImporter) LoadOffersFromCSV() ([]models.Offer, error) {
 	totalRecords := 0
 	go func() {
 		defer close(batchChan)
-		defer close(progressChan)
 		batch := make([][]string, 0, batchSize)
 
 		for {
@@ -226,19 +247,16 @@ func (d *DataImporter) LoadOffersFromCSV() ([]models.Offer, error) {
 	}()
 
 	// Rest bleibt gleich...
+	// progressChan erst nach den Workern schließen, die noch Updates senden
 	go func() {
 		wg.Wait()
+		close(progressChan)
 		close(resultChan)
 		close(errorChan)
 	}()
 
-	var allOffers []models.Offer
 	for offerBatch := range resultChan {
-		allOffers = append(allOffers, offerBatch...)
-		// Regelmäßige Garbage Collection
-		if len(allOffers)%50000 == 0 {
-			runtime.GC()
-		}
+		fn(offerBatch)
 	}
 
 	errorCount := 0
@@ -249,14 +267,14 @@ func (d *DataImporter) LoadOffersFromCSV() ([]models.Offer, error) {
 		errorCount++
 	}
 
-	return allOffers, nil
+	return nil
 }
 
 func (d *DataImporter) processBatchParallel(batch [][]string, errorChan chan<- error) []models.Offer {
 	offers := make([]models.Offer, 0, len(batch))
 
 	for _, record := range batch {
-		offer, err := parseOfferRecord(record)
+		offer, err := parseOfferRecord(record, d.loc)
 		if err != nil {
 			select {
 			case errorChan <- err:
@@ -270,8 +288,8 @@ func (d *DataImporter) processBatchParallel(batch [][]string, errorChan chan<- e
 	return offers
 }
 
-// parseOfferRecord parst eine CSV-Zeile in ein Offer-Objekt
-func parseOfferRecord(record []string) (models.Offer, error) {
+// parseOfferRecord parst eine CSV-Zeile in ein Offer-Objekt; Zeitangaben ohne Offset gelten in loc
+func parseOfferRecord(record []string, loc *time.Location) (models.Offer, error) {
 	var offer models.Offer
 	var err error
 
@@ -286,13 +304,13 @@ func parseOfferRecord(record []string) (models.Offer, error) {
 	}
 
 	// OutboundDepartureDateTime (trip start)
-	offer.DepartureDate, err = parseDateTime(record[1])
+	offer.DepartureDate, err = parseDateTime(record[1], loc)
 	if err != nil {
 		return offer, fmt.Errorf("ungültiges Abflugdatum: %s", record[1])
 	}
 
 	// InboundDepartureDateTime (trip end)
-	offer.ReturnDate, err = parseDateTime(record[2])
+	offer.ReturnDate, err = parseDateTime(record[2], loc)
 	if err != nil {
 		return offer, fmt.Errorf("ungültiges Rückflugdatum: %s", record[2])
 	}
@@ -318,14 +336,14 @@ func parseOfferRecord(record []string) (models.Offer, error) {
 	// Airports & arrival datetimes
 	offer.InboundDepartureAirport = strings.TrimSpace(record[6])
 	offer.InboundArrivalAirport = strings.TrimSpace(record[7])
-	if t, err := parseDateTime(record[8]); err == nil {
+	if t, err := parseDateTime(record[8], loc); err == nil {
 		offer.InboundArrivalDateTime = t
 	} else {
 		return offer, fmt.Errorf("ungültige inbound arrival datetime: %s", record[8])
 	}
 	offer.OutboundDepartureAirport = strings.TrimSpace(record[9])
 	offer.OutboundArrivalAirport = strings.TrimSpace(record[10])
-	if t, err := parseDateTime(record[11]); err == nil {
+	if t, err := parseDateTime(record[11], loc); err == nil {
 		offer.OutboundArrivalDateTime = t
 	} else {
 		return offer, fmt.Errorf("ungültige outbound arrival datetime: %s", record[11])
@@ -339,27 +357,38 @@ func parseOfferRecord(record []string) (models.Offer, error) {
 	return offer, nil
 }
 
-// parseDateTime parst einen DateTime-String in verschiedenen Formaten
-func parseDateTime(dateStr string) (time.Time, error) {
-	dateStr = strings.TrimSpace(dateStr)
+// Formate mit Offset; sie werden zuerst versucht, damit der Offset erhalten bleibt
+var offsetFormats = []string{
+	time.RFC3339Nano, // auch 2006-01-02T15:04:05+02:00 und ...Z
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02 15:04:05Z0700",
+}
 
-	// Verschiedene Datetime-Formate versuchen
-	formats := []string{
-		"2006-01-02T15:04:05",
-		"2006-01-02 15:04:05",
-		"2006-01-02T15:04:05Z",
-		"2006-01-02T15:04:05.000Z",
-		"02.01.2006 15:04:05",
-		"02.01.2006",
-		"2006-01-02",
-		"2006-01-02T15:04:05-07:00",
-	}
+// Formate ohne Offset; sie gelten in der lokalen Zeitzone
+var localFormats = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"02.01.2006 15:04:05",
+	"02.01.2006",
+	"2006-01-02",
+}
+
+// parseDateTime parst einen DateTime-String in verschiedenen Formaten. Ein Offset (z. B. +02:00
+// oder Z) bleibt erhalten, Angaben ohne Offset gelten in loc.
+func parseDateTime(dateStr string, loc *time.Location) (time.Time, error) {
+	dateStr = strings.TrimSpace(dateStr)
 
-	for _, format := range formats {
+	for _, format := range offsetFormats {
 		if t, err := time.Parse(format, dateStr); err == nil {
 			return t, nil
 		}
 	}
+	for _, format := range localFormats {
+		if t, err := time.ParseInLocation(format, dateStr, loc); err == nil {
+			return t, nil
+		}
+	}
 
 	return time.Time{}, fmt.Errorf("unbekanntes Datetime-Format: %s", dateStr)
 }
@@ -371,7 +400,36 @@ func parseBool(s string) bool {
 }
 
 // ImportOffersToScylla streams the offers CSV and writes rows into Scylla using gocql
+// Je Angebots-ID (models.Offer.Fingerprint: alle Spalten außer dem Preis) bleibt genau eine Zeile,
+// die günstigste der Datei; verworfene Zeilen zählt import_status 'offer_duplicates'.
 func (d *DataImporter) ImportOffersToScylla(session *gocql.Session) error {
+	if err := EnsureOffersSchema(session); err != nil {
+		return err
+	}
+	// Denormalisierte Suchtabellen zusätzlich befüllen; per Env IMPORT_SEARCH_TABLES=false abschaltbar
+	writeSearchTables := !strings.EqualFold(os.Getenv("IMPORT_SEARCH_TABLES"), "false")
+	if writeSearchTables {
+		if err := EnsureSearchTables(session); err != nil {
+			return err
+		}
+	}
+	if err := EnsureOfferIDsTable(session); err != nil {
+		return err
+	}
+	// version kennzeichnet die Zeilen dieses Imports in offer_ids (Millisekunden wie Scylla-timestamps)
+	version := time.Now().UTC().Truncate(time.Millisecond)
+	// Rollups (Mindestpreis/Anzahl je Hotel, Abflughafen, Reisende, Dauer, Abflugwoche) werden im
+	// Speicher aggregiert und nach dem Import geschrieben; per Env IMPORT_ROLLUPS=false abschaltbar.
+	// Die Rollups beschreiben nur die importierte Datei (jede Angebots-ID einmal gezählt) und lösen
+	// die Rollups früherer Importe ab: inkrementelle Importe mehrerer Dateien überschreiben sich.
+	var rollups *rollupAggregator
+	if !strings.EqualFold(os.Getenv("IMPORT_ROLLUPS"), "false") {
+		if err := EnsureRollupTable(session); err != nil {
+			return err
+		}
+		rollups = newRollupAggregator(d.loc)
+	}
+
 	file, err := os.Open(d.offersPath)
 	if err != nil {
 		return fmt.Errorf("fehler beim Öffnen der Angebots-Datei: %w", err)
@@ -388,90 +446,83 @@ func (d *DataImporter) ImportOffersToScylla(session *gocql.Session) error {
 		return fmt.Errorf("fehler beim Lesen des Headers: %w", err)
 	}
 
-	insertCQL := `INSERT INTO offers (
- 		hotelid,
- 		outbounddeparturedatetime,
- 		inbounddeparturedatetime,
- 		countadults,
- 		countchildren,
- 		price,
- 		inbounddepartureairport,
- 		inboundarrivalairport,
- 		inboundarrivaldatetime,
- 		outbounddepartureairport,
- 		outboundarrivalairport,
- 		outboundarrivaldatetime,
- 		mealtype,
- 		oceanview,
- 		roomtype
- 	) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`
-
-	// Hinweis: Die verwendete gocql-Version stellt hier kein Session.Prepare() bereit.
-	// Wir erstellen Queries pro Write, markieren sie als idempotent und setzen Consistency auf ONE.
-
-	jobs := make(chan []string, 2048)
 	errs := make(chan error, 128)
+	report := func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
 	var wg sync.WaitGroup
 
-	// Mehrere Worker für Parallelität; per Env IMPORT_WORKERS überschreibbar
+	// Angebote je ungeloggtem Batch (mit ihren offer_ids-Einträgen); per Env IMPORT_BATCH_SIZE
+	batchSize := 50
+	if v := os.Getenv("IMPORT_BATCH_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			batchSize = n
+		}
+	}
+	w := &offerWriter{
+		session:      session,
+		version:      version,
+		searchTables: writeSearchTables,
+		batchSize:    batchSize,
+		rollups:      rollups,
+		report:       report,
+	}
+
+	// Mehrere Worker für Parallelität; per Env IMPORT_WORKERS überschreibbar. Alle Zeilen eines
+	// Hotels gehen an denselben Worker; er sammelt die zusammenhängenden Zeilen eines Hotels (die
+	// Datei ist nach Hotel gruppiert) und schreibt sie gemeinsam (siehe offerWriter.writeHotel).
 	numWorkers := runtime.NumCPU() * 4
 	if v := os.Getenv("IMPORT_WORKERS"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil && n > 0 {
 			numWorkers = n
 		}
 	}
-	for i := 0; i < numWorkers; i++ {
+	jobs := make([]chan []string, numWorkers)
+	for i := range jobs {
+		jobs[i] = make(chan []string, 2048/numWorkers+1)
 		wg.Add(1)
-		go func() {
+		go func(jobs <-chan []string) {
 			defer wg.Done()
+			hotelID := 0
+			var rows []models.Offer
 			for rec := range jobs {
-				o, err := parseOfferRecord(rec)
+				o, err := parseOfferRecord(rec, d.loc)
 				if err != nil {
-					select {
-					case errs <- err:
-					default:
-					}
+					report(err)
 					continue
 				}
-				if err := session.Query(insertCQL,
-					o.HotelID,
-					o.DepartureDate,
-					o.ReturnDate,
-					o.CountAdults,
-					o.CountChildren,
-					o.Price,
-					o.InboundDepartureAirport,
-					o.InboundArrivalAirport,
-					o.InboundArrivalDateTime,
-					o.OutboundDepartureAirport,
-					o.OutboundArrivalAirport,
-					o.OutboundArrivalDateTime,
-					o.MealType,
-					o.OceanView,
-					o.RoomType,
-				).Consistency(gocql.One).Idempotent(true).Exec(); err != nil {
-					select {
-					case errs <- err:
-					default:
-					}
+				if o.HotelID != hotelID && len(rows) > 0 {
+					w.writeHotel(hotelID, rows)
+					rows = rows[:0]
 				}
+				hotelID = o.HotelID
+				rows = append(rows, o)
 			}
-		}()
+			if len(rows) > 0 {
+				w.writeHotel(hotelID, rows)
+			}
+		}(jobs[i])
 	}
 
-	// producer
+	// producer: verteilt die Zeilen nach Hotel auf die Worker
 	go func() {
 		count := 0
 		for {
 			rec, err := reader.Read()
 			if err != nil {
-				close(jobs)
+				for _, ch := range jobs {
+					close(ch)
+				}
 				return
 			}
 			if len(rec) < 15 {
 				continue
 			}
-			jobs <- rec
+			hotelID, _ := strconv.Atoi(strings.TrimSpace(rec[0]))
+			jobs[uint(hotelID)%uint(numWorkers)] <- rec
 			count++
 			if count%10000 == 0 {
 				fmt.Printf("Import fortschritt: %d Zeilen geschrieben\n", count)
@@ -494,5 +545,38 @@ func (d *DataImporter) ImportOffersToScylla(session *gocql.Session) error {
 	if errCount > 0 {
 		fmt.Printf("Gesamtzahl Importfehler: %d\n", errCount)
 	}
+	if n := w.duplicates.Load(); n > 0 {
+		fmt.Printf("%d doppelte Angebote übersprungen (gleiche Angebots-ID, nicht günstiger)\n", n)
+	}
+	if err := markImportFinished(session, "offer_duplicates", w.duplicates.Load()); err != nil {
+		fmt.Printf("Warnung: %v\n", err)
+	}
+	if n := w.repriced.Load(); n > 0 {
+		fmt.Printf("%d Angebote mit geändertem Preis ersetzt\n", n)
+	}
+
+	if rollups != nil {
+		fmt.Println("Schreibe Preis-Rollups...")
+		rows, rollupErrs := rollups.write(session, version, numWorkers)
+		fmt.Printf("%d Rollup-Zeilen geschrieben, %d Fehler\n", rows, rollupErrs)
+		// Unvollständige Rollups nicht freigeben. Die bisherige Version passt nicht mehr zu den
+		// Angeboten, daher wird auch sie abgemeldet; der Server sucht dann ohne Rollups.
+		if rollupErrs > 0 {
+			if err := session.Query(`DELETE FROM import_status WHERE name = 'offer_rollups'`).Exec(); err != nil {
+				fmt.Printf("Warnung: %v\n", err)
+			}
+		} else {
+			if err := writeImportStatus(session, "offer_rollups", version, int64(rows)); err != nil {
+				fmt.Printf("Warnung: %v\n", err)
+			} else if deleted, err := deleteOldRollups(session, version); err != nil {
+				fmt.Printf("Warnung: %v\n", err)
+			} else if deleted > 0 {
+				fmt.Printf("%d Rollup-Partitionen älterer Importe gelöscht\n", deleted)
+			}
+		}
+	}
+	if err := markImportFinished(session, "offers", w.written.Load()); err != nil {
+		fmt.Printf("Warnung: %v\n", err)
+	}
 	return nil
 }
@@ -0,0 +1,60 @@
+package importer
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseDateTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in         string
+		want       time.Time
+		wantOffset int // Offset des Ergebnisses in Sekunden
+		wantErr    bool
+	}{
+		// mit Offset: Zeitpunkt und Offset bleiben erhalten
+		{in: "2025-08-15T06:00:00+02:00", want: time.Date(2025, 8, 15, 4, 0, 0, 0, time.UTC), wantOffset: 7200},
+		{in: "2025-08-15T06:00:00Z", want: time.Date(2025, 8, 15, 6, 0, 0, 0, time.UTC), wantOffset: 0},
+		{in: "2025-08-15T06:00:00.5-04:00", want: time.Date(2025, 8, 15, 10, 0, 0, 5e8, time.UTC), wantOffset: -4 * 3600},
+		{in: "2025-08-15 06:00:00+02:00", want: time.Date(2025, 8, 15, 4, 0, 0, 0, time.UTC), wantOffset: 7200},
+		{in: "2025-08-15T06:00:00+0200", want: time.Date(2025, 8, 15, 4, 0, 0, 0, time.UTC), wantOffset: 7200},
+		{in: "2025-08-15 06:00:00+0000", want: time.Date(2025, 8, 15, 6, 0, 0, 0, time.UTC), wantOffset: 0},
+		// ohne Offset: in loc, Sommer- und Winterzeit
+		{in: "2025-08-15T06:00:00", want: time.Date(2025, 8, 15, 4, 0, 0, 0, time.UTC), wantOffset: 7200},
+		{in: "2025-01-15T06:00:00", want: time.Date(2025, 1, 15, 5, 0, 0, 0, time.UTC), wantOffset: 3600},
+		{in: "2025-08-15 06:00:00", want: time.Date(2025, 8, 15, 4, 0, 0, 0, time.UTC), wantOffset: 7200},
+		{in: "15.08.2025 06:00:00", want: time.Date(2025, 8, 15, 4, 0, 0, 0, time.UTC), wantOffset: 7200},
+		{in: "15.08.2025", want: time.Date(2025, 8, 14, 22, 0, 0, 0, time.UTC), wantOffset: 7200},
+		{in: "2025-10-26", want: time.Date(2025, 10, 25, 22, 0, 0, 0, time.UTC), wantOffset: 7200},
+		{in: "  2025-08-15  ", want: time.Date(2025, 8, 14, 22, 0, 0, 0, time.UTC), wantOffset: 7200},
+		{in: "", wantErr: true},
+		{in: "gestern", wantErr: true},
+		{in: "2025-13-01", wantErr: true},
+		{in: "08/15/2025", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDateTime(tt.in, berlin)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if _, offset := got.Zone(); offset != tt.wantOffset {
+				t.Errorf("offset %d, want %d", offset, tt.wantOffset)
+			}
+		})
+	}
+}
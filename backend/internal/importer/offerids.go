@@ -0,0 +1,52 @@
+package importer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// offerIDsTableCQL entspricht offer_ids aus infra/scylla/schema.cql (beide synchron halten).
+// Je Angebots-ID (Hotel und Fingerprint) der Preis der aktuellen Zeile in offers und der Import,
+// der sie geschrieben hat.
+const offerIDsTableCQL = `CREATE TABLE IF NOT EXISTS offer_ids (
+	hotelid int,
+	offerid bigint,
+	price double,
+	outbounddeparturedatetime timestamp,
+	importversion timestamp,
+	PRIMARY KEY ((hotelid), offerid)
+)`
+
+// EnsureOfferIDsTable legt die Tabelle offer_ids an, falls sie fehlt
+func EnsureOfferIDsTable(session *gocql.Session) error {
+	if err := session.Query(offerIDsTableCQL).Exec(); err != nil {
+		return fmt.Errorf("fehler beim Anlegen von offer_ids: %w", err)
+	}
+	return nil
+}
+
+// previousOffer ist der Eintrag in offer_ids, bevor der Import ein Hotel schreibt
+type previousOffer struct {
+	price   float64
+	version time.Time
+}
+
+// loadOfferIDs liest die offer_ids-Partition eines Hotels: einmal je Hotel statt je Zeile
+func loadOfferIDs(session *gocql.Session, hotelID int) (map[int64]previousOffer, error) {
+	prev := make(map[int64]previousOffer)
+	iter := session.Query(`SELECT offerid, price, importversion FROM offer_ids WHERE hotelid = ?`, hotelID).
+		Consistency(gocql.One).PageSize(5000).Iter()
+	var (
+		offerID int64
+		p       previousOffer
+	)
+	for iter.Scan(&offerID, &p.price, &p.version) {
+		prev[offerID] = p
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("offer_ids von Hotel %d lesen: %w", hotelID, err)
+	}
+	return prev, nil
+}
@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"sync/atomic"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+const insertOfferCQL = `INSERT INTO offers (
+	hotelid,
+	outbounddeparturedatetime,
+	inbounddeparturedatetime,
+	countadults,
+	countchildren,
+	price,
+	inbounddepartureairport,
+	inboundarrivalairport,
+	inboundarrivaldatetime,
+	outbounddepartureairport,
+	outboundarrivalairport,
+	outboundarrivaldatetime,
+	mealtype,
+	oceanview,
+	roomtype,
+	offerid
+) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`
+
+const insertSearchOfferCQL = `INSERT INTO offers_by_search (
+	outbounddepartureairport,
+	countadults,
+	countchildren,
+	duration,
+	price,
+	hotelid,
+	outbounddeparturedatetime,
+	offerid,
+	inbounddeparturedatetime,
+	inbounddepartureairport,
+	inboundarrivalairport,
+	inboundarrivaldatetime,
+	outboundarrivalairport,
+	outboundarrivaldatetime,
+	mealtype,
+	oceanview,
+	roomtype
+) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`
+
+const insertOfferIDCQL = `INSERT INTO offer_ids (hotelid, offerid, price, outbounddeparturedatetime, importversion) VALUES (?,?,?,?,?)`
+
+// Die übrigen Schlüsselspalten einer überholten Zeile stecken im Fingerprint und sind unverändert
+const (
+	deleteOfferCQL       = `DELETE FROM offers WHERE hotelid = ? AND price = ? AND outbounddeparturedatetime = ? AND offerid = ?`
+	deleteSearchOfferCQL = `DELETE FROM offers_by_search WHERE outbounddepartureairport = ? AND countadults = ? AND countchildren = ? AND duration = ? AND price = ? AND hotelid = ? AND outbounddeparturedatetime = ? AND offerid = ?`
+)
+
+// offerWriter schreibt die Angebote eines Imports hotelweise. Je Hotel wird offer_ids einmal
+// gelesen; die Zeilen des Hotels gehen zusammen mit ihren offer_ids-Einträgen und dem Löschen
+// überholter Zeilen in ungeloggten Batches auf die Hotel-Partition.
+type offerWriter struct {
+	session      *gocql.Session
+	version      time.Time
+	searchTables bool
+	batchSize    int
+	rollups      *rollupAggregator
+	report       func(error)
+
+	written, duplicates, repriced atomic.Int64
+}
+
+// writeHotel schreibt die Zeilen eines Hotels (in Dateireihenfolge). Eine Angebots-ID hat genau
+// eine Zeile: innerhalb des Imports gewinnt die günstigste, gegenüber früheren Importen die neue.
+func (w *offerWriter) writeHotel(hotelID int, rows []models.Offer) {
+	prev, err := loadOfferIDs(w.session, hotelID)
+	if err != nil {
+		// ohne bisherige Einträge wird nur überschrieben, überholte Zeilen bleiben stehen
+		w.report(err)
+		prev = map[int64]previousOffer{}
+	}
+
+	current, duplicates := currentOffers(rows, prev, w.version)
+	w.duplicates.Add(int64(duplicates))
+
+	batch := w.session.NewBatch(gocql.UnloggedBatch)
+	batch.SetConsistency(gocql.One)
+	pending := make([]*models.Offer, 0, w.batchSize)
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if err := w.session.ExecuteBatch(batch); err != nil {
+			w.report(err)
+		} else {
+			w.written.Add(int64(len(pending)))
+		}
+		batch = w.session.NewBatch(gocql.UnloggedBatch)
+		batch.SetConsistency(gocql.One)
+		pending = pending[:0]
+	}
+	for _, c := range current {
+		o, fp := &rows[c.row], c.fingerprint
+		p, found := prev[fp]
+		thisImport := found && p.version.Equal(w.version)
+		if found && p.price != o.Price {
+			w.repriced.Add(1)
+			batch.Query(deleteOfferCQL, o.HotelID, p.price, o.DepartureDate, fp)
+			if w.searchTables {
+				w.exec(deleteSearchOfferCQL, o.OutboundDepartureAirport, o.CountAdults, o.CountChildren, o.Duration(),
+					p.price, o.HotelID, o.DepartureDate, fp)
+			}
+		}
+		batch.Query(insertOfferCQL,
+			o.HotelID, o.DepartureDate, o.ReturnDate, o.CountAdults, o.CountChildren, o.Price,
+			o.InboundDepartureAirport, o.InboundArrivalAirport, o.InboundArrivalDateTime,
+			o.OutboundDepartureAirport, o.OutboundArrivalAirport, o.OutboundArrivalDateTime,
+			o.MealType, o.OceanView, o.RoomType, fp,
+		)
+		batch.Query(insertOfferIDCQL, o.HotelID, fp, o.Price, o.DepartureDate, w.version)
+		pending = append(pending, o)
+		if w.rollups != nil {
+			w.rollups.add(o, !thisImport)
+		}
+		if w.searchTables {
+			w.exec(insertSearchOfferCQL,
+				o.OutboundDepartureAirport, o.CountAdults, o.CountChildren, o.Duration(), o.Price, o.HotelID,
+				o.DepartureDate, fp, o.ReturnDate, o.InboundDepartureAirport, o.InboundArrivalAirport,
+				o.InboundArrivalDateTime, o.OutboundArrivalAirport, o.OutboundArrivalDateTime,
+				o.MealType, o.OceanView, o.RoomType,
+			)
+		}
+		if len(pending) == w.batchSize {
+			flush()
+		}
+	}
+	flush()
+}
+
+// exec schreibt eine einzelne Zeile außerhalb der Hotel-Partition (offers_by_search)
+func (w *offerWriter) exec(stmt string, args ...interface{}) {
+	if err := w.session.Query(stmt, args...).Consistency(gocql.One).Idempotent(true).Exec(); err != nil {
+		w.report(err)
+	}
+}
+
+// currentOffer ist die Zeile (Index in rows), die für eine Angebots-ID geschrieben wird
+type currentOffer struct {
+	row         int
+	fingerprint int64
+}
+
+// currentOffers wählt je Angebots-ID die günstigste Zeile, bei gleichem Preis die erste; kam das
+// Hotel in dieser Datei schon einmal vor (Eintrag in prev mit version), muss die Zeile günstiger
+// sein als die damals geschriebene. Ergebnis in Dateireihenfolge des ersten Vorkommens.
+func currentOffers(rows []models.Offer, prev map[int64]previousOffer, version time.Time) (current []currentOffer, duplicates int) {
+	index := make(map[int64]int, len(rows))
+	for i := range rows {
+		fp := rows[i].Fingerprint()
+		if k, ok := index[fp]; ok {
+			duplicates++
+			if rows[i].Price < rows[current[k].row].Price {
+				current[k].row = i
+			}
+			continue
+		}
+		if p, ok := prev[fp]; ok && p.version.Equal(version) && p.price <= rows[i].Price {
+			duplicates++
+			continue
+		}
+		index[fp] = len(current)
+		current = append(current, currentOffer{row: i, fingerprint: fp})
+	}
+	return current, duplicates
+}
@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// testOffer ist ein Angebot von Hotel 1; Angebote mit gleichem meal haben dieselbe Angebots-ID
+func testOffer(meal string, price float64) models.Offer {
+	dep := time.Date(2025, 8, 1, 6, 0, 0, 0, time.UTC)
+	ret := dep.AddDate(0, 0, 7)
+	return models.Offer{
+		HotelID:                  1,
+		DepartureDate:            dep,
+		ReturnDate:               ret,
+		CountAdults:              2,
+		Price:                    price,
+		OutboundDepartureAirport: "FRA",
+		OutboundArrivalAirport:   "PMI",
+		OutboundArrivalDateTime:  dep.Add(2 * time.Hour),
+		InboundDepartureAirport:  "PMI",
+		InboundArrivalAirport:    "FRA",
+		InboundArrivalDateTime:   ret.Add(2 * time.Hour),
+		MealType:                 meal,
+	}
+}
+
+func TestCurrentOffers(t *testing.T) {
+	version := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	earlier := version.Add(-24 * time.Hour)
+	fp := func(meal string) int64 {
+		o := testOffer(meal, 0)
+		return o.Fingerprint()
+	}
+
+	tests := []struct {
+		name           string
+		rows           []models.Offer
+		prev           map[int64]previousOffer
+		wantRows       []int
+		wantDuplicates int
+	}{
+		{
+			name:     "distinct ids",
+			rows:     []models.Offer{testOffer("a", 100), testOffer("b", 90)},
+			wantRows: []int{0, 1},
+		},
+		{
+			name:           "cheapest wins",
+			rows:           []models.Offer{testOffer("a", 100), testOffer("b", 90), testOffer("a", 80), testOffer("a", 95)},
+			wantRows:       []int{2, 1},
+			wantDuplicates: 2,
+		},
+		{
+			name:           "equal price keeps the first",
+			rows:           []models.Offer{testOffer("a", 100), testOffer("a", 100)},
+			wantRows:       []int{0},
+			wantDuplicates: 1,
+		},
+		{
+			name:     "earlier import is replaced, also by a higher price",
+			rows:     []models.Offer{testOffer("a", 120)},
+			prev:     map[int64]previousOffer{fp("a"): {price: 100, version: earlier}},
+			wantRows: []int{0},
+		},
+		{
+			name:           "hotel seen before in this import: more expensive row is skipped",
+			rows:           []models.Offer{testOffer("a", 120), testOffer("b", 50)},
+			prev:           map[int64]previousOffer{fp("a"): {price: 100, version: version}},
+			wantRows:       []int{1},
+			wantDuplicates: 1,
+		},
+		{
+			name:     "hotel seen before in this import: cheaper row wins",
+			rows:     []models.Offer{testOffer("a", 90)},
+			prev:     map[int64]previousOffer{fp("a"): {price: 100, version: version}},
+			wantRows: []int{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, duplicates := currentOffers(tt.rows, tt.prev, version)
+			var rows []int
+			for _, c := range current {
+				rows = append(rows, c.row)
+				if c.fingerprint != tt.rows[c.row].Fingerprint() {
+					t.Errorf("row %d: fingerprint %d, want %d", c.row, c.fingerprint, tt.rows[c.row].Fingerprint())
+				}
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("rows %v, want %v", rows, tt.wantRows)
+			}
+			if duplicates != tt.wantDuplicates {
+				t.Errorf("duplicates %d, want %d", duplicates, tt.wantDuplicates)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+// offerRollupsTableCQL entspricht offer_rollups aus infra/scylla/schema.cql (beide synchron halten).
+// Jeder Import schreibt seine Rollups unter eigener importversion; gültig ist die in import_status
+// (name 'offer_rollups') vermerkte Version, ältere werden danach gelöscht.
+const offerRollupsTableCQL = `CREATE TABLE IF NOT EXISTS offer_rollups (
+	hotelid int,
+	importversion timestamp,
+	outbounddepartureairport text,
+	countadults int,
+	countchildren int,
+	duration int,
+	departureweek date,
+	minprice double,
+	offercount bigint,
+	PRIMARY KEY ((hotelid, importversion), outbounddepartureairport, countadults, countchildren, duration, departureweek)
+)`
+
+// EnsureRollupTable legt die Rollup-Tabelle an, falls sie fehlt. Eine Tabelle im alten Schema ohne
+// importversion wird verworfen und neu angelegt: Rollups sind abgeleitete Daten und werden vom
+// Import vollständig neu geschrieben.
+func EnsureRollupTable(session *gocql.Session) error {
+	if err := session.Query(offerRollupsTableCQL).Exec(); err != nil {
+		return fmt.Errorf("fehler beim Anlegen von offer_rollups: %w", err)
+	}
+	var version time.Time
+	err := session.Query(`SELECT importversion FROM offer_rollups LIMIT 1`).Consistency(gocql.One).Scan(&version)
+	if err == nil || errors.Is(err, gocql.ErrNotFound) {
+		return nil
+	}
+	if msg := strings.ToLower(err.Error()); !strings.Contains(msg, "undefined") && !strings.Contains(msg, "unknown") {
+		return fmt.Errorf("fehler beim Prüfen von offer_rollups: %w", err)
+	}
+	fmt.Println("offer_rollups hat das alte Schema ohne importversion; Tabelle wird neu angelegt")
+	if err := session.Query(`DROP TABLE IF EXISTS offer_rollups`).Exec(); err != nil {
+		return fmt.Errorf("fehler beim Verwerfen von offer_rollups: %w", err)
+	}
+	if err := session.Query(offerRollupsTableCQL).Exec(); err != nil {
+		return fmt.Errorf("fehler beim Anlegen von offer_rollups: %w", err)
+	}
+	return nil
+}
+
+// rollupKey ist eine Zeile der offer_rollups-Tabelle
+type rollupKey struct {
+	hotelID  int
+	airport  string
+	adults   int
+	children int
+	duration int
+	week     time.Time
+}
+
+type rollupValue struct {
+	minPrice float64
+	count    int64
+}
+
+// rollupShards verteilt die Aggregation nach Hotel auf mehrere Maps, damit sich die Import-Worker nicht ausbremsen
+const rollupShards = 64
+
+// rollupAggregator sammelt Mindestpreis und Anzahl je Rollup-Schlüssel während des Imports;
+// Abflugwochen beginnen montags in loc (siehe models.DepartureWeek)
+type rollupAggregator struct {
+	loc    *time.Location
+	shards [rollupShards]struct {
+		mu sync.Mutex
+		m  map[rollupKey]*rollupValue
+	}
+}
+
+func newRollupAggregator(loc *time.Location) *rollupAggregator {
+	a := &rollupAggregator{loc: loc}
+	for i := range a.shards {
+		a.shards[i].m = make(map[rollupKey]*rollupValue)
+	}
+	return a
+}
+
+// add verbucht ein Angebot. counted ist false, wenn dieselbe Angebots-ID in diesem Import schon
+// verbucht wurde (günstigere Wiederholung in der Datei): dann zählt nur der Mindestpreis.
+func (a *rollupAggregator) add(o *models.Offer, counted bool) {
+	k := rollupKey{
+		hotelID:  o.HotelID,
+		airport:  o.OutboundDepartureAirport,
+		adults:   o.CountAdults,
+		children: o.CountChildren,
+		duration: o.Duration(),
+		week:     models.DepartureWeek(o.DepartureDate, a.loc),
+	}
+	var n int64
+	if counted {
+		n = 1
+	}
+	shard := &a.shards[uint(k.hotelID)%rollupShards]
+	shard.mu.Lock()
+	if v, ok := shard.m[k]; ok {
+		if o.Price < v.minPrice {
+			v.minPrice = o.Price
+		}
+		v.count += n
+	} else {
+		shard.m[k] = &rollupValue{minPrice: o.Price, count: n}
+	}
+	shard.mu.Unlock()
+}
+
+// write schreibt alle aggregierten Zeilen unter version mit numWorkers parallelen Writern
+func (a *rollupAggregator) write(session *gocql.Session, version time.Time, numWorkers int) (rows int, errCount int) {
+	insertCQL := `INSERT INTO offer_rollups (hotelid, importversion, outbounddepartureairport, countadults, countchildren, duration, departureweek, minprice, offercount) VALUES (?,?,?,?,?,?,?,?,?)`
+
+	type row struct {
+		k rollupKey
+		v *rollupValue
+	}
+	jobs := make(chan row, 1024)
+	var (
+		wg     sync.WaitGroup
+		failMu sync.Mutex
+	)
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				if err := session.Query(insertCQL,
+					r.k.hotelID, version, r.k.airport, r.k.adults, r.k.children, r.k.duration, weekDate(r.k.week), r.v.minPrice, r.v.count,
+				).Consistency(gocql.One).Idempotent(true).Exec(); err != nil {
+					failMu.Lock()
+					if errCount < 10 {
+						fmt.Printf("Warnung: Fehler beim Schreiben eines Rollups: %v\n", err)
+					}
+					errCount++
+					failMu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range a.shards {
+		for k, v := range a.shards[i].m {
+			jobs <- row{k: k, v: v}
+			rows++
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return rows, errCount
+}
+
+// weekDate liefert den Kalendertag des Wochenbeginns als UTC-Mitternacht: gocql schreibt eine
+// date-Spalte aus dem UTC-Tag des Zeitpunkts, der lokale Montag 00:00 wäre sonst oft ein Sonntag
+func weekDate(week time.Time) time.Time {
+	return time.Date(week.Year(), week.Month(), week.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// deleteOldRollups löscht die Rollups aller Importe außer version. Aufruf erst, nachdem version in
+// import_status vermerkt ist, damit der Server jederzeit eine vollständige Version liest.
+func deleteOldRollups(session *gocql.Session, version time.Time) (deleted int, err error) {
+	iter := session.Query(`SELECT DISTINCT hotelid, importversion FROM offer_rollups`).Consistency(gocql.One).Iter()
+	var (
+		hotelID int
+		v       time.Time
+	)
+	for iter.Scan(&hotelID, &v) {
+		if v.Equal(version) {
+			continue
+		}
+		if err := session.Query(`DELETE FROM offer_rollups WHERE hotelid = ? AND importversion = ?`, hotelID, v).
+			Consistency(gocql.One).Idempotent(true).Exec(); err != nil {
+			iter.Close()
+			return deleted, fmt.Errorf("fehler beim Löschen alter Rollups: %w", err)
+		}
+		deleted++
+	}
+	if err := iter.Close(); err != nil {
+		return deleted, fmt.Errorf("fehler beim Lesen alter Rollups: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,35 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+func TestWeekDate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		dep  time.Time
+		loc  *time.Location
+		want time.Time
+	}{
+		// Montag 00:00 in Berlin ist Sonntag 22:00 UTC; gespeichert wird der Montag
+		{name: "berlin summer", dep: time.Date(2025, 8, 13, 9, 0, 0, 0, berlin), loc: berlin, want: time.Date(2025, 8, 11, 0, 0, 0, 0, time.UTC)},
+		{name: "berlin local monday, utc sunday", dep: time.Date(2025, 8, 17, 22, 30, 0, 0, time.UTC), loc: berlin, want: time.Date(2025, 8, 18, 0, 0, 0, 0, time.UTC)},
+		{name: "berlin winter", dep: time.Date(2025, 1, 5, 23, 0, 0, 0, berlin), loc: berlin, want: time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)},
+		{name: "utc", dep: time.Date(2025, 8, 17, 22, 30, 0, 0, time.UTC), loc: time.UTC, want: time.Date(2025, 8, 11, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := weekDate(models.DepartureWeek(tt.dep, tt.loc))
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
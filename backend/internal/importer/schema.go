@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// offersTableCQL entspricht der offers-Tabelle aus infra/scylla/schema.cql (beide synchron halten).
+// offerid (models.Offer.Fingerprint) ist Teil des Clustering-Keys, damit Angebote mit gleichem
+// Preis und gleicher Abflugzeit sich nicht gegenseitig überschreiben.
+const offersTableCQL = `CREATE TABLE IF NOT EXISTS offers (
+	hotelid int,
+	outbounddeparturedatetime timestamp,
+	inbounddeparturedatetime timestamp,
+	countadults int,
+	countchildren int,
+	price double,
+	inbounddepartureairport text,
+	inboundarrivalairport text,
+	inboundarrivaldatetime timestamp,
+	outbounddepartureairport text,
+	outboundarrivalairport text,
+	outboundarrivaldatetime timestamp,
+	mealtype text,
+	oceanview boolean,
+	roomtype text,
+	offerid bigint,
+	PRIMARY KEY ((hotelid), price, outbounddeparturedatetime, offerid)
+) WITH CLUSTERING ORDER BY (price ASC, outbounddeparturedatetime ASC, offerid ASC)`
+
+// offersBySearchTableCQL entspricht offers_by_search aus infra/scylla/schema.cql (beide synchron halten).
+// Denormalisierte Kopie der offers-Tabelle, partitioniert nach den Feldern, die eine Suche
+// typischerweise festlegt, und innerhalb der Partition nach Preis sortiert.
+const offersBySearchTableCQL = `CREATE TABLE IF NOT EXISTS offers_by_search (
+	outbounddepartureairport text,
+	countadults int,
+	countchildren int,
+	duration int,
+	price double,
+	hotelid int,
+	outbounddeparturedatetime timestamp,
+	offerid bigint,
+	inbounddeparturedatetime timestamp,
+	inbounddepartureairport text,
+	inboundarrivalairport text,
+	inboundarrivaldatetime timestamp,
+	outboundarrivalairport text,
+	outboundarrivaldatetime timestamp,
+	mealtype text,
+	oceanview boolean,
+	roomtype text,
+	PRIMARY KEY ((outbounddepartureairport, countadults, countchildren, duration), price, hotelid, outbounddeparturedatetime, offerid)
+) WITH CLUSTERING ORDER BY (price ASC, hotelid ASC, outbounddeparturedatetime ASC, offerid ASC)`
+
+// importStatusTableCQL entspricht import_status aus infra/scylla/schema.cql (beide synchron halten).
+// Eine Zeile je Datensatz ("offers") mit dem Zeitpunkt des letzten abgeschlossenen Imports;
+// "offer_rollups" hält stattdessen die importversion der gültigen Rollups.
+const importStatusTableCQL = `CREATE TABLE IF NOT EXISTS import_status (
+	name text PRIMARY KEY,
+	finishedat timestamp,
+	rowcount bigint
+)`
+
+// markImportFinished vermerkt das Ende eines Imports; der Server verwirft daraufhin abgeleitete Caches
+func markImportFinished(session *gocql.Session, name string, rows int64) error {
+	return writeImportStatus(session, name, time.Now(), rows)
+}
+
+// writeImportStatus schreibt die Zeile name in import_status
+func writeImportStatus(session *gocql.Session, name string, at time.Time, rows int64) error {
+	if err := session.Query(importStatusTableCQL).Exec(); err != nil {
+		return fmt.Errorf("fehler beim Anlegen von import_status: %w", err)
+	}
+	if err := session.Query(`INSERT INTO import_status (name, finishedat, rowcount) VALUES (?, ?, ?)`, name, at, rows).Exec(); err != nil {
+		return fmt.Errorf("fehler beim Schreiben von import_status: %w", err)
+	}
+	return nil
+}
+
+// EnsureSearchTables legt die denormalisierten Suchtabellen an, falls sie fehlen
+func EnsureSearchTables(session *gocql.Session) error {
+	if err := session.Query(offersBySearchTableCQL).Exec(); err != nil {
+		return fmt.Errorf("fehler beim Anlegen von offers_by_search: %w", err)
+	}
+	return nil
+}
+
+// ErrLegacyOffersSchema signalisiert eine offers-Tabelle ohne offerid im Primärschlüssel
+var ErrLegacyOffersSchema = errors.New("offers-Tabelle hat das alte Schema ohne offerid; Migration mit -migrate-offers ausführen (siehe infra/scylla/migrations/001_offers_offerid.cql)")
+
+// EnsureOffersSchema prüft, ob die offers-Tabelle die Spalte offerid besitzt.
+// Fehlt die Tabelle ganz, wird sie angelegt.
+func EnsureOffersSchema(session *gocql.Session) error {
+	var id int64
+	err := session.Query(`SELECT offerid FROM offers LIMIT 1`).Consistency(gocql.One).Scan(&id)
+	switch {
+	case err == nil, errors.Is(err, gocql.ErrNotFound):
+		return nil
+	case strings.Contains(strings.ToLower(err.Error()), "unconfigured table"):
+		if err := session.Query(offersTableCQL).Exec(); err != nil {
+			return fmt.Errorf("fehler beim Anlegen der offers-Tabelle: %w", err)
+		}
+		return nil
+	case strings.Contains(strings.ToLower(err.Error()), "offerid"):
+		return ErrLegacyOffersSchema
+	default:
+		return fmt.Errorf("fehler beim Prüfen des offers-Schemas: %w", err)
+	}
+}
+
+// MigrateOffersTable ersetzt eine offers-Tabelle im alten Schema durch das neue.
+// Ein Kopieren der Bestandsdaten ist nicht sinnvoll: die alte Tabelle hat kollidierende
+// Angebote bereits verloren. Die Daten müssen daher anschließend neu aus der CSV importiert werden.
+func MigrateOffersTable(session *gocql.Session) error {
+	if err := EnsureOffersSchema(session); err == nil {
+		fmt.Println("offers-Tabelle hat bereits das neue Schema, keine Migration nötig")
+		return nil
+	} else if !errors.Is(err, ErrLegacyOffersSchema) {
+		return err
+	}
+	fmt.Println("Migriere offers-Tabelle: DROP + CREATE mit offerid im Clustering-Key...")
+	if err := session.Query(`DROP TABLE IF EXISTS offers`).Exec(); err != nil {
+		return fmt.Errorf("fehler beim Löschen der alten offers-Tabelle: %w", err)
+	}
+	if err := session.Query(offersTableCQL).Exec(); err != nil {
+		return fmt.Errorf("fehler beim Anlegen der offers-Tabelle: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Alert ist ein Preisalarm: eine gespeicherte Suche mit Zielpreis. Sobald ein Hotel der Suche
+// unter den Zielpreis fällt, wird WebhookURL benachrichtigt.
+type Alert struct {
+	ID          string          `json:"id"`
+	Search      ApiSearchParams `json:"search"`
+	TargetPrice float64         `json:"targetPrice"`
+	WebhookURL  string          `json:"webhookUrl"`
+	// Secret signiert die Webhooks (HMAC-SHA256); wird nur beim Anlegen ausgeliefert
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Notified enthält je gemeldetem Hotel den zuletzt gemeldeten Preis. Ein Hotel wird erneut
+	// gemeldet, wenn sein Preis weiter fällt oder es zwischenzeitlich über dem Zielpreis lag.
+	Notified        map[int]float64 `json:"-"`
+	LastEvaluatedAt *time.Time      `json:"lastEvaluatedAt,omitempty"`
+}
+
+// AlertHotel ist ein Hotel im Webhook, das unter den Zielpreis gefallen ist
+type AlertHotel struct {
+	Hotel         Hotel   `json:"hotel"`
+	OfferID       string  `json:"offerId"`
+	Price         float64 `json:"price"`
+	PreviousPrice float64 `json:"previousPrice,omitempty" doc:"Price of the previous notification if the hotel dropped further"`
+	DepartureDate string  `json:"departureDate"`
+	ReturnDate    string  `json:"returnDate"`
+}
+
+// AlertWebhookPayload ist der JSON-Body eines Webhooks
+type AlertWebhookPayload struct {
+	DeliveryID  string       `json:"deliveryId"`
+	AlertID     string       `json:"alertId"`
+	TargetPrice float64      `json:"targetPrice"`
+	EvaluatedAt time.Time    `json:"evaluatedAt"`
+	Hotels      []AlertHotel `json:"hotels"`
+}
+
+// AlertDelivery ist ein Eintrag im Zustellprotokoll eines Alarms
+type AlertDelivery struct {
+	ID         string    `json:"id"`
+	AlertID    string    `json:"alertId"`
+	CreatedAt  time.Time `json:"createdAt"`
+	HotelIDs   []int     `json:"hotelIds"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"statusCode,omitempty" doc:"HTTP status of the last attempt"`
+	Error      string    `json:"error,omitempty" doc:"Error of the last attempt"`
+	Delivered  bool      `json:"delivered"`
+}
+
+// ApiAlertInput ist der Body zum Anlegen eines Alarms
+type ApiAlertInput struct {
+	Search      ApiSearchParams `json:"search" doc:"Saved search, same fields as the query parameters of /bestOffersByHotel (lists as JSON arrays)"`
+	TargetPrice float64         `json:"targetPrice" exclusiveMinimum:"0" doc:"Notify when a hotel's best offer costs less than this (Euro)"`
+	WebhookURL  string          `json:"webhookUrl" format:"uri" maxLength:"2048" doc:"http(s) URL that receives signed POST requests; loopback, private and link-local addresses are rejected, also after DNS resolution and redirects"`
+}
+
+// AlertResponse für Huma API
+type AlertResponse struct {
+	Body Alert `json:"alert"`
+}
+
+// AlertDeliveriesResponse für Huma API
+type AlertDeliveriesResponse struct {
+	Body []AlertDelivery `json:"deliveries"`
+}
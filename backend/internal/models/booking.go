@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Status einer Buchung. Erlaubte Übergänge: held → confirmed, held → cancelled, held → expired
+// (nach ExpiresAt), confirmed → cancelled.
+const (
+	BookingStatusHeld      = "held"
+	BookingStatusConfirmed = "confirmed"
+	BookingStatusCancelled = "cancelled"
+	BookingStatusExpired   = "expired"
+)
+
+// Booking ist eine Reservierung (Hold) bzw. Buchung eines Angebots
+type Booking struct {
+	ID      string `json:"id"`
+	OfferID string `json:"offerId"`
+	HotelID int    `json:"hotelId"`
+	// Offer ist der Stand des Angebots beim Reservieren, Price der dabei festgehaltene Preis
+	Offer     Offer     `json:"offer"`
+	Price     float64   `json:"price"`
+	Status    string    `json:"status" enum:"held,confirmed,cancelled,expired"`
+	CreatedAt time.Time `json:"createdAt"`
+	// ExpiresAt ist das Ende der Reservierung; danach kann sie nicht mehr bestätigt werden
+	ExpiresAt time.Time `json:"expiresAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Beim Lesen neu ermittelt; fehlt, wenn das Angebot nicht mehr existiert
+	CurrentPrice *float64 `json:"currentPrice,omitempty" doc:"Current price of the offer"`
+}
+
+// ApiBookingHoldInput ist der Body zum Reservieren eines Angebots
+type ApiBookingHoldInput struct {
+	OfferID       string  `json:"offerId" maxLength:"40" doc:"Offer id as returned in offer responses"`
+	ExpectedPrice float64 `json:"expectedPrice,omitempty" minimum:"0" doc:"Price the client has shown, in the requested currency; the hold fails with 409 if the offer costs something else now"`
+}
+
+// BookingResponse für Huma API
+type BookingResponse struct {
+	CurrencyHeaders
+	Body Booking `json:"booking"`
+}
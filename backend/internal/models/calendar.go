@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Granularitäten des Preiskalenders
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// CalendarBucket liefert den Beginn des Kalender-Abschnitts, in den ein Abflug fällt:
+// den Tag, den Montag der Woche oder den Monatsersten, jeweils 00:00 in der Zeitzone loc
+func CalendarBucket(t time.Time, granularity string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	switch granularity {
+	case GranularityWeek:
+		offset := (int(day.Weekday()) + 6) % 7 // Montag = 0
+		return day.AddDate(0, 0, -offset)
+	case GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	}
+	return day
+}
+
+// PriceCalendarEntry ist der günstigste Preis eines Kalender-Abschnitts
+type PriceCalendarEntry struct {
+	Date        string  `json:"date" doc:"Start of the day, week (Monday) or month (YYYY-MM-DD)"`
+	MinPrice    float64 `json:"minPrice" doc:"Cheapest matching offer departing in this period"`
+	HotelID     int     `json:"hotelId" doc:"Hotel of the cheapest offer"`
+	CountOffers int     `json:"countOffers" doc:"Number of matching offers departing in this period"`
+	CountHotels int     `json:"countHotels" doc:"Number of hotels with a matching offer in this period"`
+}
+
+// PriceCalendarResponse für Huma API
+type PriceCalendarResponse struct {
+	CurrencyHeaders
+	Body struct {
+		Granularity string               `json:"granularity"`
+		Items       []PriceCalendarEntry `json:"items"`
+	} `json:"body"`
+}
@@ -0,0 +1,156 @@
+package models
+
+import "strconv"
+
+// BaseCurrency ist die Währung der Angebotsdaten; alle Kurse beziehen sich auf sie
+const BaseCurrency = "EUR"
+
+// ExchangeRates ist eine Kurstabelle: Rates[code] Einheiten der Währung code je Euro
+type ExchangeRates struct {
+	Base  string             `json:"base" enum:"EUR" required:"false" doc:"Base currency of the rates, always EUR"`
+	Date  string             `json:"date" format:"date" doc:"Date the rates are valid for (YYYY-MM-DD)"`
+	Rates map[string]float64 `json:"rates" doc:"Units of each currency (ISO 4217 code) per Euro"`
+}
+
+// ExchangeRatesResponse für Huma API
+type ExchangeRatesResponse struct {
+	Body ExchangeRates `json:"rates"`
+}
+
+// ApiCurrencyParams ist der Währungs-Parameter aller Endpunkte mit Preisen
+type ApiCurrencyParams struct {
+	Currency string `query:"currency" pattern:"^[A-Za-z]{3}$" doc:"ISO 4217 code of the currency for all prices and price filters (default EUR), see /rates"`
+}
+
+// CurrencyHeaders nennen in Antworten mit Preisen die verwendete Währung und den Kurs
+type CurrencyHeaders struct {
+	Currency         string `header:"X-Currency" doc:"Currency of all prices in the response"`
+	ExchangeRate     string `header:"X-Exchange-Rate" doc:"Units of the currency per Euro; omitted for EUR"`
+	ExchangeRateDate string `header:"X-Exchange-Rate-Date" doc:"Date of the exchange rate (YYYY-MM-DD); omitted for EUR"`
+}
+
+// Conversion rechnet Euro-Preise in die angefragte Währung um. Rundung: jeder ausgegebene
+// Preis wird aus dem Euro-Preis umgerechnet und kaufmännisch auf zwei Nachkommastellen
+// gerundet (RoundCents); Summen werden aus den Euro-Preisen gebildet, nicht aus gerundeten Werten.
+// Der Nullwert ist die Identität (Euro).
+type Conversion struct {
+	Currency string
+	Rate     float64
+	Date     string
+}
+
+// IsBase meldet, ob nicht umgerechnet wird
+func (c Conversion) IsBase() bool {
+	return c.Currency == "" || c.Currency == BaseCurrency
+}
+
+// Price rechnet einen Euro-Preis um
+func (c Conversion) Price(eur float64) float64 {
+	if c.IsBase() {
+		return eur
+	}
+	return RoundCents(eur * c.Rate)
+}
+
+// ToBase rechnet einen Betrag der angefragten Währung (z. B. eine Filtergrenze) in Euro um
+func (c Conversion) ToBase(amount float64) float64 {
+	if c.IsBase() || amount == 0 {
+		return amount
+	}
+	return amount / c.Rate
+}
+
+// Headers liefert die Währungs-Header der Antwort
+func (c Conversion) Headers() CurrencyHeaders {
+	if c.IsBase() {
+		return CurrencyHeaders{Currency: BaseCurrency}
+	}
+	return CurrencyHeaders{
+		Currency:         c.Currency,
+		ExchangeRate:     strconv.FormatFloat(c.Rate, 'f', -1, 64),
+		ExchangeRateDate: c.Date,
+	}
+}
+
+// SearchParams rechnet die Preisfilter einer Suche in Euro um
+func (c Conversion) SearchParams(p *SearchParams) {
+	p.MinPrice, p.MaxPrice = c.ToBase(p.MinPrice), c.ToBase(p.MaxPrice)
+	p.MinPricePerPerson, p.MaxPricePerPerson = c.ToBase(p.MinPricePerPerson), c.ToBase(p.MaxPricePerPerson)
+	p.MinPricePerNight, p.MaxPricePerNight = c.ToBase(p.MinPricePerNight), c.ToBase(p.MaxPricePerNight)
+}
+
+// Offer rechnet ein Angebot um; bei Mehrzimmer-Paketen auch die Zimmer
+func (c Conversion) Offer(o *Offer) {
+	if c.IsBase() {
+		return
+	}
+	if len(o.Rooms) > 0 {
+		o.Rooms = append([]Offer(nil), o.Rooms...)
+		c.Offers(o.Rooms)
+	}
+	o.Price = c.Price(o.Price)
+}
+
+// Offers rechnet Angebote an Ort und Stelle um
+func (c Conversion) Offers(offers []Offer) {
+	for i := range offers {
+		c.Offer(&offers[i])
+	}
+}
+
+// Facets rechnet die Mindestpreise aller Facetten-Werte um
+func (c Conversion) Facets(f *SearchFacets) {
+	for _, values := range [][]FacetValue{f.DepartureAirports, f.MealTypes, f.RoomTypes, f.OceanView, f.Stars, f.Durations} {
+		for i := range values {
+			values[i].MinPrice = c.Price(values[i].MinPrice)
+		}
+	}
+}
+
+// Insights rechnet die Preis-Kennzahlen eines Hotels um
+func (c Conversion) Insights(in *HotelInsights) {
+	in.MinPrice = c.Price(in.MinPrice)
+	in.MedianPrice = c.Price(in.MedianPrice)
+	in.MaxPrice = c.Price(in.MaxPrice)
+	in.CheapestMonthAvgPrice = c.Price(in.CheapestMonthAvgPrice)
+}
+
+// Calendar rechnet die Preise eines Preiskalenders um
+func (c Conversion) Calendar(entries []PriceCalendarEntry) {
+	for i := range entries {
+		entries[i].MinPrice = c.Price(entries[i].MinPrice)
+	}
+}
+
+// ShortlistItems rechnet gespeicherte und aktuelle Preise von Merkzettel-Einträgen um
+func (c Conversion) ShortlistItems(items []ShortlistItem) {
+	if c.IsBase() {
+		return
+	}
+	for i := range items {
+		item := &items[i]
+		item.SavedPrice = c.Price(item.SavedPrice)
+		if item.CurrentPrice != nil {
+			price := c.Price(*item.CurrentPrice)
+			item.CurrentPrice = &price
+		}
+		if item.Offer != nil {
+			offer := *item.Offer
+			c.Offer(&offer)
+			item.Offer = &offer
+		}
+	}
+}
+
+// Booking rechnet die Preise einer Buchung zur Anzeige um; abgerechnet wird in Euro
+func (c Conversion) Booking(b *Booking) {
+	if c.IsBase() {
+		return
+	}
+	b.Price = c.Price(b.Price)
+	c.Offer(&b.Offer)
+	if b.CurrentPrice != nil {
+		price := c.Price(*b.CurrentPrice)
+		b.CurrentPrice = &price
+	}
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConversionPrice(t *testing.T) {
+	tests := []struct {
+		name string
+		c    Conversion
+		eur  float64
+		want float64
+	}{
+		{name: "zero value is euro", c: Conversion{}, eur: 123.456, want: 123.456},
+		{name: "euro is not rounded", c: Conversion{Currency: "EUR", Rate: 2}, eur: 0.125, want: 0.125},
+		{name: "plain rate", c: Conversion{Currency: "USD", Rate: 1.08}, eur: 10, want: 10.8},
+		{name: "rounds down", c: Conversion{Currency: "USD", Rate: 1.0833}, eur: 100, want: 108.33},
+		{name: "rounds up", c: Conversion{Currency: "USD", Rate: 1.0833}, eur: 19.99, want: 21.66},
+		{name: "half cent rounds away from zero", c: Conversion{Currency: "GBP", Rate: 0.125}, eur: 1, want: 0.13},
+		{name: "large rate", c: Conversion{Currency: "JPY", Rate: 161.37}, eur: 999.99, want: 161368.39},
+		{name: "zero", c: Conversion{Currency: "CHF", Rate: 0.94}, eur: 0, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Price(tt.eur); got != tt.want {
+				t.Errorf("Price(%v) = %v, want %v", tt.eur, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConversionToBase(t *testing.T) {
+	tests := []struct {
+		name   string
+		c      Conversion
+		amount float64
+		want   float64
+	}{
+		{name: "zero value is euro", c: Conversion{}, amount: 99.99, want: 99.99},
+		{name: "zero means no limit", c: Conversion{Currency: "USD", Rate: 1.08}, amount: 0, want: 0},
+		{name: "not rounded to cents", c: Conversion{Currency: "USD", Rate: 3}, amount: 100, want: 100.0 / 3},
+		{name: "inverse of rate", c: Conversion{Currency: "GBP", Rate: 0.5}, amount: 21.5, want: 43},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.ToBase(tt.amount); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ToBase(%v) = %v, want %v", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+// Eine Filtergrenze in der angefragten Währung muss den umgerechneten Preis genau einschließen
+func TestConversionFilterBoundary(t *testing.T) {
+	tests := []struct {
+		c   Conversion
+		eur float64
+	}{
+		{Conversion{Currency: "USD", Rate: 1.08}, 499.99},
+		{Conversion{Currency: "USD", Rate: 1.0833}, 19.99},
+		{Conversion{Currency: "GBP", Rate: 0.8571}, 1234.56},
+		{Conversion{Currency: "JPY", Rate: 161.37}, 0.01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.c.Currency, func(t *testing.T) {
+			shown := tt.c.Price(tt.eur)
+			if limit := tt.c.ToBase(shown); math.Abs(limit-tt.eur)*tt.c.Rate > 0.005+1e-9 {
+				t.Errorf("ToBase(Price(%v)) = %v: more than half a cent apart", tt.eur, limit)
+			}
+		})
+	}
+}
+
+func TestConversionOfferPackage(t *testing.T) {
+	c := Conversion{Currency: "USD", Rate: 1.0833}
+	rooms := []Offer{{ID: "a", Price: 19.99}, {ID: "b", Price: 19.99}}
+	pkg := CombineOffers(rooms)
+
+	c.Offer(&pkg)
+
+	// 39.98 * 1.0833 = 43.310334; die gerundeten Zimmerpreise ergäben 43.32
+	if pkg.Price != 43.31 {
+		t.Errorf("package price %v, want 43.31 converted from the euro sum", pkg.Price)
+	}
+	for i, r := range pkg.Rooms {
+		if r.Price != 21.66 {
+			t.Errorf("room %d price %v, want 21.66", i, r.Price)
+		}
+	}
+	if rooms[0].Price != 19.99 {
+		t.Errorf("converting the package changed the caller's rooms")
+	}
+}
@@ -0,0 +1,82 @@
+package models
+
+// Facet bezeichnet eine Dimension, für die Suchergebnisse gezählt werden
+type Facet int
+
+const (
+	FacetDepartureAirport Facet = iota
+	FacetMealType
+	FacetRoomType
+	FacetOceanView
+	FacetStars
+	FacetDuration
+	NumFacets
+)
+
+// FacetMask ist eine Bitmenge von Facets
+type FacetMask uint8
+
+// Has prüft, ob f in der Menge enthalten ist
+func (m FacetMask) Has(f Facet) bool {
+	return m&(1<<f) != 0
+}
+
+// With liefert die Menge inklusive f
+func (m FacetMask) With(f Facet) FacetMask {
+	return m | 1<<f
+}
+
+// FacetValue ist ein Wert einer Facette mit der Anzahl Hotels und deren günstigstem Preis
+type FacetValue struct {
+	Value       string  `json:"value" doc:"Facet value, e.g. an airport code, meal type or duration in days"`
+	CountHotels int     `json:"countHotels" doc:"Number of hotels with at least one matching offer for this value"`
+	MinPrice    float64 `json:"minPrice" doc:"Cheapest matching offer for this value"`
+}
+
+// SearchFacets enthält die Facetten zu einer Suche. Jede Facette wird über die aktuelle
+// Ergebnismenge berechnet, wobei nur der eigene Filter der Facette ignoriert wird.
+type SearchFacets struct {
+	DepartureAirports []FacetValue `json:"departureAirports"`
+	MealTypes         []FacetValue `json:"mealTypes"`
+	RoomTypes         []FacetValue `json:"roomTypes"`
+	OceanView         []FacetValue `json:"oceanView"`
+	Stars             []FacetValue `json:"stars"`
+	Durations         []FacetValue `json:"durations"`
+}
+
+// FacetMisses prüft ein Angebot gegen die Such-Parameter, getrennt nach Facetten.
+// core ist false, wenn ein Filter ohne eigene Facette (Datum, Reisende, Preis) nicht passt;
+// misses enthält die Facetten, deren Filter nicht passt. Die Sterne sind ein Hotel-Filter
+// und werden hier nicht geprüft (siehe Hotel.Matches).
+func (o *Offer) FacetMisses(params SearchParams) (core bool, misses FacetMask) {
+	core = o.matchesDepartureWindow(params.DepartureWindow()) &&
+		o.matchesLatestReturnDate(params.LatestReturnDate) &&
+		o.matchesCountAdults(params.CountAdults) &&
+		o.matchesCountChildren(params.CountChildren) &&
+		o.matchesPrices(params)
+	if !core {
+		return false, 0
+	}
+	if !o.matchesDepartureAirports(params.DepartureAirports) {
+		misses = misses.With(FacetDepartureAirport)
+	}
+	if !matchesOneOf(o.MealType, params.MealTypes) {
+		misses = misses.With(FacetMealType)
+	}
+	if !matchesOneOf(o.RoomType, params.RoomTypes) {
+		misses = misses.With(FacetRoomType)
+	}
+	if !o.matchesOceanView(params.OceanView) {
+		misses = misses.With(FacetOceanView)
+	}
+	if !o.matchesDuration(params.DurationBounds()) {
+		misses = misses.With(FacetDuration)
+	}
+	return true, misses
+}
+
+// FacetsResponse für Huma API
+type FacetsResponse struct {
+	CurrencyHeaders
+	Body SearchFacets `json:"facets"`
+}
@@ -0,0 +1,30 @@
+package models
+
+// Zählweisen des Preis-Histogramms
+const (
+	HistogramModeOffers = "offers"
+	HistogramModeHotels = "hotels"
+)
+
+// HistogramBucket zählt die Preise im Intervall [From, To)
+type HistogramBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// PriceHistogram ist die Preisverteilung einer Suche
+type PriceHistogram struct {
+	Mode        string            `json:"mode" doc:"offers counts every matching offer, hotels counts each hotel's best price"`
+	BucketWidth float64           `json:"bucketWidth"`
+	Total       int               `json:"total" doc:"Sum of all bucket counts"`
+	MinPrice    float64           `json:"minPrice"`
+	MaxPrice    float64           `json:"maxPrice"`
+	Buckets     []HistogramBucket `json:"buckets" doc:"Consecutive buckets from the cheapest to the most expensive price, including empty ones"`
+}
+
+// PriceHistogramResponse für Huma API
+type PriceHistogramResponse struct {
+	CurrencyHeaders
+	Body PriceHistogram `json:"histogram"`
+}
@@ -11,22 +11,51 @@ type Hotel struct {
 type HotelWithBestOffer struct {
 	Hotel     Hotel  `json:"hotel"`
 	BestOffer *Offer `json:"bestOffer"`
+	// CountAvailableOffers ist die Anzahl aller zur Suche passenden Angebote des Hotels, mit Scylla
+	// höchstens SEARCH_COUNT_LIMIT (siehe CountCapped)
+	CountAvailableOffers int `json:"countAvailableOffers"`
+	// CountCapped: das Zählen endete bei SEARCH_COUNT_LIMIT, CountAvailableOffers ist eine Untergrenze
+	CountCapped bool `json:"countCapped,omitempty"`
 }
 
-// SearchParams für Huma API
+// SearchParams für Huma API; als JSON z. B. die gespeicherte Suche eines Preisalarms
 type ApiSearchParams struct {
-	DepartureAirports     []string `query:"departureAirports" doc:"Comma-separated list of departure airports (e.g., FRA,MUC)"`
-	EarliestDepartureDate string   `query:"earliestDepartureDate" doc:"Earliest departure date (YYYY-MM-DD)"`
-	LatestReturnDate      string   `query:"latestReturnDate" doc:"Latest return date (YYYY-MM-DD)"`
-	CountAdults           int      `query:"countAdults" doc:"Number of adults"`
-	CountChildren         int      `query:"countChildren" doc:"Number of children"`
-	Duration              int      `query:"duration" doc:"Trip duration in days"`
+	DepartureAirports     []string `query:"departureAirports" json:"departureAirports,omitempty" doc:"Comma-separated list of departure airports (e.g., FRA,MUC)"`
+	EarliestDepartureDate string   `query:"earliestDepartureDate" json:"earliestDepartureDate,omitempty" doc:"Earliest departure: YYYY-MM-DD (from the start of the day in LOCAL_TIMEZONE) or RFC 3339 with offset"`
+	LatestReturnDate      string   `query:"latestReturnDate" json:"latestReturnDate,omitempty" doc:"Latest return: YYYY-MM-DD (up to the end of the day in LOCAL_TIMEZONE, inclusive) or RFC 3339 with offset"`
+	CountAdults           int      `query:"countAdults" json:"countAdults,omitempty" doc:"Number of adults"`
+	CountChildren         int      `query:"countChildren" json:"countChildren,omitempty" doc:"Number of children"`
+	Duration              int      `query:"duration" json:"duration,omitempty" doc:"Trip duration in days"`
+	MinDuration           int      `query:"minDuration" json:"minDuration,omitempty" minimum:"0" doc:"Minimum trip duration in days (ignored if duration is set)"`
+	MaxDuration           int      `query:"maxDuration" json:"maxDuration,omitempty" minimum:"0" doc:"Maximum trip duration in days (ignored if duration is set)"`
+	FlexDays              int      `query:"flexDays" json:"flexDays,omitempty" minimum:"0" maximum:"14" doc:"Depart within ± flexDays days around earliestDepartureDate instead of on or after it"`
+	MealTypes             []string `query:"mealTypes" json:"mealTypes,omitempty" doc:"Comma-separated list of accepted meal types (e.g., breakfast,halfboard,allinclusive)"`
+	RoomTypes             []string `query:"roomTypes" json:"roomTypes,omitempty" doc:"Comma-separated list of accepted room types (e.g., double,suite)"`
+	OceanView             string   `query:"oceanView" json:"oceanView,omitempty" enum:"true,false" doc:"Only offers with (true) or without (false) ocean view; omit to accept both"`
+	MinPrice              float64  `query:"minPrice" json:"minPrice,omitempty" minimum:"0" doc:"Minimum total price in the requested currency (see currency)"`
+	MaxPrice              float64  `query:"maxPrice" json:"maxPrice,omitempty" minimum:"0" doc:"Maximum total price in the requested currency (see currency)"`
+	MinPricePerPerson     float64  `query:"minPricePerPerson" json:"minPricePerPerson,omitempty" minimum:"0" doc:"Minimum price per traveller (adults and children) in the requested currency (see currency)"`
+	MaxPricePerPerson     float64  `query:"maxPricePerPerson" json:"maxPricePerPerson,omitempty" minimum:"0" doc:"Maximum price per traveller (adults and children) in the requested currency (see currency)"`
+	MinPricePerNight      float64  `query:"minPricePerNight" json:"minPricePerNight,omitempty" minimum:"0" doc:"Minimum price per night in the requested currency (see currency)"`
+	MaxPricePerNight      float64  `query:"maxPricePerNight" json:"maxPricePerNight,omitempty" minimum:"0" doc:"Maximum price per night in the requested currency (see currency)"`
+	MinStars              float64  `query:"minStars" json:"minStars,omitempty" minimum:"0" maximum:"5" doc:"Minimum hotel stars"`
+}
+
+// ApiHotelOrder enthält Sortierung und Paginierung für /bestOffersByHotel
+type ApiHotelOrder struct {
+	SortBy string `query:"sortBy" enum:"price,stars,pricePerNight,pricePerPerson,name,valueScore" doc:"Sort key (default price); valueScore is stars per 100 Euro price per night"`
+	Order  string `query:"order" enum:"asc,desc" doc:"Sort order; defaults to desc for stars and valueScore, asc otherwise"`
+	Limit  int    `query:"limit" minimum:"0" doc:"Maximum number of hotels to return; 0 returns all"`
+	Offset int    `query:"offset" minimum:"0" doc:"Number of hotels to skip"`
 }
 
 // BestHotelOffer entspricht der Frontend-Erwartung
 type BestHotelOffer struct {
 	Hotel                Hotel   `json:"hotel"`
+	OfferID              string  `json:"offerId" doc:"Id of the best offer, see /offers/{offerId}"`
 	MinPrice             float64 `json:"minPrice"`
+	PricePerPerson       float64 `json:"pricePerPerson" doc:"minPrice divided by the number of travellers"`
+	PricePerNight        float64 `json:"pricePerNight" doc:"minPrice divided by the number of nights"`
 	DepartureDate        string  `json:"departureDate"`
 	ReturnDate           string  `json:"returnDate"`
 	RoomType             string  `json:"roomType,omitempty"`
@@ -34,19 +63,55 @@ type BestHotelOffer struct {
 	CountAdults          int     `json:"countAdults"`
 	CountChildren        int     `json:"countChildren"`
 	Duration             int     `json:"duration"`
-	CountAvailableOffers int     `json:"countAvailableOffers"`
+	CountAvailableOffers int     `json:"countAvailableOffers" doc:"Number of matching offers of the hotel (packages when searching with rooms). With the Scylla backend counting stops at SEARCH_COUNT_LIMIT (default 1000); countCapped then marks the value as a lower bound"`
+	CountCapped          bool    `json:"countCapped,omitempty" doc:"countAvailableOffers is a lower bound: counting stopped at the server's count limit (display e.g. as 1000+)"`
+	Rooms                []Offer `json:"rooms,omitempty" doc:"Offers per room when searching with rooms"`
 }
 
 // BestOffersByHotelResponse für Huma API - kompatibel mit Frontend
 type BestOffersByHotelResponse struct {
-	Body []BestHotelOffer `json:"body"`
+	CurrencyHeaders
+	TotalCount int              `header:"X-Total-Count" doc:"Number of hotels matching the search before limit/offset"`
+	Body       []BestHotelOffer `json:"body"`
+}
+
+// OffersPage ist eine Seite der Angebote eines Hotels
+type OffersPage struct {
+	Items []Offer
+	// NextCursor setzt die Abfrage fort; leer, wenn keine weiteren Angebote folgen
+	NextCursor string
+	// TotalEstimate ist die (geschätzte) Gesamtzahl passender Angebote
+	TotalEstimate int
 }
 
 // HotelOffersResponse für Huma API - kompatibel mit Frontend
 type HotelOffersResponse struct {
+	CurrencyHeaders
+	Body struct {
+		Hotel         Hotel   `json:"hotel"`
+		Items         []Offer `json:"items"`
+		NextCursor    string  `json:"nextCursor,omitempty" doc:"Cursor for the next page; omitted on the last page. Only valid with the same hotel, rooms and filters"`
+		TotalEstimate int     `json:"totalEstimate" doc:"Estimated number of matching offers, taken on the first page and repeated on later pages (exact for the in-memory backend and when the first page holds every offer)"`
+	} `json:"body"`
+}
+
+// HotelSearchResult ist ein Treffer der Hotelnamen-Suche
+type HotelSearchResult struct {
+	Hotel Hotel   `json:"hotel"`
+	Score float64 `json:"score" doc:"Match quality, higher is better (100 = exact name)"`
+}
+
+// HotelSearchResponse für Huma API
+type HotelSearchResponse struct {
+	Body []HotelSearchResult `json:"results"`
+}
+
+// OfferResponse für Huma API
+type OfferResponse struct {
+	CurrencyHeaders
 	Body struct {
-		Hotel Hotel   `json:"hotel"`
-		Items []Offer `json:"items"`
+		Hotel Hotel `json:"hotel"`
+		Offer Offer `json:"offer"`
 	} `json:"body"`
 }
 
@@ -0,0 +1,26 @@
+package models
+
+// HotelInsights fasst alle Angebote eines Hotels zusammen (ohne Such-Filter)
+type HotelInsights struct {
+	CountOffers       int      `json:"countOffers"`
+	MinPrice          float64  `json:"minPrice"`
+	MedianPrice       float64  `json:"medianPrice"`
+	MaxPrice          float64  `json:"maxPrice"`
+	DepartureAirports []string `json:"departureAirports"`
+	MealTypes         []string `json:"mealTypes"`
+	RoomTypes         []string `json:"roomTypes"`
+	MinDuration       int      `json:"minDuration"`
+	MaxDuration       int      `json:"maxDuration"`
+	// CheapestMonth ist der Abflugmonat mit dem niedrigsten Durchschnittspreis
+	CheapestMonth         string  `json:"cheapestMonth,omitempty" doc:"Departure month (YYYY-MM) with the lowest average price"`
+	CheapestMonthAvgPrice float64 `json:"cheapestMonthAvgPrice,omitempty"`
+}
+
+// HotelDetailResponse für Huma API
+type HotelDetailResponse struct {
+	CurrencyHeaders
+	Body struct {
+		Hotel    Hotel         `json:"hotel"`
+		Insights HotelInsights `json:"insights"`
+	} `json:"body"`
+}
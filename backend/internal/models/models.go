@@ -1,29 +1,163 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/danielgtaylor/huma/v2"
 )
 
 // Offer repräsentiert ein Angebot für ein Hotel
 type Offer struct {
+	// ID ist die stabile Angebots-ID (siehe StableID); wird beim Lesen aus dem Storage gesetzt
+	ID                       string    `csv:"-" json:"id"`
 	HotelID                  int       `csv:"hotelid" json:"hotelId"`
-	DepartureDate            time.Time `csv:"departuredate" json:"departureDate"`
-	ReturnDate               time.Time `csv:"returndate" json:"returnDate"`
+	DepartureDate            time.Time `csv:"departuredate" json:"departureDate" doc:"Outbound departure as a UTC instant (the offset of the source data is not kept)"`
+	ReturnDate               time.Time `csv:"returndate" json:"returnDate" doc:"Inbound departure as a UTC instant"`
 	CountAdults              int       `csv:"countadults" json:"countAdults"`
 	CountChildren            int       `csv:"countchildren" json:"countChildren"`
 	Price                    float64   `csv:"price" json:"price"`
 	InboundDepartureAirport  string    `csv:"inbounddepartureairport" json:"inboundDepartureAirport"`
 	InboundArrivalAirport    string    `csv:"inboundarrivalairport" json:"inboundArrivalAirport"`
-	InboundArrivalDateTime   time.Time `csv:"inboundarrivaldatetime" json:"inboundArrivalDateTime"`
+	InboundArrivalDateTime   time.Time `csv:"inboundarrivaldatetime" json:"inboundArrivalDateTime" doc:"Inbound arrival as a UTC instant"`
 	OutboundDepartureAirport string    `csv:"outbounddepartureairport" json:"outboundDepartureAirport"`
 	OutboundArrivalAirport   string    `csv:"outboundarrivalairport" json:"outboundArrivalAirport"`
-	OutboundArrivalDateTime  time.Time `csv:"outboundarrivaldatetime" json:"outboundArrivalDateTime"`
+	OutboundArrivalDateTime  time.Time `csv:"outboundarrivaldatetime" json:"outboundArrivalDateTime" doc:"Outbound arrival as a UTC instant"`
 	MealType                 string    `csv:"mealtype,omitempty" json:"mealType,omitempty"`
 	OceanView                bool      `csv:"oceanview,omitempty" json:"oceanView,omitempty"`
 	RoomType                 string    `csv:"roomtype,omitempty" json:"roomType,omitempty"`
+	// Rooms enthält bei Mehrzimmer-Paketen (siehe CombineOffers) die Einzelangebote je Zimmer
+	Rooms []Offer `csv:"-" json:"rooms,omitempty" doc:"Offers per room of a multi-room package"`
 }
 
 // Duration berechnet die Dauer des Aufenthalts in Tagen
 func (o *Offer) Duration() int {
 	return int(o.InboundArrivalDateTime.Sub(o.OutboundArrivalDateTime).Hours() / 24)
 }
+
+// Nights ist die Anzahl der Übernachtungen: Duration zählt volle Tage zwischen Hin- und
+// Rückflug-Ankunft, das entspricht den Nächten im Hotel. Mindestens 1, damit Preise pro
+// Nacht auch für Tagesreisen definiert sind.
+func (o *Offer) Nights() int {
+	return max(o.Duration(), 1)
+}
+
+// PricePerNight ist der Gesamtpreis geteilt durch die Anzahl der Nächte
+func (o *Offer) PricePerNight() float64 {
+	return o.Price / float64(o.Nights())
+}
+
+// Persons ist die Anzahl der Reisenden (Erwachsene und Kinder), mindestens 1
+func (o *Offer) Persons() int {
+	return max(o.CountAdults+o.CountChildren, 1)
+}
+
+// PricePerPerson ist der Gesamtpreis geteilt durch die Anzahl der Reisenden
+func (o *Offer) PricePerPerson() float64 {
+	return o.Price / float64(o.Persons())
+}
+
+// RoundCents rundet einen berechneten Preis für die Ausgabe auf Cent
+func RoundCents(price float64) float64 {
+	return math.Round(price*100) / 100
+}
+
+// MarshalJSON ergänzt jedes ausgelieferte Angebot um die berechneten Felder pricePerPerson und
+// pricePerNight (auf Cent gerundet); beim Einlesen werden sie ignoriert
+func (o Offer) MarshalJSON() ([]byte, error) {
+	type offerFields Offer // ohne Methoden, sonst Rekursion
+	return json.Marshal(struct {
+		offerFields
+		PricePerPerson float64 `json:"pricePerPerson"`
+		PricePerNight  float64 `json:"pricePerNight"`
+	}{offerFields(o), RoundCents(o.PricePerPerson()), RoundCents(o.PricePerNight())})
+}
+
+// TransformSchema nimmt die berechneten Felder aus MarshalJSON in das OpenAPI-Schema auf
+func (o *Offer) TransformSchema(r huma.Registry, s *huma.Schema) *huma.Schema {
+	s.Properties["pricePerPerson"] = &huma.Schema{Type: huma.TypeNumber, ReadOnly: true, Description: "Total price divided by the number of travellers (adults and children)"}
+	s.Properties["pricePerNight"] = &huma.Schema{Type: huma.TypeNumber, ReadOnly: true, Description: "Total price divided by the number of nights (see Nights: full days between outbound and inbound arrival, at least 1)"}
+	return s
+}
+
+// StableID liefert die deterministische Angebots-ID "<hotelId>-<Fingerprint als 16 Hex-Ziffern>".
+// Sie hängt von Hotel, Flugzeiten, Flughäfen, Reisenden, Zimmer und Verpflegung ab, nicht vom Preis.
+func (o *Offer) StableID() string {
+	return FormatOfferID(o.HotelID, o.Fingerprint())
+}
+
+// FormatOfferID setzt eine Angebots-ID aus Hotel und Fingerprint zusammen
+func FormatOfferID(hotelID int, fingerprint int64) string {
+	return fmt.Sprintf("%d-%016x", hotelID, uint64(fingerprint))
+}
+
+// ParseOfferID zerlegt eine Angebots-ID in Hotel und Fingerprint
+func ParseOfferID(id string) (hotelID int, fingerprint int64, err error) {
+	hotelPart, fpPart, ok := strings.Cut(id, "-")
+	if !ok || len(fpPart) != 16 {
+		return 0, 0, fmt.Errorf("ungültige Angebots-ID %q", id)
+	}
+	if hotelID, err = strconv.Atoi(hotelPart); err != nil || hotelID <= 0 {
+		return 0, 0, fmt.Errorf("ungültige Angebots-ID %q", id)
+	}
+	fp, err := strconv.ParseUint(fpPart, 16, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("ungültige Angebots-ID %q", id)
+	}
+	return hotelID, int64(fp), nil
+}
+
+// Fingerprint liefert einen deterministischen 64-Bit-Schlüssel über alle Attribute außer dem Preis.
+// Angebote mit gleichem Preis und gleicher Abflugzeit, die sich z.B. in Zimmer, Verpflegung oder
+// Personenzahl unterscheiden, erhalten damit unterschiedliche Schlüssel. Zeiten gehen als
+// Unix-Sekunden ein, der Schlüssel ist also unabhängig von der Zeitzonen-Darstellung.
+func (o *Offer) Fingerprint() int64 {
+	buf := make([]byte, 0, 160)
+	sep := func() { buf = append(buf, '|') }
+	buf = strconv.AppendInt(buf, int64(o.HotelID), 10)
+	sep()
+	buf = strconv.AppendInt(buf, o.DepartureDate.Unix(), 10)
+	sep()
+	buf = strconv.AppendInt(buf, o.ReturnDate.Unix(), 10)
+	sep()
+	buf = strconv.AppendInt(buf, int64(o.CountAdults), 10)
+	sep()
+	buf = strconv.AppendInt(buf, int64(o.CountChildren), 10)
+	sep()
+	buf = append(buf, o.InboundDepartureAirport...)
+	sep()
+	buf = append(buf, o.InboundArrivalAirport...)
+	sep()
+	buf = strconv.AppendInt(buf, o.InboundArrivalDateTime.Unix(), 10)
+	sep()
+	buf = append(buf, o.OutboundDepartureAirport...)
+	sep()
+	buf = append(buf, o.OutboundArrivalAirport...)
+	sep()
+	buf = strconv.AppendInt(buf, o.OutboundArrivalDateTime.Unix(), 10)
+	sep()
+	buf = append(buf, o.MealType...)
+	sep()
+	buf = strconv.AppendBool(buf, o.OceanView)
+	sep()
+	buf = append(buf, o.RoomType...)
+
+	h := fnv.New64a()
+	_, _ = h.Write(buf)
+	return int64(h.Sum64())
+}
+
+// DepartureWeek liefert den Montag (00:00 in loc) der Woche, in der t liegt. Gemeinsamer
+// Wochenschlüssel für die beim Import erzeugten Preis-Rollups; in loc, damit Wochen und reine
+// Datumsgrenzen der Suche (siehe ParseSearchDate) an denselben Mitternächten beginnen.
+func DepartureWeek(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	offset := (int(day.Weekday()) + 6) % 7 // Montag = 0
+	return day.AddDate(0, 0, -offset)
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestDepartureWeek(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{name: "monday", t: time.Date(2025, 8, 11, 10, 0, 0, 0, berlin), want: time.Date(2025, 8, 11, 0, 0, 0, 0, berlin)},
+		{name: "sunday", t: time.Date(2025, 8, 17, 23, 59, 0, 0, berlin), want: time.Date(2025, 8, 11, 0, 0, 0, 0, berlin)},
+		// Montag 00:30 in Berlin ist in UTC noch Sonntag
+		{name: "local monday, utc sunday", t: time.Date(2025, 8, 17, 22, 30, 0, 0, time.UTC), want: time.Date(2025, 8, 18, 0, 0, 0, 0, berlin)},
+		{name: "week with spring forward", t: time.Date(2025, 3, 30, 12, 0, 0, 0, berlin), want: time.Date(2025, 3, 24, 0, 0, 0, 0, berlin)},
+		{name: "week after spring forward", t: time.Date(2025, 3, 31, 0, 30, 0, 0, berlin), want: time.Date(2025, 3, 31, 0, 0, 0, 0, berlin)},
+		{name: "week with fall back", t: time.Date(2025, 10, 26, 23, 0, 0, 0, berlin), want: time.Date(2025, 10, 20, 0, 0, 0, 0, berlin)},
+		{name: "utc", t: time.Date(2025, 8, 17, 22, 30, 0, 0, time.UTC), want: time.Date(2025, 8, 11, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := berlin
+			if tt.want.Location() == time.UTC {
+				loc = time.UTC
+			}
+			got := DepartureWeek(tt.t, loc)
+			if !got.Equal(tt.want) {
+				t.Errorf("DepartureWeek(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+			// Suchgrenzen (ParseSearchDate) und Wochen beginnen an derselben Mitternacht
+			if start, _ := ParseSearchDate(got.Format("2006-01-02"), loc, false); !start.Equal(got) {
+				t.Errorf("week starts at %v, search day at %v", got, start)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"holiday-coding-challenge/backend/internal/apierror"
+)
+
+// MaxRooms ist die maximale Anzahl Zimmer einer Mehrzimmer-Suche
+const MaxRooms = 4
+
+// Room beschreibt die Belegung eines Zimmers
+type Room struct {
+	Adults   int `json:"adults"`
+	Children int `json:"children"`
+}
+
+// ApiRoomsParams ist der Mehrzimmer-Parameter der Such-Endpunkte
+type ApiRoomsParams struct {
+	Rooms string `query:"rooms" pattern:"^[0-9]+:[0-9]+(,[0-9]+:[0-9]+)*$" doc:"Party per room as adults:children, comma-separated (e.g. 2:1,2:2). Combines one offer per room with identical flights into a package; replaces countAdults/countChildren"`
+}
+
+// ParseRooms parst den rooms-Parameter ("2:1,2:0"); leer ergibt keine Zimmer
+func ParseRooms(s string) ([]Room, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) > MaxRooms {
+		return nil, apierror.New(apierror.CodeTooManyRooms, "max", MaxRooms)
+	}
+	rooms := make([]Room, len(parts))
+	for i, part := range parts {
+		adults, children, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, apierror.New(apierror.CodeInvalidRoom, "room", part)
+		}
+		var err error
+		if rooms[i].Adults, err = strconv.Atoi(adults); err != nil || rooms[i].Adults < 1 {
+			return nil, apierror.New(apierror.CodeRoomWithoutAdult, "room", part)
+		}
+		if rooms[i].Children, err = strconv.Atoi(children); err != nil || rooms[i].Children < 0 {
+			return nil, apierror.New(apierror.CodeInvalidRoomChildren, "room", part)
+		}
+	}
+	return rooms, nil
+}
+
+// FormatRooms ist die Umkehrung von ParseRooms
+func FormatRooms(rooms []Room) string {
+	parts := make([]string, len(rooms))
+	for i, r := range rooms {
+		parts[i] = fmt.Sprintf("%d:%d", r.Adults, r.Children)
+	}
+	return strings.Join(parts, ",")
+}
+
+// RoomScanParams liefert die Parameter, mit denen die Einzelangebote eines Zimmers gesucht werden:
+// Reisende sind die Belegung des Zimmers; MinPrice und die Filter je Person/Nacht gelten für das
+// Paket und entfallen, MaxPrice begrenzt auch jedes einzelne Zimmer.
+func (p SearchParams) RoomScanParams(room Room) SearchParams {
+	scan := p
+	scan.CountAdults, scan.CountChildren = room.Adults, room.Children
+	scan.MinPrice = 0
+	scan.MinPricePerPerson, scan.MaxPricePerPerson = 0, 0
+	scan.MinPricePerNight, scan.MaxPricePerNight = 0, 0
+	return scan
+}
+
+// flightKey identifiziert Hin- und Rückflug eines Angebots
+type flightKey struct {
+	departure, returns              int64
+	outboundArrival, inboundArrival int64
+	outboundFrom, outboundTo        string
+	inboundFrom, inboundTo          string
+}
+
+func offerFlightKey(o *Offer) flightKey {
+	return flightKey{
+		departure:       o.DepartureDate.Unix(),
+		returns:         o.ReturnDate.Unix(),
+		outboundArrival: o.OutboundArrivalDateTime.Unix(),
+		inboundArrival:  o.InboundArrivalDateTime.Unix(),
+		outboundFrom:    o.OutboundDepartureAirport,
+		outboundTo:      o.OutboundArrivalAirport,
+		inboundFrom:     o.InboundDepartureAirport,
+		inboundTo:       o.InboundArrivalAirport,
+	}
+}
+
+// CombineRooms bildet aus den passenden Angeboten eines Hotels Mehrzimmer-Pakete: je Flugpaar
+// für jedes Zimmer ein Angebot mit genau dessen Belegung. Gleich belegte Zimmer erhalten
+// verschiedene Angebote, die günstigsten zuerst (bei gleichem Preis nach Angebots-ID); gibt es
+// für ein Flugpaar weniger passende Angebote als solche Zimmer, entsteht kein Paket. Der
+// Paketpreis ist die Summe und wird gegen die Preisfilter von params geprüft. Ergebnis nach Preis
+// sortiert.
+func CombineRooms(offers []Offer, rooms []Room, params SearchParams) []Offer {
+	// need: Anzahl Zimmer je Belegung; candidates: Angebote je Belegung und Flugpaar
+	need := make(map[Room]int, len(rooms))
+	for _, r := range rooms {
+		need[r]++
+	}
+	candidates := make(map[Room]map[flightKey][]*Offer, len(need))
+	for r := range need {
+		candidates[r] = make(map[flightKey][]*Offer)
+	}
+	for oi := range offers {
+		o := &offers[oi]
+		byFlight, ok := candidates[Room{Adults: o.CountAdults, Children: o.CountChildren}]
+		if !ok {
+			continue
+		}
+		key := offerFlightKey(o)
+		byFlight[key] = append(byFlight[key], o)
+	}
+	for r, byFlight := range candidates {
+		for key, list := range byFlight {
+			sort.Slice(list, func(i, j int) bool {
+				if list[i].Price != list[j].Price {
+					return list[i].Price < list[j].Price
+				}
+				return list[i].ID < list[j].ID
+			})
+			if len(list) > need[r] {
+				byFlight[key] = list[:need[r]]
+			}
+		}
+	}
+
+	var packages []Offer
+	picked := make([]Offer, len(rooms))
+	used := make(map[Room]int, len(need))
+	for key := range candidates[rooms[0]] {
+		clear(used)
+		complete := true
+		for i, r := range rooms {
+			list := candidates[r][key]
+			if used[r] >= len(list) {
+				complete = false
+				break
+			}
+			picked[i] = *list[used[r]]
+			used[r]++
+		}
+		if !complete {
+			continue
+		}
+		pkg := CombineOffers(picked)
+		if !pkg.matchesPrices(params) {
+			continue
+		}
+		packages = append(packages, pkg)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Price != packages[j].Price {
+			return packages[i].Price < packages[j].Price
+		}
+		return packages[i].ID < packages[j].ID
+	})
+	return packages
+}
+
+// CombineOffers fasst die Angebote der Zimmer (gleiche Flüge) zu einem Paket zusammen: Preis und
+// Reisende sind Summen, Zimmertyp und Verpflegung nur gesetzt, wenn sie in allen Zimmern gleich
+// sind. Die ID verbindet die Angebots-IDs der Zimmer mit "+".
+func CombineOffers(rooms []Offer) Offer {
+	pkg := rooms[0]
+	pkg.Rooms = append([]Offer(nil), rooms...)
+	ids := make([]string, len(rooms))
+	ids[0] = rooms[0].ID
+	for i, o := range rooms[1:] {
+		ids[i+1] = o.ID
+		pkg.Price += o.Price
+		pkg.CountAdults += o.CountAdults
+		pkg.CountChildren += o.CountChildren
+		if o.RoomType != pkg.RoomType {
+			pkg.RoomType = ""
+		}
+		if o.MealType != pkg.MealType {
+			pkg.MealType = ""
+		}
+		pkg.OceanView = pkg.OceanView && o.OceanView
+	}
+	pkg.ID = strings.Join(ids, "+")
+	return pkg
+}
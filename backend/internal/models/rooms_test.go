@@ -0,0 +1,218 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/apierror"
+)
+
+func TestParseRooms(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     []Room
+		wantCode string
+	}{
+		{in: "", want: nil},
+		{in: "2:0", want: []Room{{2, 0}}},
+		{in: "2:1, 2:2", want: []Room{{2, 1}, {2, 2}}},
+		{in: "1:0,1:0,1:0,1:0", want: []Room{{1, 0}, {1, 0}, {1, 0}, {1, 0}}},
+		{in: "1:0,1:0,1:0,1:0,1:0", wantCode: apierror.CodeTooManyRooms},
+		{in: "2", wantCode: apierror.CodeInvalidRoom},
+		{in: "0:2", wantCode: apierror.CodeRoomWithoutAdult},
+		{in: "x:0", wantCode: apierror.CodeRoomWithoutAdult},
+		{in: "2:-1", wantCode: apierror.CodeInvalidRoomChildren},
+		{in: "2:x", wantCode: apierror.CodeInvalidRoomChildren},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRooms(tt.in)
+			if tt.wantCode != "" {
+				var e *apierror.Error
+				if !errors.As(err, &e) || e.Code != tt.wantCode {
+					t.Fatalf("got err %v, want code %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if tt.in != "" {
+				if again, _ := ParseRooms(FormatRooms(got)); !reflect.DeepEqual(again, got) {
+					t.Errorf("FormatRooms does not round-trip: %q", FormatRooms(got))
+				}
+			}
+		})
+	}
+}
+
+// roomOffer ist ein Angebot für adults/children mit Abflug flight (Stunde des 1. August)
+func roomOffer(id string, adults, children, flight int, price float64, roomType, meal string) Offer {
+	dep := time.Date(2025, 8, 1, flight, 0, 0, 0, time.UTC)
+	ret := dep.AddDate(0, 0, 7)
+	return Offer{
+		ID:                       id,
+		HotelID:                  1,
+		DepartureDate:            dep,
+		ReturnDate:               ret,
+		CountAdults:              adults,
+		CountChildren:            children,
+		Price:                    price,
+		OutboundDepartureAirport: "FRA",
+		OutboundArrivalAirport:   "PMI",
+		OutboundArrivalDateTime:  dep.Add(2 * time.Hour),
+		InboundDepartureAirport:  "PMI",
+		InboundArrivalAirport:    "FRA",
+		InboundArrivalDateTime:   ret.Add(2 * time.Hour),
+		RoomType:                 roomType,
+		MealType:                 meal,
+		OceanView:                true,
+	}
+}
+
+func TestCombineOffers(t *testing.T) {
+	tests := []struct {
+		name  string
+		rooms []Offer
+		want  Offer
+	}{
+		{
+			name:  "same attributes",
+			rooms: []Offer{roomOffer("a", 2, 0, 6, 500, "double", "halfboard"), roomOffer("b", 2, 1, 6, 700, "double", "halfboard")},
+			want:  Offer{ID: "a+b", Price: 1200, CountAdults: 4, CountChildren: 1, RoomType: "double", MealType: "halfboard", OceanView: true},
+		},
+		{
+			name:  "differing attributes are cleared",
+			rooms: []Offer{roomOffer("a", 2, 0, 6, 500, "double", "halfboard"), roomOffer("b", 1, 0, 6, 300, "single", "halfboard")},
+			want:  Offer{ID: "a+b", Price: 800, CountAdults: 3, RoomType: "", MealType: "halfboard", OceanView: true},
+		},
+		{
+			name: "ocean view only if every room has it",
+			rooms: func() []Offer {
+				b := roomOffer("b", 2, 0, 6, 500, "double", "")
+				b.OceanView = false
+				return []Offer{roomOffer("a", 2, 0, 6, 500, "double", ""), b, roomOffer("c", 1, 0, 6, 200, "double", "")}
+			}(),
+			want: Offer{ID: "a+b+c", Price: 1200, CountAdults: 5, RoomType: "double", OceanView: false},
+		},
+		{
+			name:  "single room",
+			rooms: []Offer{roomOffer("a", 2, 2, 6, 900, "family", "allinclusive")},
+			want:  Offer{ID: "a", Price: 900, CountAdults: 2, CountChildren: 2, RoomType: "family", MealType: "allinclusive", OceanView: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CombineOffers(tt.rooms)
+			if got.ID != tt.want.ID || got.Price != tt.want.Price || got.CountAdults != tt.want.CountAdults ||
+				got.CountChildren != tt.want.CountChildren || got.RoomType != tt.want.RoomType ||
+				got.MealType != tt.want.MealType || got.OceanView != tt.want.OceanView {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(got.Rooms, tt.rooms) {
+				t.Errorf("rooms %v, want %v", got.Rooms, tt.rooms)
+			}
+			if !got.DepartureDate.Equal(tt.rooms[0].DepartureDate) {
+				t.Errorf("departure %v, want the flights of the first room", got.DepartureDate)
+			}
+		})
+	}
+}
+
+func TestCombineOffersCopiesRooms(t *testing.T) {
+	rooms := []Offer{roomOffer("a", 2, 0, 6, 500, "", ""), roomOffer("b", 2, 0, 6, 600, "", "")}
+	pkg := CombineOffers(rooms)
+	rooms[0].Price = 1
+	if pkg.Rooms[0].Price != 500 {
+		t.Errorf("package shares the rooms slice of its caller")
+	}
+}
+
+func TestCombineRooms(t *testing.T) {
+	offers := []Offer{
+		// Flug um 6 Uhr
+		roomOffer("a1", 2, 0, 6, 500, "", ""),
+		roomOffer("a2", 2, 0, 6, 450, "", ""),
+		roomOffer("a3", 2, 0, 6, 450, "", ""),
+		roomOffer("c1", 2, 1, 6, 700, "", ""),
+		// Flug um 9 Uhr: nur ein Angebot für zwei Erwachsene
+		roomOffer("b1", 2, 0, 9, 300, "", ""),
+		roomOffer("d1", 2, 1, 9, 600, "", ""),
+		// andere Belegung, passt zu keinem Zimmer
+		roomOffer("x1", 1, 0, 6, 100, "", ""),
+	}
+	tests := []struct {
+		name   string
+		rooms  []Room
+		params SearchParams
+		want   []string
+	}{
+		{
+			name:  "one package per flight, sorted by price",
+			rooms: []Room{{2, 0}, {2, 1}},
+			want:  []string{"b1+d1", "a2+c1"},
+		},
+		{
+			name:  "identical rooms get distinct offers, cheapest and lowest id first",
+			rooms: []Room{{2, 0}, {2, 0}},
+			want:  []string{"a2+a3"},
+		},
+		{
+			name:  "not enough offers for identical rooms",
+			rooms: []Room{{2, 0}, {2, 0}, {2, 0}, {2, 0}},
+			want:  nil,
+		},
+		{
+			name:  "room order is kept",
+			rooms: []Room{{2, 1}, {2, 0}},
+			want:  []string{"d1+b1", "c1+a2"},
+		},
+		{
+			name:   "package price filter",
+			rooms:  []Room{{2, 0}, {2, 1}},
+			params: SearchParams{MinPrice: 1000},
+			want:   []string{"a2+c1"},
+		},
+		{
+			name:   "max price filters the package",
+			rooms:  []Room{{2, 0}, {2, 1}},
+			params: SearchParams{MaxPrice: 1000},
+			want:   []string{"b1+d1"},
+		},
+		{
+			name:   "price per person of the package",
+			rooms:  []Room{{2, 0}, {2, 1}},
+			params: SearchParams{MaxPricePerPerson: 200},
+			want:   []string{"b1+d1"},
+		},
+		{
+			name:  "no offer for a room",
+			rooms: []Room{{2, 0}, {3, 0}},
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, pkg := range CombineRooms(offers, tt.rooms, tt.params) {
+				got = append(got, pkg.ID)
+				if len(pkg.Rooms) != len(tt.rooms) {
+					t.Errorf("%s: %d rooms, want %d", pkg.ID, len(pkg.Rooms), len(tt.rooms))
+				}
+				for i, r := range tt.rooms {
+					if o := pkg.Rooms[i]; o.CountAdults != r.Adults || o.CountChildren != r.Children {
+						t.Errorf("%s: room %d is %d:%d, want %d:%d", pkg.ID, i, o.CountAdults, o.CountChildren, r.Adults, r.Children)
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
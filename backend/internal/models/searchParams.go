@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/apierror"
+)
 
 // SearchParams repräsentiert die Such-Parameter
 type SearchParams struct {
@@ -10,16 +15,176 @@ type SearchParams struct {
 	CountAdults           int       `query:"countAdults"`
 	CountChildren         int       `query:"countChildren"`
 	Duration              int       `query:"duration"`
+	// MinDuration/MaxDuration begrenzen die Dauer in Tagen (0 = keine Grenze); Duration hat Vorrang
+	MinDuration int `query:"minDuration"`
+	MaxDuration int `query:"maxDuration"`
+	// FlexDays macht aus EarliestDepartureDate ein Abflugfenster von ± FlexDays Tagen
+	FlexDays  int      `query:"flexDays"`
+	MealTypes []string `query:"mealTypes"`
+	RoomTypes []string `query:"roomTypes"`
+	// OceanView: nil = egal, sonst muss das Angebot genau diesen Wert haben
+	OceanView *bool   `query:"oceanView"`
+	MinPrice  float64 `query:"minPrice"`
+	MaxPrice  float64 `query:"maxPrice"`
+	// Preis je Person bzw. je Nacht (siehe Offer.PricePerPerson, Offer.PricePerNight); 0 = keine Grenze
+	MinPricePerPerson float64 `query:"minPricePerPerson"`
+	MaxPricePerPerson float64 `query:"maxPricePerPerson"`
+	MinPricePerNight  float64 `query:"minPricePerNight"`
+	MaxPricePerNight  float64 `query:"maxPricePerNight"`
+	// MinStars wird gegen Hotel.Stars geprüft (siehe Hotel.Matches), nicht gegen das Angebot
+	MinStars float64 `query:"minStars"`
+}
+
+// ToSearchParams prüft die API-Parameter und konvertiert sie zu SearchParams; reine Datumsangaben
+// gelten in der Zeitzone loc (siehe ParseSearchDate)
+func (params ApiSearchParams) ToSearchParams(loc *time.Location) (SearchParams, error) {
+	var result SearchParams
+
+	// Departure Airports
+	result.DepartureAirports = params.DepartureAirports
+
+	// Earliest Departure Date - ab Beginn des Tages
+	if params.EarliestDepartureDate != "" {
+		date, err := ParseSearchDate(params.EarliestDepartureDate, loc, false)
+		if err != nil {
+			return result, apierror.New(apierror.CodeInvalidDate, "param", "earliestDepartureDate", "value", params.EarliestDepartureDate)
+		}
+		result.EarliestDepartureDate = date
+	}
+
+	// Latest Return Date - einschließlich des ganzen Tages
+	if params.LatestReturnDate != "" {
+		date, err := ParseSearchDate(params.LatestReturnDate, loc, true)
+		if err != nil {
+			return result, apierror.New(apierror.CodeInvalidDate, "param", "latestReturnDate", "value", params.LatestReturnDate)
+		}
+		result.LatestReturnDate = date
+	}
+
+	// Counts and Duration
+	result.CountAdults = params.CountAdults
+	result.CountChildren = params.CountChildren
+	result.Duration = params.Duration
+	if params.MaxDuration > 0 && params.MinDuration > params.MaxDuration {
+		return result, rangeInverted("minDuration", "maxDuration")
+	}
+	result.MinDuration = params.MinDuration
+	result.MaxDuration = params.MaxDuration
+	if params.FlexDays > 0 && result.EarliestDepartureDate.IsZero() {
+		return result, apierror.New(apierror.CodeFlexDaysWithoutDate)
+	}
+	result.FlexDays = params.FlexDays
+
+	// Zusätzliche Filter
+	result.MealTypes = params.MealTypes
+	result.RoomTypes = params.RoomTypes
+	if params.OceanView != "" {
+		oceanView, err := strconv.ParseBool(params.OceanView)
+		if err != nil {
+			return result, apierror.New(apierror.CodeInvalidBoolean, "param", "oceanView", "value", params.OceanView)
+		}
+		result.OceanView = &oceanView
+	}
+	if params.MaxPrice > 0 && params.MinPrice > params.MaxPrice {
+		return result, rangeInverted("minPrice", "maxPrice")
+	}
+	result.MinPrice = params.MinPrice
+	result.MaxPrice = params.MaxPrice
+	if params.MaxPricePerPerson > 0 && params.MinPricePerPerson > params.MaxPricePerPerson {
+		return result, rangeInverted("minPricePerPerson", "maxPricePerPerson")
+	}
+	result.MinPricePerPerson = params.MinPricePerPerson
+	result.MaxPricePerPerson = params.MaxPricePerPerson
+	if params.MaxPricePerNight > 0 && params.MinPricePerNight > params.MaxPricePerNight {
+		return result, rangeInverted("minPricePerNight", "maxPricePerNight")
+	}
+	result.MinPricePerNight = params.MinPricePerNight
+	result.MaxPricePerNight = params.MaxPricePerNight
+	result.MinStars = params.MinStars
+
+	return result, nil
+}
+
+// ParseSearchDate parst eine Datumsgrenze der Suche: RFC 3339 mit Offset (unverändert) oder
+// YYYY-MM-DD als Beginn des Tages in loc. Mit endOfDay ergibt ein reines Datum den letzten
+// Zeitpunkt des Tages, damit die Grenze den ganzen Tag einschließt.
+func ParseSearchDate(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		// AddDate statt 24h, damit Tage mit Zeitumstellung stimmen
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return day, nil
+}
+
+// rangeInverted meldet eine Unter- größer als die Obergrenze
+func rangeInverted(min, max string) error {
+	return apierror.New(apierror.CodeRangeInverted, "min", min, "max", max)
+}
+
+// HasOfferAttributeFilters meldet, ob Filter gesetzt sind, die über Abflughafen, Reisende,
+// Dauer und Datum hinausgehen (Verpflegung, Zimmer, Meerblick, Preisspanne, Preis je Person/Nacht)
+func (p SearchParams) HasOfferAttributeFilters() bool {
+	return len(p.MealTypes) > 0 || len(p.RoomTypes) > 0 || p.OceanView != nil || p.MinPrice > 0 || p.MaxPrice > 0 ||
+		p.HasUnitPriceFilters()
+}
+
+// HasUnitPriceFilters meldet, ob nach Preis je Person oder je Nacht gefiltert wird
+func (p SearchParams) HasUnitPriceFilters() bool {
+	return p.MinPricePerPerson > 0 || p.MaxPricePerPerson > 0 || p.MinPricePerNight > 0 || p.MaxPricePerNight > 0
+}
+
+// DurationBounds liefert die erlaubte Dauer in Tagen als [min, max]; 0 bedeutet keine Grenze.
+// Eine exakte Duration hat Vorrang vor MinDuration/MaxDuration.
+func (p SearchParams) DurationBounds() (minDuration, maxDuration int) {
+	if p.Duration != 0 {
+		return p.Duration, p.Duration
+	}
+	return p.MinDuration, p.MaxDuration
+}
+
+// ExactDuration meldet, ob die Suche genau eine Dauer zulässt
+func (p SearchParams) ExactDuration() (int, bool) {
+	minDuration, maxDuration := p.DurationBounds()
+	return minDuration, minDuration > 0 && minDuration == maxDuration
+}
+
+// DepartureWindow liefert das Abflugfenster [from, until). Ohne FlexDays ist es nach oben offen
+// (until ist Null) und beginnt bei EarliestDepartureDate; mit FlexDays umfasst es die ganzen Tage
+// EarliestDepartureDate ± FlexDays.
+func (p SearchParams) DepartureWindow() (from, until time.Time) {
+	if p.EarliestDepartureDate.IsZero() {
+		return time.Time{}, time.Time{}
+	}
+	if p.FlexDays <= 0 {
+		return p.EarliestDepartureDate, time.Time{}
+	}
+	return p.EarliestDepartureDate.AddDate(0, 0, -p.FlexDays), p.EarliestDepartureDate.AddDate(0, 0, p.FlexDays+1)
 }
 
 // Matches prüft, ob ein Angebot den Such-Parametern entspricht
 func (o *Offer) Matches(params SearchParams) bool {
 	return o.matchesDepartureAirports(params.DepartureAirports) &&
-		o.matchesEarliestDepartureDate(params.EarliestDepartureDate) &&
+		o.matchesDepartureWindow(params.DepartureWindow()) &&
 		o.matchesLatestReturnDate(params.LatestReturnDate) &&
 		o.matchesCountAdults(params.CountAdults) &&
 		o.matchesCountChildren(params.CountChildren) &&
-		o.matchesDuration(params.Duration)
+		o.matchesDuration(params.DurationBounds()) &&
+		matchesOneOf(o.MealType, params.MealTypes) &&
+		matchesOneOf(o.RoomType, params.RoomTypes) &&
+		o.matchesOceanView(params.OceanView) &&
+		o.matchesPrices(params)
+}
+
+// Matches prüft die Hotel-bezogenen Such-Parameter (Mindest-Sterne)
+func (h *Hotel) Matches(params SearchParams) bool {
+	return params.MinStars == 0 || h.Stars >= params.MinStars
 }
 
 // matchesDepartureAirports prüft, ob das Angebot einen der gewünschten Abflughäfen hat
@@ -35,9 +200,9 @@ func (o *Offer) matchesDepartureAirports(airports []string) bool {
 	return false
 }
 
-// matchesEarliestDepartureDate prüft das früheste Abflugdatum
-func (o *Offer) matchesEarliestDepartureDate(earliestDate time.Time) bool {
-	return earliestDate.IsZero() || !o.DepartureDate.Before(earliestDate)
+// matchesDepartureWindow prüft das Abflugdatum gegen [from, until) (Null = keine Grenze)
+func (o *Offer) matchesDepartureWindow(from, until time.Time) bool {
+	return (from.IsZero() || !o.DepartureDate.Before(from)) && (until.IsZero() || o.DepartureDate.Before(until))
 }
 
 // matchesLatestReturnDate prüft das späteste Rückflugdatum
@@ -55,7 +220,46 @@ func (o *Offer) matchesCountChildren(countChildren int) bool {
 	return countChildren == 0 || o.CountChildren == countChildren
 }
 
-// matchesDuration prüft die Dauer
-func (o *Offer) matchesDuration(duration int) bool {
-	return duration == 0 || o.Duration() == duration
+// matchesDuration prüft die Dauer gegen [minDuration, maxDuration] (0 = keine Grenze)
+func (o *Offer) matchesDuration(minDuration, maxDuration int) bool {
+	d := o.Duration()
+	return (minDuration == 0 || d >= minDuration) && (maxDuration == 0 || d <= maxDuration)
+}
+
+// matchesOneOf prüft, ob value in values enthalten ist (leere Liste = alle)
+func matchesOneOf(value string, values []string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesOceanView prüft den Meerblick (nil = egal)
+func (o *Offer) matchesOceanView(oceanView *bool) bool {
+	return oceanView == nil || o.OceanView == *oceanView
+}
+
+// matchesPriceRange prüft Mindest- und Höchstpreis (0 = keine Grenze)
+func (o *Offer) matchesPriceRange(minPrice, maxPrice float64) bool {
+	return (minPrice == 0 || o.Price >= minPrice) && (maxPrice == 0 || o.Price <= maxPrice)
+}
+
+// matchesPrices prüft alle Preisfilter: Gesamtpreis, Preis je Person und je Nacht
+func (o *Offer) matchesPrices(params SearchParams) bool {
+	if !o.matchesPriceRange(params.MinPrice, params.MaxPrice) {
+		return false
+	}
+	if !params.HasUnitPriceFilters() {
+		return true
+	}
+	perPerson, perNight := o.PricePerPerson(), o.PricePerNight()
+	return (params.MinPricePerPerson == 0 || perPerson >= params.MinPricePerPerson) &&
+		(params.MaxPricePerPerson == 0 || perPerson <= params.MaxPricePerPerson) &&
+		(params.MinPricePerNight == 0 || perNight >= params.MinPricePerNight) &&
+		(params.MaxPricePerNight == 0 || perNight <= params.MaxPricePerNight)
 }
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseSearchDate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		in       string
+		endOfDay bool
+		want     time.Time
+		wantErr  bool
+	}{
+		{name: "start of summer day", in: "2025-08-15", want: time.Date(2025, 8, 14, 22, 0, 0, 0, time.UTC)},
+		{name: "end of summer day", in: "2025-08-15", endOfDay: true, want: time.Date(2025, 8, 15, 21, 59, 59, 999999999, time.UTC)},
+		{name: "start of winter day", in: "2025-01-15", want: time.Date(2025, 1, 14, 23, 0, 0, 0, time.UTC)},
+		{name: "end of winter day", in: "2025-01-15", endOfDay: true, want: time.Date(2025, 1, 15, 22, 59, 59, 999999999, time.UTC)},
+		// 30.03.2025 hat 23 Stunden, 26.10.2025 hat 25 Stunden
+		{name: "start of spring forward", in: "2025-03-30", want: time.Date(2025, 3, 29, 23, 0, 0, 0, time.UTC)},
+		{name: "end of spring forward", in: "2025-03-30", endOfDay: true, want: time.Date(2025, 3, 30, 21, 59, 59, 999999999, time.UTC)},
+		{name: "start of fall back", in: "2025-10-26", want: time.Date(2025, 10, 25, 22, 0, 0, 0, time.UTC)},
+		{name: "end of fall back", in: "2025-10-26", endOfDay: true, want: time.Date(2025, 10, 26, 22, 59, 59, 999999999, time.UTC)},
+		// mit Offset gilt genau der Zeitpunkt, auch als Obergrenze
+		{name: "offset", in: "2025-08-15T06:00:00+02:00", want: time.Date(2025, 8, 15, 4, 0, 0, 0, time.UTC)},
+		{name: "offset end of day", in: "2025-08-15T06:00:00Z", endOfDay: true, want: time.Date(2025, 8, 15, 6, 0, 0, 0, time.UTC)},
+		{name: "invalid", in: "15.08.2025", wantErr: true},
+		{name: "no offset", in: "2025-08-15T06:00:00", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSearchDate(tt.in, berlin, tt.endOfDay)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got.UTC(), tt.want)
+			}
+		})
+	}
+}
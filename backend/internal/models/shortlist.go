@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status eines Merkzettel-Eintrags nach erneuter Preisprüfung
+const (
+	ShortlistStatusAvailable     = "available"
+	ShortlistStatusPriceIncrease = "priceIncreased"
+	ShortlistStatusPriceDecrease = "priceDecreased"
+	ShortlistStatusUnavailable   = "unavailable"
+)
+
+// ShortlistItem ist ein gemerktes Hotel oder ein gemerktes Angebot
+type ShortlistItem struct {
+	ID      string `json:"id" doc:"Item id: hotel-<hotelId> for hotels, the offer id for offers"`
+	HotelID int    `json:"hotelId"`
+	// Offer ist der Stand des Angebots beim Merken; nil für gemerkte Hotels
+	Offer *Offer `json:"offer,omitempty"`
+	Note  string `json:"note,omitempty"`
+	// SavedPrice ist der Angebotspreis (bzw. der günstigste Hotelpreis) beim Merken
+	SavedPrice float64   `json:"savedPrice"`
+	AddedAt    time.Time `json:"addedAt"`
+
+	// Beim Lesen neu berechnet
+	Hotel        *Hotel   `json:"hotel,omitempty"`
+	CurrentPrice *float64 `json:"currentPrice,omitempty" doc:"Current price of the offer, or the hotel's current cheapest offer"`
+	Status       string   `json:"status,omitempty" doc:"available, priceIncreased, priceDecreased or unavailable"`
+}
+
+// ShortlistHotelItemID ist die Eintrags-ID eines gemerkten Hotels
+func ShortlistHotelItemID(hotelID int) string {
+	return fmt.Sprintf("hotel-%d", hotelID)
+}
+
+// ApiShortlistItemInput ist der Body zum Anlegen eines Eintrags
+type ApiShortlistItemInput struct {
+	HotelID int    `json:"hotelId" minimum:"1" doc:"Hotel to remember"`
+	Offer   *Offer `json:"offer,omitempty" required:"false" doc:"Specific offer to remember, as returned by the search endpoints"`
+	Note    string `json:"note,omitempty" required:"false" maxLength:"500"`
+}
+
+// ApiShortlistNoteInput ist der Body zum Ändern eines Eintrags
+type ApiShortlistNoteInput struct {
+	Note string `json:"note" maxLength:"500"`
+}
+
+// ShortlistItemsResponse für Huma API
+type ShortlistItemsResponse struct {
+	CurrencyHeaders
+	Body []ShortlistItem `json:"items"`
+}
+
+// ShortlistItemResponse für Huma API
+type ShortlistItemResponse struct {
+	CurrencyHeaders
+	Body ShortlistItem `json:"item"`
+}
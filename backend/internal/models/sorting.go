@@ -0,0 +1,125 @@
+package models
+
+import (
+	"container/heap"
+	"sort"
+	"strings"
+)
+
+// Sortierschlüssel für die Hotel-Ergebnisliste
+const (
+	SortByPrice          = "price"
+	SortByStars          = "stars"
+	SortByPricePerNight  = "pricePerNight"
+	SortByPricePerPerson = "pricePerPerson"
+	SortByName           = "name"
+	SortByValueScore     = "valueScore"
+)
+
+// HotelOrder beschreibt Sortierung und Ausschnitt der Hotel-Ergebnisliste
+type HotelOrder struct {
+	SortBy string
+	// Descending kehrt die Sortierung um; ohne Angabe gilt DefaultDescending(SortBy)
+	Descending bool
+	// Limit begrenzt die Anzahl der Ergebnisse (0 = alle), Offset überspringt die ersten Ergebnisse
+	Limit  int
+	Offset int
+}
+
+// DefaultDescending liefert die natürliche Richtung eines Sortierschlüssels:
+// Sterne und Preis-Leistung absteigend, alles andere aufsteigend
+func DefaultDescending(sortBy string) bool {
+	return sortBy == SortByStars || sortBy == SortByValueScore
+}
+
+// ValueScore bewertet das Preis-Leistungs-Verhältnis: Sterne je 100€ Preis pro Nacht
+func (h *HotelWithBestOffer) ValueScore() float64 {
+	perNight := h.BestOffer.PricePerNight()
+	if perNight <= 0 {
+		return 0
+	}
+	return h.Hotel.Stars / perNight * 100
+}
+
+// less vergleicht aufsteigend nach dem Sortierschlüssel; bei Gleichstand entscheidet der Preis, dann die Hotel-ID
+func (o HotelOrder) less(a, b *HotelWithBestOffer) bool {
+	var cmp int
+	switch o.SortBy {
+	case SortByStars:
+		cmp = compareFloat(a.Hotel.Stars, b.Hotel.Stars)
+	case SortByPricePerNight:
+		cmp = compareFloat(a.BestOffer.PricePerNight(), b.BestOffer.PricePerNight())
+	case SortByPricePerPerson:
+		cmp = compareFloat(a.BestOffer.PricePerPerson(), b.BestOffer.PricePerPerson())
+	case SortByName:
+		cmp = strings.Compare(strings.ToLower(a.Hotel.Name), strings.ToLower(b.Hotel.Name))
+	case SortByValueScore:
+		cmp = compareFloat(a.ValueScore(), b.ValueScore())
+	}
+	if o.Descending {
+		cmp = -cmp
+	}
+	if cmp == 0 {
+		cmp = compareFloat(a.BestOffer.Price, b.BestOffer.Price)
+	}
+	if cmp == 0 {
+		cmp = a.Hotel.ID - b.Hotel.ID
+	}
+	return cmp < 0
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// SelectHotels sortiert results nach order und liefert den Ausschnitt [Offset, Offset+Limit).
+// Mit Limit wird statt einer vollständigen Sortierung nur eine Top-K-Auswahl (K = Offset+Limit)
+// über einen Heap gebildet. results wird dabei umsortiert.
+func SelectHotels(results []HotelWithBestOffer, order HotelOrder) []HotelWithBestOffer {
+	if order.Offset >= len(results) {
+		return []HotelWithBestOffer{}
+	}
+	k := order.Offset + order.Limit
+	if order.Limit <= 0 || k >= len(results) {
+		sort.Slice(results, func(i, j int) bool { return order.less(&results[i], &results[j]) })
+		if order.Limit <= 0 {
+			return results[order.Offset:]
+		}
+		return results[order.Offset:min(k, len(results))]
+	}
+
+	// Max-Heap der K besten: die Wurzel ist das schlechteste der bisher besten Ergebnisse
+	h := &topK{order: order, items: make([]HotelWithBestOffer, 0, k)}
+	for i := range results {
+		if h.Len() < k {
+			heap.Push(h, results[i])
+		} else if order.less(&results[i], &h.items[0]) {
+			h.items[0] = results[i]
+			heap.Fix(h, 0)
+		}
+	}
+	sort.Slice(h.items, func(i, j int) bool { return order.less(&h.items[i], &h.items[j]) })
+	return h.items[order.Offset:]
+}
+
+// topK implementiert heap.Interface als Max-Heap bezüglich HotelOrder
+type topK struct {
+	order HotelOrder
+	items []HotelWithBestOffer
+}
+
+func (h *topK) Len() int           { return len(h.items) }
+func (h *topK) Less(i, j int) bool { return h.order.less(&h.items[j], &h.items[i]) }
+func (h *topK) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *topK) Push(x any)         { h.items = append(h.items, x.(HotelWithBestOffer)) }
+func (h *topK) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
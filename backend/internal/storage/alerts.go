@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+// AlertStore persists price alerts and their delivery log. DeleteAlert and SetNotified return
+// ErrNotFound for unknown alerts; deliveries are listed newest first.
+type AlertStore interface {
+	ListAlerts(ctx context.Context) ([]models.Alert, error)
+	GetAlert(ctx context.Context, alertID string) (*models.Alert, error)
+	SaveAlert(ctx context.Context, alert models.Alert) error
+	DeleteAlert(ctx context.Context, alertID string) error
+	// SetNotified replaces the notified hotels of an alert and records the evaluation time
+	SetNotified(ctx context.Context, alertID string, notified map[int]float64, evaluatedAt time.Time) error
+	AddDelivery(ctx context.Context, delivery models.AlertDelivery) error
+	ListDeliveries(ctx context.Context, alertID string, limit int) ([]models.AlertDelivery, error)
+}
+
+var (
+	_ AlertStore = (*ScyllaAlertStore)(nil)
+	_ AlertStore = (*MemoryAlertStore)(nil)
+)
+
+// alertsTableCQL and alertDeliveriesTableCQL are kept in sync with infra/scylla/schema.cql
+const (
+	alertsTableCQL = `CREATE TABLE IF NOT EXISTS alerts (
+	alertid text PRIMARY KEY,
+	search text,
+	targetprice double,
+	webhookurl text,
+	secret text,
+	notified map<int, double>,
+	createdat timestamp,
+	lastevaluatedat timestamp
+)`
+	alertDeliveriesTableCQL = `CREATE TABLE IF NOT EXISTS alert_deliveries (
+	alertid text,
+	createdat timestamp,
+	deliveryid text,
+	hotelids list<int>,
+	attempts int,
+	statuscode int,
+	error text,
+	delivered boolean,
+	PRIMARY KEY ((alertid), createdat, deliveryid)
+) WITH CLUSTERING ORDER BY (createdat DESC, deliveryid ASC) AND default_time_to_live = 2592000`
+)
+
+// ScyllaAlertStore stores alerts in the alerts table (the saved search as JSON) and the delivery
+// log in alert_deliveries, one partition per alert; log entries expire after 30 days.
+type ScyllaAlertStore struct {
+	session *gocql.Session
+}
+
+// NewScyllaAlertStore creates the alert tables if needed
+func NewScyllaAlertStore(session *gocql.Session) (*ScyllaAlertStore, error) {
+	for name, stmt := range map[string]string{"alerts": alertsTableCQL, "alert_deliveries": alertDeliveriesTableCQL} {
+		if err := session.Query(stmt).Exec(); err != nil {
+			return nil, fmt.Errorf("create %s: %w", name, err)
+		}
+	}
+	return &ScyllaAlertStore{session: session}, nil
+}
+
+const alertSelect = `SELECT alertid, search, targetprice, webhookurl, secret, notified, createdat, lastevaluatedat FROM alerts`
+
+func (s *ScyllaAlertStore) ListAlerts(ctx context.Context) ([]models.Alert, error) {
+	iter := s.session.Query(alertSelect).WithContext(ctx).Iter()
+	alerts := []models.Alert{}
+	for {
+		alert, ok, err := scanAlert(iter)
+		if err != nil {
+			iter.Close()
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, classifyErr(fmt.Errorf("list alerts: %w", err))
+	}
+	sortAlerts(alerts)
+	return alerts, nil
+}
+
+func (s *ScyllaAlertStore) GetAlert(ctx context.Context, alertID string) (*models.Alert, error) {
+	iter := s.session.Query(alertSelect+` WHERE alertid = ?`, alertID).WithContext(ctx).Iter()
+	alert, ok, err := scanAlert(iter)
+	if cerr := iter.Close(); err == nil && cerr != nil {
+		err = classifyErr(fmt.Errorf("get alert %s: %w", alertID, cerr))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &alert, nil
+}
+
+func (s *ScyllaAlertStore) SaveAlert(ctx context.Context, alert models.Alert) error {
+	search, err := json.Marshal(alert.Search)
+	if err != nil {
+		return err
+	}
+	err = s.session.Query(`INSERT INTO alerts (alertid, search, targetprice, webhookurl, secret, notified, createdat, lastevaluatedat) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		alert.ID, string(search), alert.TargetPrice, alert.WebhookURL, alert.Secret, alert.Notified, alert.CreatedAt, alert.LastEvaluatedAt).WithContext(ctx).Exec()
+	if err != nil {
+		return classifyErr(fmt.Errorf("save alert %s: %w", alert.ID, err))
+	}
+	return nil
+}
+
+func (s *ScyllaAlertStore) DeleteAlert(ctx context.Context, alertID string) error {
+	applied, err := s.session.Query(`DELETE FROM alerts WHERE alertid = ? IF EXISTS`, alertID).WithContext(ctx).ScanCAS()
+	if err != nil {
+		return classifyErr(fmt.Errorf("delete alert %s: %w", alertID, err))
+	}
+	if !applied {
+		return ErrNotFound
+	}
+	if err := s.session.Query(`DELETE FROM alert_deliveries WHERE alertid = ?`, alertID).WithContext(ctx).Exec(); err != nil {
+		return classifyErr(fmt.Errorf("delete deliveries of alert %s: %w", alertID, err))
+	}
+	return nil
+}
+
+func (s *ScyllaAlertStore) SetNotified(ctx context.Context, alertID string, notified map[int]float64, evaluatedAt time.Time) error {
+	applied, err := s.session.Query(`UPDATE alerts SET notified = ?, lastevaluatedat = ? WHERE alertid = ? IF EXISTS`, notified, evaluatedAt, alertID).
+		WithContext(ctx).ScanCAS()
+	if err != nil {
+		return classifyErr(fmt.Errorf("update alert %s: %w", alertID, err))
+	}
+	if !applied {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *ScyllaAlertStore) AddDelivery(ctx context.Context, d models.AlertDelivery) error {
+	err := s.session.Query(`INSERT INTO alert_deliveries (alertid, createdat, deliveryid, hotelids, attempts, statuscode, error, delivered) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.AlertID, d.CreatedAt, d.ID, d.HotelIDs, d.Attempts, d.StatusCode, d.Error, d.Delivered).WithContext(ctx).Exec()
+	if err != nil {
+		return classifyErr(fmt.Errorf("save delivery %s of alert %s: %w", d.ID, d.AlertID, err))
+	}
+	return nil
+}
+
+func (s *ScyllaAlertStore) ListDeliveries(ctx context.Context, alertID string, limit int) ([]models.AlertDelivery, error) {
+	iter := s.session.Query(`SELECT createdat, deliveryid, hotelids, attempts, statuscode, error, delivered FROM alert_deliveries WHERE alertid = ? LIMIT ?`, alertID, limit).
+		WithContext(ctx).Iter()
+	deliveries := []models.AlertDelivery{}
+	d := models.AlertDelivery{AlertID: alertID}
+	for iter.Scan(&d.CreatedAt, &d.ID, &d.HotelIDs, &d.Attempts, &d.StatusCode, &d.Error, &d.Delivered) {
+		deliveries = append(deliveries, d)
+		d = models.AlertDelivery{AlertID: alertID}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, classifyErr(fmt.Errorf("list deliveries of alert %s: %w", alertID, err))
+	}
+	return deliveries, nil
+}
+
+// scanAlert reads the next row selected by alertSelect
+func scanAlert(iter *gocql.Iter) (models.Alert, bool, error) {
+	var (
+		alert         models.Alert
+		search        string
+		lastEvaluated time.Time
+	)
+	if !iter.Scan(&alert.ID, &search, &alert.TargetPrice, &alert.WebhookURL, &alert.Secret, &alert.Notified, &alert.CreatedAt, &lastEvaluated) {
+		return alert, false, nil
+	}
+	if err := json.Unmarshal([]byte(search), &alert.Search); err != nil {
+		return alert, false, fmt.Errorf("decode alert %s: %w", alert.ID, err)
+	}
+	if !lastEvaluated.IsZero() {
+		alert.LastEvaluatedAt = &lastEvaluated
+	}
+	return alert, true, nil
+}
+
+// maxMemoryDeliveries bounds the delivery log per alert of the MemoryAlertStore
+const maxMemoryDeliveries = 100
+
+// MemoryAlertStore keeps alerts in process memory; they are lost on restart.
+// Used with the memory backend or when the Scylla tables are unavailable.
+type MemoryAlertStore struct {
+	mu         sync.RWMutex
+	alerts     map[string]models.Alert
+	deliveries map[string][]models.AlertDelivery // oldest first
+}
+
+// NewMemoryAlertStore creates an empty in-memory store
+func NewMemoryAlertStore() *MemoryAlertStore {
+	return &MemoryAlertStore{
+		alerts:     make(map[string]models.Alert),
+		deliveries: make(map[string][]models.AlertDelivery),
+	}
+}
+
+func (s *MemoryAlertStore) ListAlerts(ctx context.Context) ([]models.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	alerts := make([]models.Alert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		alerts = append(alerts, copyAlert(alert))
+	}
+	sortAlerts(alerts)
+	return alerts, nil
+}
+
+func (s *MemoryAlertStore) GetAlert(ctx context.Context, alertID string) (*models.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	alert, ok := s.alerts[alertID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	alert = copyAlert(alert)
+	return &alert, nil
+}
+
+func (s *MemoryAlertStore) SaveAlert(ctx context.Context, alert models.Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts[alert.ID] = copyAlert(alert)
+	return nil
+}
+
+func (s *MemoryAlertStore) DeleteAlert(ctx context.Context, alertID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.alerts[alertID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.alerts, alertID)
+	delete(s.deliveries, alertID)
+	return nil
+}
+
+func (s *MemoryAlertStore) SetNotified(ctx context.Context, alertID string, notified map[int]float64, evaluatedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	alert, ok := s.alerts[alertID]
+	if !ok {
+		return ErrNotFound
+	}
+	alert.Notified = notified
+	alert.LastEvaluatedAt = &evaluatedAt
+	s.alerts[alertID] = copyAlert(alert)
+	return nil
+}
+
+func (s *MemoryAlertStore) AddDelivery(ctx context.Context, d models.AlertDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log := append(s.deliveries[d.AlertID], d)
+	if len(log) > maxMemoryDeliveries {
+		log = log[len(log)-maxMemoryDeliveries:]
+	}
+	s.deliveries[d.AlertID] = log
+	return nil
+}
+
+func (s *MemoryAlertStore) ListDeliveries(ctx context.Context, alertID string, limit int) ([]models.AlertDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	log := s.deliveries[alertID]
+	deliveries := make([]models.AlertDelivery, 0, min(limit, len(log)))
+	for i := len(log) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		deliveries = append(deliveries, log[i])
+	}
+	return deliveries, nil
+}
+
+// copyAlert copies the notified map so callers cannot modify the stored alert
+func copyAlert(alert models.Alert) models.Alert {
+	notified := make(map[int]float64, len(alert.Notified))
+	for id, price := range alert.Notified {
+		notified[id] = price
+	}
+	alert.Notified = notified
+	return alert
+}
+
+// sortAlerts orders alerts by creation time, oldest first
+func sortAlerts(alerts []models.Alert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].CreatedAt.Equal(alerts[j].CreatedAt) {
+			return alerts[i].CreatedAt.Before(alerts[j].CreatedAt)
+		}
+		return alerts[i].ID < alerts[j].ID
+	})
+}
@@ -0,0 +1,346 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+var (
+	// ErrOfferUnavailable is returned by the booking workflow when the offer no longer exists
+	ErrOfferUnavailable = errors.New("offer no longer available")
+	// ErrBookingExpired is returned when confirming a hold after its expiry
+	ErrBookingExpired = errors.New("booking hold expired")
+)
+
+// PriceChangedError is returned by the booking workflow when the offer's current price differs
+// from the held (or expected) price. Prices are in Euro unless Currency is set.
+type PriceChangedError struct {
+	Held, Current float64
+	Currency      string
+}
+
+func (e *PriceChangedError) Error() string {
+	return fmt.Sprintf("price changed from %.2f to %.2f", e.Held, e.Current)
+}
+
+// BookingStatusError is returned when a booking's status does not allow the requested transition
+type BookingStatusError struct {
+	Status string
+}
+
+func (e *BookingStatusError) Error() string {
+	return fmt.Sprintf("booking is %s", e.Status)
+}
+
+// BookingStore persists bookings. UpdateBookingStatus changes the status only if it still is
+// from (compare-and-set) and returns a *BookingStatusError with the actual status otherwise.
+type BookingStore interface {
+	CreateBooking(ctx context.Context, booking models.Booking) error
+	GetBooking(ctx context.Context, bookingID string) (*models.Booking, error)
+	UpdateBookingStatus(ctx context.Context, bookingID, from, to string, at time.Time) error
+}
+
+var (
+	_ BookingStore = (*ScyllaBookingStore)(nil)
+	_ BookingStore = (*MemoryBookingStore)(nil)
+)
+
+// bookingsTableCQL is kept in sync with infra/scylla/schema.cql
+const bookingsTableCQL = `CREATE TABLE IF NOT EXISTS bookings (
+	bookingid text PRIMARY KEY,
+	offerid text,
+	hotelid int,
+	offer text,
+	price double,
+	status text,
+	createdat timestamp,
+	expiresat timestamp,
+	updatedat timestamp
+)`
+
+// ScyllaBookingStore stores bookings in the bookings table; status changes are lightweight
+// transactions so concurrent confirm/cancel/expire calls cannot both succeed.
+type ScyllaBookingStore struct {
+	session *gocql.Session
+}
+
+// NewScyllaBookingStore creates the bookings table if needed
+func NewScyllaBookingStore(session *gocql.Session) (*ScyllaBookingStore, error) {
+	if err := session.Query(bookingsTableCQL).Exec(); err != nil {
+		return nil, fmt.Errorf("create bookings: %w", err)
+	}
+	return &ScyllaBookingStore{session: session}, nil
+}
+
+func (s *ScyllaBookingStore) CreateBooking(ctx context.Context, b models.Booking) error {
+	offer, err := json.Marshal(b.Offer)
+	if err != nil {
+		return err
+	}
+	applied, err := s.session.Query(`INSERT INTO bookings (bookingid, offerid, hotelid, offer, price, status, createdat, expiresat, updatedat) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) IF NOT EXISTS`,
+		b.ID, b.OfferID, b.HotelID, string(offer), b.Price, b.Status, b.CreatedAt, b.ExpiresAt, b.UpdatedAt).WithContext(ctx).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return classifyErr(fmt.Errorf("create booking %s: %w", b.ID, err))
+	}
+	if !applied {
+		return fmt.Errorf("create booking %s: id already taken", b.ID)
+	}
+	return nil
+}
+
+func (s *ScyllaBookingStore) GetBooking(ctx context.Context, bookingID string) (*models.Booking, error) {
+	var (
+		b     models.Booking
+		offer string
+	)
+	err := s.session.Query(`SELECT bookingid, offerid, hotelid, offer, price, status, createdat, expiresat, updatedat FROM bookings WHERE bookingid = ?`, bookingID).
+		WithContext(ctx).Scan(&b.ID, &b.OfferID, &b.HotelID, &offer, &b.Price, &b.Status, &b.CreatedAt, &b.ExpiresAt, &b.UpdatedAt)
+	if errors.Is(err, gocql.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, classifyErr(fmt.Errorf("get booking %s: %w", bookingID, err))
+	}
+	if err := json.Unmarshal([]byte(offer), &b.Offer); err != nil {
+		return nil, fmt.Errorf("decode booking %s: %w", bookingID, err)
+	}
+	return &b, nil
+}
+
+func (s *ScyllaBookingStore) UpdateBookingStatus(ctx context.Context, bookingID, from, to string, at time.Time) error {
+	previous := map[string]interface{}{}
+	applied, err := s.session.Query(`UPDATE bookings SET status = ?, updatedat = ? WHERE bookingid = ? IF status = ?`, to, at, bookingID, from).
+		WithContext(ctx).MapScanCAS(previous)
+	if err != nil {
+		return classifyErr(fmt.Errorf("update booking %s: %w", bookingID, err))
+	}
+	if applied {
+		return nil
+	}
+	// a missing row reports a null status
+	status, _ := previous["status"].(string)
+	if status == "" {
+		return ErrNotFound
+	}
+	return &BookingStatusError{Status: status}
+}
+
+// MemoryBookingStore keeps bookings in process memory; they are lost on restart.
+// Used with the memory backend or when the Scylla table is unavailable.
+type MemoryBookingStore struct {
+	mu       sync.Mutex
+	bookings map[string]models.Booking
+}
+
+// NewMemoryBookingStore creates an empty in-memory store
+func NewMemoryBookingStore() *MemoryBookingStore {
+	return &MemoryBookingStore{bookings: make(map[string]models.Booking)}
+}
+
+func (s *MemoryBookingStore) CreateBooking(ctx context.Context, b models.Booking) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, taken := s.bookings[b.ID]; taken {
+		return fmt.Errorf("create booking %s: id already taken", b.ID)
+	}
+	s.bookings[b.ID] = b
+	return nil
+}
+
+func (s *MemoryBookingStore) GetBooking(ctx context.Context, bookingID string) (*models.Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.bookings[bookingID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &b, nil
+}
+
+func (s *MemoryBookingStore) UpdateBookingStatus(ctx context.Context, bookingID, from, to string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.bookings[bookingID]
+	if !ok {
+		return ErrNotFound
+	}
+	if b.Status != from {
+		return &BookingStatusError{Status: b.Status}
+	}
+	b.Status = to
+	b.UpdatedAt = at
+	s.bookings[bookingID] = b
+	return nil
+}
+
+// BookingService implements the hold → confirm/cancel workflow. Every step re-checks the offer
+// against the current data via Storage.GetOffer.
+type BookingService struct {
+	storage  Storage
+	bookings BookingStore
+	holdFor  time.Duration
+	// now is the clock for holds and their expiry; replaced in tests
+	now func() time.Time
+}
+
+// NewBookingService creates a BookingService whose holds expire after holdFor
+func NewBookingService(s Storage, bookings BookingStore, holdFor time.Duration) *BookingService {
+	return &BookingService{storage: s, bookings: bookings, holdFor: holdFor, now: time.Now}
+}
+
+// Hold reserves an offer at its current price. With expectedPrice > 0 the hold fails with a
+// *PriceChangedError if the offer costs something else now; expectedPrice is compared with the
+// price converted by conv, i.e. as shown to the client. The booking itself is held in Euro.
+func (b *BookingService) Hold(ctx context.Context, offerID string, expectedPrice float64, conv models.Conversion) (*models.Booking, error) {
+	offer, err := b.currentOffer(ctx, offerID)
+	if err != nil {
+		return nil, err
+	}
+	if current := conv.Price(offer.Price); expectedPrice > 0 && current != expectedPrice {
+		return nil, &PriceChangedError{Held: expectedPrice, Current: current, Currency: conv.Currency}
+	}
+
+	now := b.now().UTC()
+	booking := models.Booking{
+		ID:        newBookingID(),
+		OfferID:   offer.ID,
+		HotelID:   offer.HotelID,
+		Offer:     *offer,
+		Price:     offer.Price,
+		Status:    models.BookingStatusHeld,
+		CreatedAt: now,
+		ExpiresAt: now.Add(b.holdFor),
+		UpdatedAt: now,
+	}
+	if err := b.bookings.CreateBooking(ctx, booking); err != nil {
+		return nil, err
+	}
+	price := offer.Price
+	booking.CurrentPrice = &price
+	return &booking, nil
+}
+
+// Get returns a booking with its current price; holds past their expiry are marked expired
+func (b *BookingService) Get(ctx context.Context, bookingID string) (*models.Booking, error) {
+	booking, err := b.get(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	offer, err := b.currentOffer(ctx, booking.OfferID)
+	if err == nil {
+		booking.CurrentPrice = &offer.Price
+	} else if !errors.Is(err, ErrOfferUnavailable) {
+		return nil, err
+	}
+	return booking, nil
+}
+
+// Confirm turns a hold into a booking. Fails with ErrBookingExpired after the hold expired,
+// ErrOfferUnavailable or *PriceChangedError if the offer is gone or its price differs from the
+// held price, and *BookingStatusError if the booking is no longer held.
+func (b *BookingService) Confirm(ctx context.Context, bookingID string) (*models.Booking, error) {
+	booking, err := b.get(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	switch booking.Status {
+	case models.BookingStatusHeld:
+	case models.BookingStatusExpired:
+		return nil, ErrBookingExpired
+	default:
+		return nil, &BookingStatusError{Status: booking.Status}
+	}
+
+	offer, err := b.currentOffer(ctx, booking.OfferID)
+	if err != nil {
+		return nil, err
+	}
+	if offer.Price != booking.Price {
+		return nil, &PriceChangedError{Held: booking.Price, Current: offer.Price}
+	}
+	if err := b.transition(ctx, booking, models.BookingStatusConfirmed); err != nil {
+		return nil, err
+	}
+	booking.CurrentPrice = &offer.Price
+	return booking, nil
+}
+
+// Cancel cancels a hold or a confirmed booking; the response still reports the offer's current price
+func (b *BookingService) Cancel(ctx context.Context, bookingID string) (*models.Booking, error) {
+	booking, err := b.get(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != models.BookingStatusHeld && booking.Status != models.BookingStatusConfirmed {
+		return nil, &BookingStatusError{Status: booking.Status}
+	}
+	if err := b.transition(ctx, booking, models.BookingStatusCancelled); err != nil {
+		return nil, err
+	}
+	offer, err := b.currentOffer(ctx, booking.OfferID)
+	if err == nil {
+		booking.CurrentPrice = &offer.Price
+	} else if !errors.Is(err, ErrOfferUnavailable) {
+		return nil, err
+	}
+	return booking, nil
+}
+
+// get loads a booking and expires it if it is a hold past its expiry
+func (b *BookingService) get(ctx context.Context, bookingID string) (*models.Booking, error) {
+	booking, err := b.bookings.GetBooking(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status == models.BookingStatusHeld && b.now().After(booking.ExpiresAt) {
+		err := b.transition(ctx, booking, models.BookingStatusExpired)
+		var statusErr *BookingStatusError
+		if errors.As(err, &statusErr) {
+			// changed concurrently (e.g. confirmed just before expiring), report the actual status
+			booking.Status = statusErr.Status
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return booking, nil
+}
+
+// transition moves booking from its current status to status
+func (b *BookingService) transition(ctx context.Context, booking *models.Booking, status string) error {
+	now := b.now().UTC()
+	if err := b.bookings.UpdateBookingStatus(ctx, booking.ID, booking.Status, status, now); err != nil {
+		return err
+	}
+	booking.Status = status
+	booking.UpdatedAt = now
+	return nil
+}
+
+// currentOffer resolves an offer id, mapping ErrNotFound to ErrOfferUnavailable
+func (b *BookingService) currentOffer(ctx context.Context, offerID string) (*models.Offer, error) {
+	offer, err := b.storage.GetOffer(ctx, offerID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrOfferUnavailable
+	}
+	return offer, err
+}
+
+// newBookingID returns a random booking id. Booking ids are the only access control on a
+// booking, so it panics rather than hand out a predictable id if crypto/rand fails.
+func newBookingID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("bookings: crypto/rand: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
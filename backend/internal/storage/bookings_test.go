@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// offerStub serves GetOffer from a mutable map, so tests can reprice or withdraw an offer;
+// every other Storage method is unused by the booking workflow
+type offerStub struct {
+	Storage
+	mu     sync.Mutex
+	offers map[string]models.Offer
+}
+
+func (s *offerStub) GetOffer(ctx context.Context, offerID string) (*models.Offer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.offers[offerID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &o, nil
+}
+
+func (s *offerStub) setPrice(offerID string, price float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := s.offers[offerID]
+	o.Price = price
+	s.offers[offerID] = o
+}
+
+func (s *offerStub) remove(offerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.offers, offerID)
+}
+
+// staleBookings hands out bookings as still held, like a read that raced with a concurrent
+// transition; status changes go to the underlying store and must fail its compare-and-set
+type staleBookings struct {
+	*MemoryBookingStore
+}
+
+func (s staleBookings) GetBooking(ctx context.Context, bookingID string) (*models.Booking, error) {
+	b, err := s.MemoryBookingStore.GetBooking(ctx, bookingID)
+	if err == nil {
+		b.Status = models.BookingStatusHeld
+	}
+	return b, err
+}
+
+// bookingTest is a booking service over one offer of 19.99 Euro with a hold of 15 minutes and a
+// clock that only advance moves
+type bookingTest struct {
+	offers  *offerStub
+	store   *MemoryBookingStore
+	service *BookingService
+	offerID string
+	clock   time.Time
+}
+
+func newBookingTest() *bookingTest {
+	offer := testOffer(1, 19.99, "breakfast")
+	offer.ID = offer.StableID()
+	bt := &bookingTest{
+		offers:  &offerStub{offers: map[string]models.Offer{offer.ID: offer}},
+		store:   NewMemoryBookingStore(),
+		offerID: offer.ID,
+		clock:   time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC),
+	}
+	bt.service = NewBookingService(bt.offers, bt.store, 15*time.Minute)
+	bt.service.now = func() time.Time { return bt.clock }
+	return bt
+}
+
+func (bt *bookingTest) advance(d time.Duration) { bt.clock = bt.clock.Add(d) }
+
+func (bt *bookingTest) hold(t *testing.T) *models.Booking {
+	t.Helper()
+	b, err := bt.service.Hold(context.Background(), bt.offerID, 0, models.Conversion{})
+	if err != nil {
+		t.Fatalf("hold: %v", err)
+	}
+	return b
+}
+
+func (bt *bookingTest) storedStatus(t *testing.T, bookingID string) string {
+	t.Helper()
+	b, err := bt.store.GetBooking(context.Background(), bookingID)
+	if err != nil {
+		t.Fatalf("stored booking: %v", err)
+	}
+	return b.Status
+}
+
+// wantStatusErr checks that err is a *BookingStatusError reporting status
+func wantStatusErr(t *testing.T, err error, status string) {
+	t.Helper()
+	var statusErr *BookingStatusError
+	if !errors.As(err, &statusErr) || statusErr.Status != status {
+		t.Fatalf("got %v, want BookingStatusError %s", err, status)
+	}
+}
+
+func TestBookingHoldConfirm(t *testing.T) {
+	bt := newBookingTest()
+	ctx := context.Background()
+	held := bt.hold(t)
+	if held.Status != models.BookingStatusHeld || held.Price != 19.99 || !held.ExpiresAt.Equal(bt.clock.Add(15*time.Minute)) {
+		t.Fatalf("hold %+v, want held at 19.99 for 15 minutes", held)
+	}
+
+	bt.advance(14 * time.Minute)
+	confirmed, err := bt.service.Confirm(ctx, held.ID)
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if confirmed.Status != models.BookingStatusConfirmed || *confirmed.CurrentPrice != 19.99 || !confirmed.UpdatedAt.Equal(bt.clock) {
+		t.Errorf("confirmed %+v, want confirmed now at 19.99", confirmed)
+	}
+	// a confirmed booking does not expire
+	bt.advance(time.Hour)
+	if got := bt.storedStatus(t, held.ID); got != models.BookingStatusConfirmed {
+		t.Errorf("stored status %s, want confirmed", got)
+	}
+	if b, err := bt.service.Get(ctx, held.ID); err != nil || b.Status != models.BookingStatusConfirmed {
+		t.Errorf("get after the hold time: %+v, %v", b, err)
+	}
+}
+
+func TestBookingHoldExpires(t *testing.T) {
+	bt := newBookingTest()
+	ctx := context.Background()
+	held := bt.hold(t)
+
+	// on the expiry instant the hold is still valid
+	bt.advance(15 * time.Minute)
+	if b, err := bt.service.Get(ctx, held.ID); err != nil || b.Status != models.BookingStatusHeld {
+		t.Fatalf("get at expiry: %+v, %v", b, err)
+	}
+
+	bt.advance(time.Nanosecond)
+	b, err := bt.service.Get(ctx, held.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if b.Status != models.BookingStatusExpired || bt.storedStatus(t, held.ID) != models.BookingStatusExpired {
+		t.Errorf("booking %+v, want expired and stored as expired", b)
+	}
+	if _, err := bt.service.Confirm(ctx, held.ID); !errors.Is(err, ErrBookingExpired) {
+		t.Errorf("confirm after expiry: got %v, want ErrBookingExpired", err)
+	}
+	_, err = bt.service.Cancel(ctx, held.ID)
+	wantStatusErr(t, err, models.BookingStatusExpired)
+}
+
+func TestBookingConfirmAfterExpiry(t *testing.T) {
+	bt := newBookingTest()
+	held := bt.hold(t)
+	bt.advance(16 * time.Minute)
+	// the first access after the expiry expires the hold
+	if _, err := bt.service.Confirm(context.Background(), held.ID); !errors.Is(err, ErrBookingExpired) {
+		t.Fatalf("got %v, want ErrBookingExpired", err)
+	}
+	if got := bt.storedStatus(t, held.ID); got != models.BookingStatusExpired {
+		t.Errorf("stored status %s, want expired", got)
+	}
+}
+
+func TestBookingInvalidTransitions(t *testing.T) {
+	tests := []struct {
+		name string
+		// first runs on a fresh hold, then second must fail with status
+		first, second func(s *BookingService, id string) error
+		status        string
+	}{
+		{name: "confirm twice", first: confirm, second: confirm, status: models.BookingStatusConfirmed},
+		{name: "confirm cancelled", first: cancel, second: confirm, status: models.BookingStatusCancelled},
+		{name: "cancel twice", first: cancel, second: cancel, status: models.BookingStatusCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bt := newBookingTest()
+			held := bt.hold(t)
+			if err := tt.first(bt.service, held.ID); err != nil {
+				t.Fatalf("first transition: %v", err)
+			}
+			wantStatusErr(t, tt.second(bt.service, held.ID), tt.status)
+		})
+	}
+}
+
+func confirm(s *BookingService, id string) error {
+	_, err := s.Confirm(context.Background(), id)
+	return err
+}
+
+func cancel(s *BookingService, id string) error {
+	_, err := s.Cancel(context.Background(), id)
+	return err
+}
+
+func TestBookingConcurrentTransition(t *testing.T) {
+	// confirm reads a hold that another request confirmed meanwhile: the compare-and-set fails
+	bt := newBookingTest()
+	held := bt.hold(t)
+	if err := confirm(bt.service, held.ID); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	stale := NewBookingService(bt.offers, staleBookings{bt.store}, 15*time.Minute)
+	stale.now = bt.service.now
+	wantStatusErr(t, confirm(stale, held.ID), models.BookingStatusConfirmed)
+
+	// expiring a hold that was confirmed meanwhile reports the actual status
+	bt.advance(time.Hour)
+	b, err := stale.Get(context.Background(), held.ID)
+	if err != nil || b.Status != models.BookingStatusConfirmed {
+		t.Errorf("get: %+v, %v, want the confirmed booking", b, err)
+	}
+	if got := bt.storedStatus(t, held.ID); got != models.BookingStatusConfirmed {
+		t.Errorf("stored status %s, want confirmed", got)
+	}
+}
+
+func TestBookingConfirmChangedOffer(t *testing.T) {
+	bt := newBookingTest()
+	held := bt.hold(t)
+
+	bt.offers.setPrice(bt.offerID, 24.5)
+	_, err := bt.service.Confirm(context.Background(), held.ID)
+	var priceErr *PriceChangedError
+	if !errors.As(err, &priceErr) || priceErr.Held != 19.99 || priceErr.Current != 24.5 || priceErr.Currency != "" {
+		t.Fatalf("got %v, want a price change from 19.99 to 24.5 in Euro", err)
+	}
+	if got := bt.storedStatus(t, held.ID); got != models.BookingStatusHeld {
+		t.Errorf("stored status %s, want still held", got)
+	}
+
+	bt.offers.remove(bt.offerID)
+	if _, err := bt.service.Confirm(context.Background(), held.ID); !errors.Is(err, ErrOfferUnavailable) {
+		t.Errorf("got %v, want ErrOfferUnavailable", err)
+	}
+	// the booking itself still reads, without a current price
+	if b, err := bt.service.Get(context.Background(), held.ID); err != nil || b.CurrentPrice != nil {
+		t.Errorf("get: %+v, %v", b, err)
+	}
+}
+
+func TestBookingHoldExpectedPrice(t *testing.T) {
+	usd := models.Conversion{Currency: "USD", Rate: 1.0833}
+	tests := []struct {
+		name     string
+		expected float64
+		conv     models.Conversion
+		// wantErr is the price change reported, nil for a successful hold
+		wantErr *PriceChangedError
+	}{
+		{name: "no expectation"},
+		{name: "euro", expected: 19.99},
+		{name: "euro changed", expected: 17.99, wantErr: &PriceChangedError{Held: 17.99, Current: 19.99}},
+		// 19.99 * 1.0833 = 21.655467
+		{name: "converted", expected: 21.66, conv: usd},
+		{name: "converted changed", expected: 21.65, conv: usd, wantErr: &PriceChangedError{Held: 21.65, Current: 21.66, Currency: "USD"}},
+		{name: "euro amount in other currency", expected: 19.99, conv: usd, wantErr: &PriceChangedError{Held: 19.99, Current: 21.66, Currency: "USD"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bt := newBookingTest()
+			b, err := bt.service.Hold(context.Background(), bt.offerID, tt.expected, tt.conv)
+			if tt.wantErr != nil {
+				var priceErr *PriceChangedError
+				if !errors.As(err, &priceErr) || *priceErr != *tt.wantErr {
+					t.Fatalf("got %v, want %+v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("hold: %v", err)
+			}
+			// held in Euro whatever the client's currency
+			if b.Price != 19.99 || bt.storedStatus(t, b.ID) != models.BookingStatusHeld {
+				t.Errorf("hold %+v, want held at 19.99 Euro", b)
+			}
+		})
+	}
+
+	bt := newBookingTest()
+	if _, err := bt.service.Hold(context.Background(), "1-00000000000000ff", 0, models.Conversion{}); !errors.Is(err, ErrOfferUnavailable) {
+		t.Errorf("hold of an unknown offer: got %v, want ErrOfferUnavailable", err)
+	}
+	if _, err := bt.service.Confirm(context.Background(), "unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("confirm of an unknown booking: got %v, want ErrNotFound", err)
+	}
+}
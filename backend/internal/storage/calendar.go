@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// PriceCalendar aggregates the matching offers of one hotel (or all hotels for hotelID 0) into the
+// cheapest price per departure day, week or month in loc. Periods without offers are omitted.
+func PriceCalendar(ctx context.Context, s Storage, params models.SearchParams, hotelID int, granularity string, loc *time.Location) ([]models.PriceCalendarEntry, error) {
+	buckets := make(map[time.Time]*models.PriceCalendarEntry)
+	err := s.ScanOffers(ctx, params, hotelID, func(hotel *models.Hotel, offers []models.Offer) {
+		seen := make(map[time.Time]bool)
+		for i := range offers {
+			o := &offers[i]
+			key := models.CalendarBucket(o.DepartureDate, granularity, loc)
+			e, ok := buckets[key]
+			if !ok {
+				e = &models.PriceCalendarEntry{Date: key.Format("2006-01-02"), MinPrice: o.Price, HotelID: hotel.ID}
+				buckets[key] = e
+			} else if o.Price < e.MinPrice || (o.Price == e.MinPrice && hotel.ID < e.HotelID) {
+				e.MinPrice, e.HotelID = o.Price, hotel.ID
+			}
+			e.CountOffers++
+			if !seen[key] {
+				seen[key] = true
+				e.CountHotels++
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]time.Time, 0, len(buckets))
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Before(keys[j]) })
+	entries := make([]models.PriceCalendarEntry, len(keys))
+	for i, k := range keys {
+		entries[i] = *buckets[k]
+	}
+	return entries, nil
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"slices"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// ErrInvalidCursor is returned for cursors that are malformed or belong to another hotel or search.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// offersPageSize is the Scylla fetch size used while paging through a hotel's offers
+const offersPageSize = 1000
+
+// pageCursor is the decoded form of the opaque cursor handed out by GetOffersPageByHotel.
+// ScyllaStorage resumes at State (gocql paging state) after skipping Skip rows of that page;
+// MemoryStorage resumes at row Skip of the hotel's range and never sets State. Multi-room pages
+// (see MultiRoomOffersPage) set Rooms and resume at package Skip. Filter is filterKey of the
+// search the cursor was handed out for; both positions are only meaningful for that search.
+// Total is the TotalEstimate taken on the first page, so later pages need not count again.
+type pageCursor struct {
+	HotelID int    `json:"h"`
+	State   []byte `json:"s,omitempty"`
+	Skip    int    `json:"k,omitempty"`
+	Rooms   string `json:"r,omitempty"`
+	Filter  uint64 `json:"f,omitempty"`
+	Total   int    `json:"t,omitempty"`
+}
+
+// filterKey hashes the filters of params in a normalized form: list filters as sorted sets, times
+// as instants and price bounds as whole euro cents (the handlers convert them from the requested
+// currency first, so the key does not depend on it). Pages are always in price order, so the sort
+// order needs no part in it. Without any filter the key is 0.
+func filterKey(params models.SearchParams) uint64 {
+	h := fnv.New64a()
+	filtered := false
+	put := func(name string, value interface{}) {
+		filtered = true
+		fmt.Fprintf(h, "%s=%v;", name, value)
+	}
+	set := func(name string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		v := slices.Compact(slices.Sorted(slices.Values(values)))
+		put(name, len(v))
+		for _, s := range v {
+			// length-prefixed, so no value can imitate a separator
+			fmt.Fprintf(h, "%d:", len(s))
+			io.WriteString(h, s)
+		}
+	}
+	num := func(name string, v int) {
+		if v != 0 {
+			put(name, v)
+		}
+	}
+	cents := func(name string, eur float64) {
+		if eur != 0 {
+			put(name, int64(math.Round(eur*100)))
+		}
+	}
+	set("airports", params.DepartureAirports)
+	if !params.EarliestDepartureDate.IsZero() {
+		put("earliest", params.EarliestDepartureDate.UnixNano())
+	}
+	if !params.LatestReturnDate.IsZero() {
+		put("latest", params.LatestReturnDate.UnixNano())
+	}
+	num("adults", params.CountAdults)
+	num("children", params.CountChildren)
+	num("duration", params.Duration)
+	num("minDuration", params.MinDuration)
+	num("maxDuration", params.MaxDuration)
+	num("flexDays", params.FlexDays)
+	set("meals", params.MealTypes)
+	set("rooms", params.RoomTypes)
+	if params.OceanView != nil {
+		put("oceanView", *params.OceanView)
+	}
+	cents("minPrice", params.MinPrice)
+	cents("maxPrice", params.MaxPrice)
+	cents("minPricePerPerson", params.MinPricePerPerson)
+	cents("maxPricePerPerson", params.MaxPricePerPerson)
+	cents("minPricePerNight", params.MinPricePerNight)
+	cents("maxPricePerNight", params.MaxPricePerNight)
+	if params.MinStars != 0 {
+		put("minStars", params.MinStars)
+	}
+	if !filtered {
+		return 0
+	}
+	return h.Sum64()
+}
+
+func encodePageCursor(c pageCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodePageCursor parses cursor of a search for params; the empty cursor is the start of
+// hotelID's offers. Cursors handed out for other filters are invalid.
+func decodePageCursor(cursor string, hotelID int, params models.SearchParams) (pageCursor, error) {
+	return decodeRoomsPageCursor(cursor, hotelID, "", params)
+}
+
+// decodeRoomsPageCursor parses cursor of a multi-room search for rooms (see models.FormatRooms);
+// cursors of other room searches, other filters or of single-offer pages are invalid
+func decodeRoomsPageCursor(cursor string, hotelID int, rooms string, params models.SearchParams) (pageCursor, error) {
+	filter := filterKey(params)
+	if cursor == "" {
+		return pageCursor{HotelID: hotelID, Rooms: rooms, Filter: filter}, nil
+	}
+	// decode into a zero cursor: fields missing from the cursor must not default to the expected ones
+	var c pageCursor
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.HotelID != hotelID || c.Rooms != rooms || c.Filter != filter || c.Skip < 0 {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// collectOffersPage walks a hotel's offers page by page from cur until limit matches are found.
+// fetch returns the rows of the page at state and the state of the following page (empty after
+// the last page). TotalEstimate is exact if the first page holds every match; otherwise estimate
+// is called once on the first page with the number of rows inspected and accepted, and its result
+// travels in the cursor to all later pages.
+func collectOffersPage(params models.SearchParams, limit int, cur pageCursor, fetch func(state []byte) ([]models.Offer, []byte, error), estimate func(scanned, matched int) (int, error)) (*models.OffersPage, error) {
+	page := &models.OffersPage{Items: []models.Offer{}}
+	first := cur.State == nil && cur.Skip == 0
+	scanned, matched := 0, 0
+	state, skip := cur.State, cur.Skip
+	var next *pageCursor
+walk:
+	for {
+		rows, following, err := fetch(state)
+		if err != nil {
+			return nil, err
+		}
+		for i := skip; i < len(rows); i++ {
+			if !rows[i].Matches(params) {
+				scanned++
+				continue
+			}
+			if limit > 0 && len(page.Items) == limit {
+				// another match exists: resume right before it
+				next = &pageCursor{HotelID: cur.HotelID, State: state, Skip: i, Filter: cur.Filter}
+				break walk
+			}
+			scanned++
+			matched++
+			page.Items = append(page.Items, rows[i])
+		}
+		if len(following) == 0 {
+			break
+		}
+		if limit > 0 && len(page.Items) == limit {
+			next = &pageCursor{HotelID: cur.HotelID, State: following, Filter: cur.Filter}
+			break
+		}
+		state, skip = following, 0
+	}
+
+	switch {
+	case !first:
+		page.TotalEstimate = cur.Total
+	case next == nil:
+		page.TotalEstimate = matched
+	default:
+		total, err := estimate(scanned, matched)
+		if err != nil {
+			return nil, err
+		}
+		// more matches follow this page
+		page.TotalEstimate = max(total, matched+1)
+	}
+	if next != nil {
+		next.Total = page.TotalEstimate
+		page.NextCursor = encodePageCursor(*next)
+	}
+	return page, nil
+}
@@ -0,0 +1,377 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		c    pageCursor
+	}{
+		{"start", pageCursor{HotelID: 7}},
+		{"skip only", pageCursor{HotelID: 7, Skip: 42}},
+		{"paging state", pageCursor{HotelID: 7, State: []byte{0, 1, 2, 0xff}, Skip: 3}},
+		{"rooms", pageCursor{HotelID: 7, Skip: 5, Rooms: "2:0,2:1"}},
+		{"filter", pageCursor{HotelID: 7, Skip: 5, Filter: filterKey(models.SearchParams{MaxPrice: 500})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params models.SearchParams
+			if tt.c.Filter != 0 {
+				params.MaxPrice = 500
+			}
+			got, err := decodeRoomsPageCursor(encodePageCursor(tt.c), tt.c.HotelID, tt.c.Rooms, params)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.c) {
+				t.Errorf("got %+v, want %+v", got, tt.c)
+			}
+		})
+	}
+}
+
+func TestDecodePageCursor(t *testing.T) {
+	allInclusive := models.SearchParams{MealTypes: []string{"allinclusive"}, MaxPrice: 500}
+	filtered := encodePageCursor(pageCursor{HotelID: 7, Skip: 1, Filter: filterKey(allInclusive)})
+
+	tests := []struct {
+		name    string
+		cursor  string
+		hotelID int
+		rooms   string
+		params  models.SearchParams
+		want    pageCursor
+		wantErr bool
+	}{
+		{name: "empty is start", cursor: "", hotelID: 7, want: pageCursor{HotelID: 7}},
+		{name: "empty rooms start", cursor: "", hotelID: 7, rooms: "2:0", want: pageCursor{HotelID: 7, Rooms: "2:0"}},
+		{name: "not base64", cursor: "!!!", hotelID: 7, wantErr: true},
+		{name: "not json", cursor: "bm9wZQ", hotelID: 7, wantErr: true},
+		{name: "other hotel", cursor: encodePageCursor(pageCursor{HotelID: 8, Skip: 1}), hotelID: 7, wantErr: true},
+		{name: "negative skip", cursor: encodePageCursor(pageCursor{HotelID: 7, Skip: -1}), hotelID: 7, wantErr: true},
+		{name: "rooms cursor on offers page", cursor: encodePageCursor(pageCursor{HotelID: 7, Rooms: "2:0"}), hotelID: 7, wantErr: true},
+		{name: "offers cursor on rooms page", cursor: encodePageCursor(pageCursor{HotelID: 7, Skip: 1}), hotelID: 7, rooms: "2:0", wantErr: true},
+		{name: "cursor without hotel", cursor: "e30", hotelID: 7, wantErr: true},
+		{name: "other rooms", cursor: encodePageCursor(pageCursor{HotelID: 7, Rooms: "2:0"}), hotelID: 7, rooms: "2:1", wantErr: true},
+		{name: "empty filtered start", cursor: "", hotelID: 7, params: allInclusive, want: pageCursor{HotelID: 7, Filter: filterKey(allInclusive)}},
+		{name: "same filters", cursor: filtered, hotelID: 7, params: allInclusive, want: pageCursor{HotelID: 7, Skip: 1, Filter: filterKey(allInclusive)}},
+		{name: "other filters", cursor: filtered, hotelID: 7, params: models.SearchParams{MealTypes: []string{"breakfast"}, MaxPrice: 500}, wantErr: true},
+		{name: "filters dropped", cursor: filtered, hotelID: 7, wantErr: true},
+		{name: "filters added", cursor: encodePageCursor(pageCursor{HotelID: 7, Skip: 1}), hotelID: 7, params: allInclusive, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRoomsPageCursor(tt.cursor, tt.hotelID, tt.rooms, tt.params)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Fatalf("got err %v, want ErrInvalidCursor", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testOffer is a 7-night offer of hotel; the departure minute follows the price so that offers
+// with different prices are distinct offer ids
+func testOffer(hotel int, price float64, meal string) models.Offer {
+	dep := time.Date(2025, 8, 1, 6, 0, 0, 0, time.UTC).Add(time.Duration(price) * time.Minute)
+	ret := dep.AddDate(0, 0, 7)
+	return models.Offer{
+		HotelID:                  hotel,
+		DepartureDate:            dep,
+		ReturnDate:               ret,
+		CountAdults:              2,
+		Price:                    price,
+		OutboundDepartureAirport: "FRA",
+		OutboundArrivalAirport:   "PMI",
+		OutboundArrivalDateTime:  dep.Add(2 * time.Hour),
+		InboundDepartureAirport:  "PMI",
+		InboundArrivalAirport:    "FRA",
+		InboundArrivalDateTime:   ret.Add(2 * time.Hour),
+		MealType:                 meal,
+		RoomType:                 "double",
+	}
+}
+
+// fakePages serves rows in pages of pageSize; the paging state is the page number
+func fakePages(rows []models.Offer, pageSize int) func(state []byte) ([]models.Offer, []byte, error) {
+	return func(state []byte) ([]models.Offer, []byte, error) {
+		n := 0
+		if len(state) > 0 {
+			n = int(state[0])
+		}
+		from, to := n*pageSize, (n+1)*pageSize
+		if to >= len(rows) {
+			return rows[from:], nil, nil
+		}
+		return rows[from:to], []byte{byte(n + 1)}, nil
+	}
+}
+
+func TestCollectOffersPage(t *testing.T) {
+	// prices 1..10; odd prices are all-inclusive, even ones breakfast
+	var rows []models.Offer
+	for p := 1; p <= 10; p++ {
+		meal := "breakfast"
+		if p%2 == 1 {
+			meal = "allinclusive"
+		}
+		rows = append(rows, testOffer(1, float64(p), meal))
+	}
+	allInclusive := models.SearchParams{MealTypes: []string{"allinclusive"}}
+
+	tests := []struct {
+		name     string
+		params   models.SearchParams
+		pageSize int
+		limit    int
+		// wantPages lists the prices of each API page
+		wantPages [][]float64
+		// wantFirst is the cursor after the first page (zero value: no next page)
+		wantFirst pageCursor
+	}{
+		{
+			name: "fills mid scylla page", pageSize: 4, limit: 3,
+			wantPages: [][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}, {10}},
+			wantFirst: pageCursor{HotelID: 1, Skip: 3, Total: 100},
+		},
+		{
+			name: "fills exactly at scylla page end", pageSize: 4, limit: 4,
+			wantPages: [][]float64{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10}},
+			wantFirst: pageCursor{HotelID: 1, State: []byte{1}, Total: 100},
+		},
+		{
+			name: "fills at last row of last page", pageSize: 5, limit: 10,
+			wantPages: [][]float64{{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		},
+		{
+			name: "spans scylla pages", pageSize: 3, limit: 5,
+			wantPages: [][]float64{{1, 2, 3, 4, 5}, {6, 7, 8, 9, 10}},
+			wantFirst: pageCursor{HotelID: 1, State: []byte{1}, Skip: 2, Total: 100},
+		},
+		{
+			name: "filtered fills at page end", params: allInclusive, pageSize: 4, limit: 2,
+			// the last match of page 0 fills the page; page 1 still holds matches
+			wantPages: [][]float64{{1, 3}, {5, 7}, {9}},
+			wantFirst: pageCursor{HotelID: 1, State: []byte{1}, Filter: filterKey(allInclusive), Total: 100},
+		},
+		{
+			name: "no limit", pageSize: 3, limit: 0,
+			wantPages: [][]float64{{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetch := fakePages(rows, tt.pageSize)
+			// the estimate is taken once, on a first page that does not hold every match
+			estimates := 0
+			estimate := func(scanned, matched int) (int, error) {
+				estimates++
+				if scanned == 0 || matched > scanned {
+					t.Errorf("estimate(%d, %d): implausible counts", scanned, matched)
+				}
+				return 100, nil
+			}
+			wantTotal := 100
+			if len(tt.wantPages) == 1 {
+				wantTotal = len(tt.wantPages[0])
+			}
+			cur := pageCursor{HotelID: 1, Filter: filterKey(tt.params)}
+			var got [][]float64
+			for i := 0; ; i++ {
+				if i > len(rows) {
+					t.Fatal("paging does not terminate")
+				}
+				page, err := collectOffersPage(tt.params, tt.limit, cur, fetch, estimate)
+				if err != nil {
+					t.Fatalf("page %d: %v", i, err)
+				}
+				if page.TotalEstimate != wantTotal {
+					t.Errorf("page %d: total %d, want %d", i, page.TotalEstimate, wantTotal)
+				}
+				if i == 0 {
+					var first pageCursor
+					if page.NextCursor != "" {
+						if first, err = decodePageCursor(page.NextCursor, 1, tt.params); err != nil {
+							t.Fatalf("next cursor: %v", err)
+						}
+					}
+					if !reflect.DeepEqual(first, tt.wantFirst) {
+						t.Errorf("first cursor %+v, want %+v", first, tt.wantFirst)
+					}
+				}
+				prices := []float64{}
+				for _, o := range page.Items {
+					prices = append(prices, o.Price)
+				}
+				if len(prices) > 0 {
+					got = append(got, prices)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				if cur, err = decodePageCursor(page.NextCursor, 1, tt.params); err != nil {
+					t.Fatalf("next cursor: %v", err)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantPages) {
+				t.Errorf("pages %v, want %v", got, tt.wantPages)
+			}
+			if want := min(1, len(tt.wantPages)-1); estimates != want {
+				t.Errorf("estimated %d times, want %d", estimates, want)
+			}
+		})
+	}
+}
+
+func TestCollectOffersPageEstimate(t *testing.T) {
+	var rows []models.Offer
+	for p := 1; p <= 10; p++ {
+		rows = append(rows, testOffer(1, float64(p), "breakfast"))
+	}
+	// an estimate below the matches already seen is raised: at least one more match follows
+	page, err := collectOffersPage(models.SearchParams{}, 3, pageCursor{HotelID: 1}, fakePages(rows, 4),
+		func(int, int) (int, error) { return 2, nil })
+	if err != nil {
+		t.Fatalf("page: %v", err)
+	}
+	if page.TotalEstimate != 4 {
+		t.Errorf("total %d, want 4", page.TotalEstimate)
+	}
+
+	boom := errors.New("boom")
+	if _, err := collectOffersPage(models.SearchParams{}, 3, pageCursor{HotelID: 1}, fakePages(rows, 4),
+		func(int, int) (int, error) { return 0, boom }); !errors.Is(err, boom) {
+		t.Errorf("got %v, want %v", err, boom)
+	}
+}
+
+func TestCollectOffersPageFetchError(t *testing.T) {
+	boom := errors.New("boom")
+	_, err := collectOffersPage(models.SearchParams{}, 1, pageCursor{HotelID: 1}, func([]byte) ([]models.Offer, []byte, error) {
+		return nil, nil, boom
+	}, func(int, int) (int, error) { return 0, nil })
+	if !errors.Is(err, boom) {
+		t.Fatalf("got %v, want %v", err, boom)
+	}
+}
+
+func TestMemoryOffersPageResume(t *testing.T) {
+	s := NewMemoryStorage([]models.Hotel{{ID: 1, Name: "Test", Stars: 4}})
+	var batch []models.Offer
+	for p := 1; p <= 7; p++ {
+		meal := "breakfast"
+		if p%3 == 0 {
+			meal = "allinclusive"
+		}
+		batch = append(batch, testOffer(1, float64(p), meal))
+	}
+	s.AddOffers(batch)
+	s.Build()
+
+	tests := []struct {
+		name      string
+		params    models.SearchParams
+		limit     int
+		wantPages [][]float64
+		wantTotal int
+	}{
+		{name: "unfiltered", limit: 3, wantPages: [][]float64{{1, 2, 3}, {4, 5, 6}, {7}}, wantTotal: 7},
+		{name: "exact fill", limit: 7, wantPages: [][]float64{{1, 2, 3, 4, 5, 6, 7}}, wantTotal: 7},
+		{name: "filtered", params: models.SearchParams{MealTypes: []string{"breakfast"}}, limit: 2,
+			wantPages: [][]float64{{1, 2}, {4, 5}, {7}}, wantTotal: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]float64
+			cursor := ""
+			for i := 0; ; i++ {
+				if i > len(batch) {
+					t.Fatal("paging does not terminate")
+				}
+				page, err := s.GetOffersPageByHotel(context.Background(), 1, tt.params, tt.limit, cursor)
+				if err != nil {
+					t.Fatalf("page %d: %v", i, err)
+				}
+				if page.TotalEstimate != tt.wantTotal {
+					t.Errorf("page %d: total %d, want %d", i, page.TotalEstimate, tt.wantTotal)
+				}
+				var prices []float64
+				for _, o := range page.Items {
+					prices = append(prices, o.Price)
+				}
+				got = append(got, prices)
+				if cursor = page.NextCursor; cursor == "" {
+					break
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantPages) {
+				t.Errorf("pages %v, want %v", got, tt.wantPages)
+			}
+		})
+	}
+
+	// the cursor belongs to the filters of the first page
+	first, err := s.GetOffersPageByHotel(context.Background(), 1, models.SearchParams{MealTypes: []string{"breakfast"}}, 2, "")
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if _, err := s.GetOffersPageByHotel(context.Background(), 1, models.SearchParams{}, 2, first.NextCursor); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor with other filters: got %v, want ErrInvalidCursor", err)
+	}
+
+	// a paging state belongs to Scylla and is rejected
+	if _, err := s.GetOffersPageByHotel(context.Background(), 1, models.SearchParams{}, 2,
+		encodePageCursor(pageCursor{HotelID: 1, State: []byte{1}})); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("got %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestFilterKey(t *testing.T) {
+	yes := true
+	at := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		a, b models.SearchParams
+		same bool
+	}{
+		{name: "unfiltered", same: true},
+		{name: "set order", a: models.SearchParams{MealTypes: []string{"a", "b"}}, b: models.SearchParams{MealTypes: []string{"b", "a", "b"}}, same: true},
+		{name: "same instant in another zone", a: models.SearchParams{EarliestDepartureDate: at},
+			b: models.SearchParams{EarliestDepartureDate: at.In(time.FixedZone("CEST", 2*3600))}, same: true},
+		{name: "price within a cent", a: models.SearchParams{MaxPrice: 2.3}, b: models.SearchParams{MaxPrice: 2.2999999999}, same: true},
+		{name: "other price", a: models.SearchParams{MaxPrice: 2.3}, b: models.SearchParams{MaxPrice: 2.31}},
+		{name: "min and max swapped", a: models.SearchParams{MinPrice: 100}, b: models.SearchParams{MaxPrice: 100}},
+		{name: "meals versus rooms", a: models.SearchParams{MealTypes: []string{"x"}}, b: models.SearchParams{RoomTypes: []string{"x"}}},
+		{name: "joined values", a: models.SearchParams{MealTypes: []string{"a;b"}}, b: models.SearchParams{MealTypes: []string{"a", "b"}}},
+		{name: "ocean view", a: models.SearchParams{OceanView: &yes}},
+		{name: "stars", a: models.SearchParams{MinStars: 4}},
+		{name: "adults", a: models.SearchParams{CountAdults: 2}, b: models.SearchParams{CountChildren: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterKey(tt.a) == filterKey(tt.b); got != tt.same {
+				t.Errorf("filterKey(%+v) == filterKey(%+v) is %v, want %v", tt.a, tt.b, got, tt.same)
+			}
+		})
+	}
+	if filterKey(models.SearchParams{}) != 0 {
+		t.Errorf("unfiltered key is not 0")
+	}
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"math/bits"
+	"sort"
+	"strconv"
+	"sync"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// hotelFacets collects the facet values of one hotel: the cheapest price per facet value.
+// An offer counts for every facet if it matches all filters, and only for facet f if f's
+// own filter is the single one it misses ("exclude own filter" semantics).
+type hotelFacets [models.NumFacets]map[string]float64
+
+// add records an offer with the given facet values and the facets whose filter it misses
+func (h *hotelFacets) add(misses models.FacetMask, values *[models.NumFacets]string, price float64) {
+	switch bits.OnesCount8(uint8(misses)) {
+	case 0:
+		for f := models.Facet(0); f < models.NumFacets; f++ {
+			h.addValue(f, values[f], price)
+		}
+	case 1:
+		for f := models.Facet(0); f < models.NumFacets; f++ {
+			if misses.Has(f) {
+				h.addValue(f, values[f], price)
+				return
+			}
+		}
+	}
+}
+
+func (h *hotelFacets) addValue(f models.Facet, value string, price float64) {
+	if h[f] == nil {
+		h[f] = make(map[string]float64)
+	}
+	if cur, ok := h[f][value]; !ok || price < cur {
+		h[f][value] = price
+	}
+}
+
+// mergeFrom adds the values of o, another part of the same hotel's offers
+func (h *hotelFacets) mergeFrom(o *hotelFacets) {
+	for f := range o {
+		for value, price := range o[f] {
+			h.addValue(models.Facet(f), value, price)
+		}
+	}
+}
+
+// facetCollector merges hotelFacets into hotel counts and min prices per value. Safe for concurrent use.
+type facetCollector struct {
+	mu     sync.Mutex
+	values [models.NumFacets]map[string]*models.FacetValue
+}
+
+func (c *facetCollector) merge(h *hotelFacets) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for f := range h {
+		for value, price := range h[f] {
+			if c.values[f] == nil {
+				c.values[f] = make(map[string]*models.FacetValue)
+			}
+			v, ok := c.values[f][value]
+			if !ok {
+				c.values[f][value] = &models.FacetValue{Value: value, CountHotels: 1, MinPrice: price}
+				continue
+			}
+			v.CountHotels++
+			if price < v.MinPrice {
+				v.MinPrice = price
+			}
+		}
+	}
+}
+
+func (c *facetCollector) result() *models.SearchFacets {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := func(f models.Facet) []models.FacetValue {
+		out := make([]models.FacetValue, 0, len(c.values[f]))
+		for _, v := range c.values[f] {
+			out = append(out, *v)
+		}
+		sort.Slice(out, func(i, j int) bool { return facetValueLess(out[i].Value, out[j].Value) })
+		return out
+	}
+	return &models.SearchFacets{
+		DepartureAirports: list(models.FacetDepartureAirport),
+		MealTypes:         list(models.FacetMealType),
+		RoomTypes:         list(models.FacetRoomType),
+		OceanView:         list(models.FacetOceanView),
+		Stars:             list(models.FacetStars),
+		Durations:         list(models.FacetDuration),
+	}
+}
+
+// facetValueLess orders numeric values (stars, durations) numerically, everything else alphabetically
+func facetValueLess(a, b string) bool {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return fa < fb
+	}
+	return a < b
+}
+
+// starsFacetValue formats hotel stars as facet value ("4", "4.5")
+func starsFacetValue(stars float64) string {
+	return strconv.FormatFloat(stars, 'f', -1, 64)
+}
+
+// offerFacetValues returns the facet values of an offer of hotel h
+func offerFacetValues(o *models.Offer, h *models.Hotel) [models.NumFacets]string {
+	return [models.NumFacets]string{
+		models.FacetDepartureAirport: o.OutboundDepartureAirport,
+		models.FacetMealType:         o.MealType,
+		models.FacetRoomType:         o.RoomType,
+		models.FacetOceanView:        strconv.FormatBool(o.OceanView),
+		models.FacetStars:            starsFacetValue(h.Stars),
+		models.FacetDuration:         strconv.Itoa(o.Duration()),
+	}
+}
+
+// addOfferFacets feeds one offer of hotel h into hf
+func addOfferFacets(hf *hotelFacets, o *models.Offer, h *models.Hotel, params models.SearchParams) {
+	core, misses := o.FacetMisses(params)
+	if !core {
+		return
+	}
+	if !h.Matches(params) {
+		misses = misses.With(models.FacetStars)
+	}
+	values := offerFacetValues(o, h)
+	hf.add(misses, &values, o.Price)
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// MaxHistogramBuckets bounds the number of buckets a histogram may span
+const MaxHistogramBuckets = 1000
+
+// ErrTooManyBuckets is returned when the price range needs more than MaxHistogramBuckets buckets.
+var ErrTooManyBuckets = errors.New("too many histogram buckets")
+
+// PriceHistogram buckets the prices of a search by bucketWidth. In offers mode every matching
+// offer is counted (via ScanOffers); in hotels mode each hotel's best price as returned by
+// GetHotelsWithBestOffers, so the totals agree with /bestOffersByHotel. Prices are converted with
+// conv before bucketing, so bucketWidth and the bounds are in the requested currency. The span of
+// buckets is checked with every price, so a range needing more than MaxHistogramBuckets buckets
+// stops the scan with ErrTooManyBuckets before more than that many counts are held.
+func PriceHistogram(ctx context.Context, s Storage, params models.SearchParams, bucketWidth float64, mode string, conv models.Conversion) (*models.PriceHistogram, error) {
+	h := &models.PriceHistogram{Mode: mode, BucketWidth: bucketWidth, Buckets: []models.HistogramBucket{}}
+	if !(bucketWidth > 0) || math.IsInf(bucketWidth, 0) {
+		return nil, fmt.Errorf("histogram bucket width %v", bucketWidth)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// counts is keyed by bucket number floor(price/bucketWidth) within [first, last]; densified below
+	counts := make(map[int64]int)
+	var first, last int64
+	tooMany := false
+	add := func(price float64) {
+		if tooMany {
+			return
+		}
+		price = conv.Price(price)
+		b := int64(math.Floor(price / bucketWidth))
+		if h.Total == 0 {
+			first, last = b, b
+		}
+		first, last = min(first, b), max(last, b)
+		if last-first >= MaxHistogramBuckets {
+			// the remaining prices cannot make the range smaller
+			tooMany = true
+			cancel()
+			return
+		}
+		if h.Total == 0 || price < h.MinPrice {
+			h.MinPrice = price
+		}
+		if h.Total == 0 || price > h.MaxPrice {
+			h.MaxPrice = price
+		}
+		h.Total++
+		counts[b]++
+	}
+	var err error
+	if mode == models.HistogramModeHotels {
+		var results []models.HotelWithBestOffer
+		results, err = s.GetHotelsWithBestOffers(ctx, params)
+		for i := range results {
+			add(results[i].BestOffer.Price)
+		}
+	} else {
+		err = s.ScanOffers(ctx, params, 0, func(_ *models.Hotel, offers []models.Offer) {
+			for i := range offers {
+				add(offers[i].Price)
+			}
+		})
+	}
+	if tooMany {
+		return nil, ErrTooManyBuckets
+	}
+	if err != nil {
+		return nil, err
+	}
+	if h.Total == 0 {
+		return h, nil
+	}
+
+	h.Buckets = make([]models.HistogramBucket, last-first+1)
+	for i := range h.Buckets {
+		b := first + int64(i)
+		h.Buckets[i] = models.HistogramBucket{
+			From:  float64(b) * bucketWidth,
+			To:    float64(b+1) * bucketWidth,
+			Count: counts[b],
+		}
+	}
+	return h, nil
+}
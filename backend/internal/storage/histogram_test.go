@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+func TestPriceHistogram(t *testing.T) {
+	s := NewMemoryStorage([]models.Hotel{{ID: 1, Name: "Eins", Stars: 3}, {ID: 2, Name: "Zwei", Stars: 4}})
+	var batch []models.Offer
+	for _, p := range []float64{120, 180, 250, 999.99} {
+		batch = append(batch, testOffer(1, p, "breakfast"))
+	}
+	batch = append(batch, testOffer(2, 310, "breakfast"), testOffer(2, 10000.5, "breakfast"))
+	s.AddOffers(batch)
+	s.Build()
+
+	tests := []struct {
+		name        string
+		params      models.SearchParams
+		bucketWidth float64
+		mode        string
+		conv        models.Conversion
+		// wantCounts are the bucket counts from wantFrom on
+		wantFrom    float64
+		wantCounts  []int
+		wantBuckets int
+		wantErr     error
+	}{
+		{name: "offers", params: models.SearchParams{MaxPrice: 1000}, bucketWidth: 100, mode: models.HistogramModeOffers,
+			wantFrom: 100, wantCounts: []int{2, 1, 1, 0, 0, 0, 0, 0, 1}},
+		{name: "hotels", bucketWidth: 100, mode: models.HistogramModeHotels, wantFrom: 100, wantCounts: []int{1, 0, 1}},
+		{name: "converted", params: models.SearchParams{MaxPrice: 300}, bucketWidth: 100, mode: models.HistogramModeOffers,
+			conv: models.Conversion{Currency: "USD", Rate: 2}, wantFrom: 200, wantCounts: []int{1, 1, 0, 1}},
+		// 120 and 999.99 fall into buckets 136 and 1135, or 136 and 1136
+		{name: "maximum span", params: models.SearchParams{MinPrice: 120, MaxPrice: 999.99}, bucketWidth: 0.881, mode: models.HistogramModeOffers,
+			wantBuckets: MaxHistogramBuckets},
+		{name: "one bucket more than the maximum", params: models.SearchParams{MinPrice: 120, MaxPrice: 999.99}, bucketWidth: 0.88, mode: models.HistogramModeOffers,
+			wantErr: ErrTooManyBuckets},
+		{name: "too many buckets", bucketWidth: 1, mode: models.HistogramModeOffers, wantErr: ErrTooManyBuckets},
+		{name: "too many buckets of hotels", params: models.SearchParams{MinPrice: 200}, bucketWidth: 0.01, mode: models.HistogramModeHotels, wantErr: ErrTooManyBuckets},
+		{name: "nothing found", params: models.SearchParams{MinPrice: 50000}, bucketWidth: 0.01, mode: models.HistogramModeOffers},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := PriceHistogram(context.Background(), s, tt.params, tt.bucketWidth, tt.mode, tt.conv)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("histogram: %v", err)
+			}
+			total := 0
+			var counts []int
+			for i, b := range h.Buckets {
+				total += b.Count
+				counts = append(counts, b.Count)
+				if i == 0 && b.From > h.MinPrice || i == len(h.Buckets)-1 && b.To <= h.MaxPrice {
+					t.Errorf("buckets %v do not cover %v to %v", h.Buckets, h.MinPrice, h.MaxPrice)
+				}
+			}
+			if total != h.Total || len(h.Buckets) > MaxHistogramBuckets {
+				t.Errorf("%d buckets count %d prices, total %d", len(h.Buckets), total, h.Total)
+			}
+			if tt.wantBuckets != 0 && len(h.Buckets) != tt.wantBuckets {
+				t.Errorf("%d buckets, want %d", len(h.Buckets), tt.wantBuckets)
+			}
+			if tt.wantCounts != nil && (h.Buckets[0].From != tt.wantFrom || !reflect.DeepEqual(counts, tt.wantCounts)) {
+				t.Errorf("buckets from %v: %v, want from %v: %v", h.Buckets[0].From, counts, tt.wantFrom, tt.wantCounts)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// HotelIndex answers hotel name searches from memory. It reloads the hotels through
+// GetAllHotels at most every HOTEL_INDEX_REFRESH_SECONDS (default 60) and rebuilds the
+// index only when the hotel list has changed.
+type HotelIndex struct {
+	storage Storage
+	refresh time.Duration
+
+	mu       sync.RWMutex
+	entries  []hotelIndexEntry
+	checksum uint64
+	loadedAt time.Time
+}
+
+type hotelIndexEntry struct {
+	hotel   models.Hotel
+	name    string   // folded name, words separated by single spaces
+	compact string   // folded name without spaces
+	tokens  []string // words of name
+}
+
+// NewHotelIndex creates an index on top of s; it is filled on the first search
+func NewHotelIndex(s Storage) *HotelIndex {
+	return &HotelIndex{
+		storage: s,
+		refresh: time.Duration(getEnvInt("HOTEL_INDEX_REFRESH_SECONDS", 60)) * time.Second,
+	}
+}
+
+// Search returns up to limit hotels matching q, best match first
+func (x *HotelIndex) Search(ctx context.Context, q string, limit int) ([]models.HotelSearchResult, error) {
+	if err := x.ensureFresh(ctx); err != nil {
+		return nil, err
+	}
+	query := foldName(q)
+	if query == "" {
+		return []models.HotelSearchResult{}, nil
+	}
+	qTokens := strings.Fields(query)
+	qCompact := strings.ReplaceAll(query, " ", "")
+
+	x.mu.RLock()
+	results := make([]models.HotelSearchResult, 0, limit)
+	for i := range x.entries {
+		e := &x.entries[i]
+		if score := matchScore(e, query, qCompact, qTokens); score > 0 {
+			results = append(results, models.HotelSearchResult{Hotel: e.hotel, Score: score})
+		}
+	}
+	x.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := &results[i], &results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Hotel.Name) != len(b.Hotel.Name) {
+			return len(a.Hotel.Name) < len(b.Hotel.Name)
+		}
+		return a.Hotel.Name < b.Hotel.Name
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// ensureFresh reloads the hotels once the refresh interval has passed
+func (x *HotelIndex) ensureFresh(ctx context.Context) error {
+	x.mu.RLock()
+	fresh := !x.loadedAt.IsZero() && time.Since(x.loadedAt) < x.refresh
+	x.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	hotels, err := x.storage.GetAllHotels(ctx)
+	if err != nil {
+		x.mu.RLock()
+		stale := x.entries != nil
+		x.mu.RUnlock()
+		if stale {
+			// keep answering from the previous index; retried on the next search
+			log.Printf("hotel index: reload failed, serving previous index: %v", err)
+			return nil
+		}
+		return err
+	}
+	sum := hotelsChecksum(hotels)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.loadedAt = time.Now()
+	if x.entries != nil && sum == x.checksum {
+		return nil
+	}
+	entries := make([]hotelIndexEntry, len(hotels))
+	for i, h := range hotels {
+		name := foldName(h.Name)
+		entries[i] = hotelIndexEntry{hotel: h, name: name, compact: strings.ReplaceAll(name, " ", ""), tokens: strings.Fields(name)}
+	}
+	x.entries, x.checksum = entries, sum
+	log.Printf("hotel index: rebuilt with %d hotels", len(entries))
+	return nil
+}
+
+func hotelsChecksum(hotels []models.Hotel) uint64 {
+	h := fnv.New64a()
+	for _, hotel := range hotels {
+		h.Write([]byte(hotel.Name))
+		h.Write([]byte{0, byte(hotel.ID), byte(hotel.ID >> 8), byte(hotel.ID >> 16), byte(hotel.ID >> 24), byte(hotel.Stars * 2)})
+	}
+	return h.Sum64()
+}
+
+// Match scores, highest first. Typo matches score below every exact match.
+const (
+	scoreExact       = 100
+	scoreNamePrefix  = 90
+	scoreWordPrefix  = 80
+	scoreAllPrefixes = 70
+	scoreInfix       = 60
+	scoreTypo        = 40
+)
+
+func matchScore(e *hotelIndexEntry, query, qCompact string, qTokens []string) float64 {
+	switch {
+	case e.name == query:
+		return scoreExact
+	case strings.HasPrefix(e.name, query):
+		return scoreNamePrefix + wholeWordBonus(e.name, query)
+	case strings.Contains(" "+e.name, " "+query):
+		return scoreWordPrefix + wholeWordBonus(e.name, query)
+	}
+	allPrefixes, typos := true, 0
+	for _, qt := range qTokens {
+		best := -1
+		for _, t := range e.tokens {
+			if strings.HasPrefix(t, qt) {
+				best = 0
+				break
+			}
+			if d := tokenDistance(qt, t); d >= 0 && (best < 0 || d < best) {
+				best = d
+			}
+		}
+		if best != 0 {
+			allPrefixes = false
+		}
+		if best < 0 {
+			typos = -1
+			break
+		}
+		typos += best
+	}
+	switch {
+	case allPrefixes:
+		return scoreAllPrefixes
+	case strings.Contains(e.compact, qCompact):
+		return scoreInfix
+	case typos > 0:
+		return scoreTypo - float64(typos)
+	}
+	return 0
+}
+
+// wholeWordBonus prefers names where the query ends on a word boundary ("bahia de" ranks
+// "Bahia de Alcudia" above "Bahia del Sol")
+func wholeWordBonus(name, query string) float64 {
+	if strings.Contains(" "+name+" ", " "+query+" ") {
+		return 5
+	}
+	return 0
+}
+
+// tokenDistance is the edit distance between a query word and a name word (or the name word's
+// prefix of the same length, to allow typos while typing); -1 if above the allowed typos.
+func tokenDistance(q, t string) int {
+	allowed := 0
+	switch n := len([]rune(q)); {
+	case n >= 8:
+		allowed = 2
+	case n >= 4:
+		allowed = 1
+	}
+	if allowed == 0 {
+		return -1
+	}
+	d := editDistance(q, t)
+	if tr := []rune(t); len(tr) > len([]rune(q)) {
+		d = min(d, editDistance(q, string(tr[:len([]rune(q))])))
+	}
+	if d > allowed {
+		return -1
+	}
+	return d
+}
+
+// editDistance is the optimal string alignment distance (Levenshtein plus adjacent transpositions)
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// foldName lowercases s, strips Spanish/Catalan diacritics, drops apostrophes and the Catalan
+// middle dot ("Cala d'Or" -> "cala dor", "Pil·larí" -> "pillari") and turns other punctuation
+// into word breaks.
+func foldName(s string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(s) {
+		if f, ok := diacriticFold[r]; ok {
+			r = f
+		}
+		switch {
+		case r == '\'' || r == '’' || r == '´' || r == '`' || r == '·':
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			space = false
+		case !space:
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+var diacriticFold = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ä': 'a', 'ã': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'ö': 'o', 'õ': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ñ': 'n', 'ç': 'c',
+}
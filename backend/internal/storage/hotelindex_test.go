@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"palma", "palma", 0},
+		{"palma", "plama", 1}, // adjacent transposition
+		{"palma", "palmas", 1},
+		{"palma", "pama", 1},
+		{"palma", "pelma", 1},
+		{"alcudia", "alcduia", 1},
+		{"kitten", "sitting", 3},
+		{"ca", "abc", 3}, // optimal string alignment, not full Damerau
+		{"pañol", "panol", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := editDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := editDistance(tt.b, tt.a); got != tt.want {
+				t.Errorf("editDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenDistance(t *testing.T) {
+	tests := []struct {
+		q, t string
+		want int
+	}{
+		{"sol", "sil", -1},       // short words allow no typos
+		{"palma", "plama", 1},    // one typo from four letters
+		{"plam", "palmanova", 1}, // typo in the typed prefix
+		{"palma", "polmo", -1},   // two typos on a short word
+		{"alcudiaa", "alcudia", 1},
+		{"alcduiax", "alcudiamar", 2}, // two typos from eight letters
+		{"xyzxyzxy", "alcudiamar", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.q+"/"+tt.t, func(t *testing.T) {
+			if got := tokenDistance(tt.q, tt.t); got != tt.want {
+				t.Errorf("tokenDistance(%q, %q) = %d, want %d", tt.q, tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFoldName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Hotel Palma", "hotel palma"},
+		{"  Hotel   Palma  ", "hotel palma"},
+		{"Cala d'Or", "cala dor"},
+		{"Cala d’Or", "cala dor"},
+		{"Pil·larí", "pillari"},
+		{"Àrea Señorío Müller", "area senorio muller"},
+		{"Sol-Mar/Beach, Resort & Spa", "sol mar beach resort spa"},
+		{"Hotel 2000", "hotel 2000"},
+		{"!!!", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := foldName(tt.in); got != tt.want {
+				t.Errorf("foldName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHotelIndexSearchOrder(t *testing.T) {
+	hotels := []models.Hotel{
+		{ID: 1, Name: "Bahia del Sol"},
+		{ID: 2, Name: "Bahia de Alcudia"},
+		{ID: 3, Name: "Hotel Bahia"},
+		{ID: 4, Name: "Bahia"},
+		{ID: 5, Name: "Grand Hotel Palma"},
+		{ID: 6, Name: "Palma Beach"},
+		{ID: 7, Name: "Cala d'Or Playa"},
+		{ID: 8, Name: "Playa de Palma Resort"},
+		{ID: 9, Name: "Alcudiamar Club"},
+	}
+	x := NewHotelIndex(NewMemoryStorage(hotels))
+
+	tests := []struct {
+		name    string
+		q       string
+		limit   int
+		wantIDs []int
+	}{
+		// exact, then name prefixes (equal scores: shorter name first), then word prefix
+		{name: "exact and prefixes", q: "bahia", limit: 10, wantIDs: []int{4, 1, 2, 3}},
+		{name: "whole word bonus", q: "Bahia de", limit: 10, wantIDs: []int{2, 1}},
+		{name: "limit", q: "bahia", limit: 2, wantIDs: []int{4, 1}},
+		{name: "name prefix before word prefixes", q: "palma", limit: 10, wantIDs: []int{6, 5, 8}},
+		{name: "all word prefixes", q: "play pal", limit: 10, wantIDs: []int{8}},
+		{name: "folded", q: "cala dor", limit: 10, wantIDs: []int{7}},
+		{name: "infix", q: "udiam", limit: 10, wantIDs: []int{9}},
+		// one typo each, against a whole word and a word prefix
+		{name: "typo", q: "alcduia", limit: 10, wantIDs: []int{9, 2}},
+		{name: "no match", q: "zzzz", limit: 10, wantIDs: []int{}},
+		{name: "empty query", q: " ' ", limit: 10, wantIDs: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := x.Search(context.Background(), tt.q, tt.limit)
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			ids := []int{}
+			for i, r := range results {
+				ids = append(ids, r.Hotel.ID)
+				if i > 0 && r.Score > results[i-1].Score {
+					t.Errorf("results not sorted by score: %v", results)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("Search(%q) = %v, want %v", tt.q, ids, tt.wantIDs)
+			}
+		})
+	}
+}
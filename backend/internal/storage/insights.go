@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// HotelInsights summarizes all offers of a hotel; months are departure months in loc. ScanOffers
+// delivers the offers in price order, so min, median and max are read off the slice directly.
+func HotelInsights(ctx context.Context, s Storage, hotelID int, loc *time.Location) (*models.HotelInsights, error) {
+	insights := &models.HotelInsights{DepartureAirports: []string{}, MealTypes: []string{}, RoomTypes: []string{}}
+	err := s.ScanOffers(ctx, models.SearchParams{}, hotelID, func(_ *models.Hotel, offers []models.Offer) {
+		n := len(offers)
+		insights.CountOffers = n
+		insights.MinPrice = offers[0].Price
+		insights.MaxPrice = offers[n-1].Price
+		insights.MedianPrice = offers[n/2].Price
+		if n%2 == 0 {
+			insights.MedianPrice = (offers[n/2-1].Price + offers[n/2].Price) / 2
+		}
+
+		airports := make(map[string]bool)
+		mealTypes := make(map[string]bool)
+		roomTypes := make(map[string]bool)
+		type monthTotal struct {
+			sum   float64
+			count int
+		}
+		months := make(map[string]*monthTotal)
+		insights.MinDuration = offers[0].Duration()
+		for i := range offers {
+			o := &offers[i]
+			airports[o.OutboundDepartureAirport] = true
+			mealTypes[o.MealType] = true
+			roomTypes[o.RoomType] = true
+			d := o.Duration()
+			insights.MinDuration = min(insights.MinDuration, d)
+			insights.MaxDuration = max(insights.MaxDuration, d)
+			month := models.CalendarBucket(o.DepartureDate, models.GranularityMonth, loc).Format("2006-01")
+			m, ok := months[month]
+			if !ok {
+				m = &monthTotal{}
+				months[month] = m
+			}
+			m.sum += o.Price
+			m.count++
+		}
+		insights.DepartureAirports = sortedKeys(airports)
+		insights.MealTypes = sortedKeys(mealTypes)
+		insights.RoomTypes = sortedKeys(roomTypes)
+		for month, m := range months {
+			avg := m.sum / float64(m.count)
+			if insights.CheapestMonth == "" || avg < insights.CheapestMonthAvgPrice ||
+				(avg == insights.CheapestMonthAvgPrice && month < insights.CheapestMonth) {
+				insights.CheapestMonth, insights.CheapestMonthAvgPrice = month, avg
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return insights, nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// InsightsCache caches HotelInsights per hotel. All entries are dropped as soon as the storage
+// reports a new DataVersion, i.e. after an offers import has finished.
+type InsightsCache struct {
+	storage Storage
+	loc     *time.Location
+
+	mu      sync.Mutex
+	version time.Time
+	entries map[int]*models.HotelInsights
+}
+
+// NewInsightsCache creates an empty cache on top of s, computing months in loc
+func NewInsightsCache(s Storage, loc *time.Location) *InsightsCache {
+	return &InsightsCache{storage: s, loc: loc, entries: make(map[int]*models.HotelInsights)}
+}
+
+// Get returns the cached insights of a hotel, computing them on a miss
+func (c *InsightsCache) Get(ctx context.Context, hotelID int) (*models.HotelInsights, error) {
+	version, err := c.storage.DataVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if !version.Equal(c.version) {
+		c.version = version
+		c.entries = make(map[int]*models.HotelInsights)
+	}
+	cached := c.entries[hotelID]
+	c.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	insights, err := HotelInsights(ctx, c.storage, hotelID, c.loc)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if version.Equal(c.version) {
+		c.entries[hotelID] = insights
+	}
+	c.mu.Unlock()
+	return insights, nil
+}
@@ -0,0 +1,1040 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/bits"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+// MemoryStorage implements Storage with a columnar in-memory offer index.
+//
+// Offers are stored column by column and grouped per hotel, each hotel's rows
+// ordered by price ascending (mirroring the clustering order of the Scylla
+// offers table). Airports, meal and room types are dictionary-encoded; times are
+// packed as minutes since the Unix epoch, arrivals as deltas to their departure.
+// Usage: NewMemoryStorage, AddOffers (any number of times), Build.
+type MemoryStorage struct {
+	hotels    []models.Hotel
+	hotelByID map[int]models.Hotel
+
+	// ranges maps a hotel id to its row range [start, end) in cols
+	ranges map[int]rowRange
+	cols   offerColumns
+
+	airports  dictionary
+	mealTypes dictionary
+	roomTypes dictionary
+
+	// departureAirports holds the sorted outbound departure airport codes
+	departureAirports []string
+
+	searchLatency *latencyRecorder
+
+	// pending rows per hotel until Build is called
+	buildMu sync.Mutex
+	pending map[int]*offerColumns
+	built   bool
+	builtAt time.Time
+	// duplicates is the number of rows Build dropped for a cheaper row with the same offer id
+	duplicates int
+}
+
+// ctxCheckInterval is the number of rows scanned between context checks
+const ctxCheckInterval = 4096
+
+type rowRange struct {
+	start, end int
+}
+
+// offerColumns stores offers as parallel slices, one per attribute.
+type offerColumns struct {
+	price []uint32 // euro cents
+
+	outDep        []uint32 // minutes since epoch
+	inDep         []uint32 // minutes since epoch
+	outArrDelta   []uint16 // minutes after outDep
+	inArrDelta    []uint16 // minutes after inDep
+	duration      []uint8  // precomputed models.Offer.Duration
+	countAdults   []uint8
+	countChildren []uint8
+
+	outDepAirport []uint16
+	outArrAirport []uint16
+	inDepAirport  []uint16
+	inArrAirport  []uint16
+	mealType      []uint16
+	roomType      []uint16
+	oceanView     []bool
+
+	// fingerprint is models.Offer.Fingerprint of each row, filled by Build only (not by appendRow)
+	fingerprint []int64
+}
+
+// dictionary maps strings to compact codes and back.
+type dictionary struct {
+	codes  map[string]uint16
+	values []string
+}
+
+func (d *dictionary) encode(v string) uint16 {
+	if d.codes == nil {
+		d.codes = make(map[string]uint16)
+	}
+	if c, ok := d.codes[v]; ok {
+		return c
+	}
+	if len(d.values) > math.MaxUint16 {
+		panic(fmt.Sprintf("memory storage: dictionary overflow for %q", v))
+	}
+	c := uint16(len(d.values))
+	d.codes[v] = c
+	d.values = append(d.values, v)
+	return c
+}
+
+// codeSet marks the codes of values; nil for an empty list (matches all).
+// ok is false if none of the values is known, i.e. nothing can match.
+func (d *dictionary) codeSet(values []string) (set []bool, ok bool) {
+	if len(values) == 0 {
+		return nil, true
+	}
+	set = make([]bool, len(d.values))
+	for _, v := range values {
+		if c, known := d.codes[v]; known {
+			set[c] = true
+			ok = true
+		}
+	}
+	return set, ok
+}
+
+// NewMemoryStorage creates an empty in-memory storage for the given hotels.
+func NewMemoryStorage(hotels []models.Hotel) *MemoryStorage {
+	s := &MemoryStorage{
+		hotelByID: make(map[int]models.Hotel, len(hotels)),
+		ranges:    make(map[int]rowRange),
+		pending:   make(map[int]*offerColumns),
+
+		searchLatency: newLatencyRecorder(getEnvInt("SEARCH_LATENCY_WINDOW", 1024)),
+	}
+	for _, h := range hotels {
+		if _, dup := s.hotelByID[h.ID]; dup {
+			continue
+		}
+		s.hotelByID[h.ID] = h
+		s.hotels = append(s.hotels, h)
+	}
+	sort.Slice(s.hotels, func(i, j int) bool { return s.hotels[i].ID < s.hotels[j].ID })
+	return s
+}
+
+// AddOffers appends a batch of offers. Safe for concurrent use; must not be called after Build.
+func (s *MemoryStorage) AddOffers(batch []models.Offer) {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+	if s.built {
+		panic("memory storage: AddOffers after Build")
+	}
+	for i := range batch {
+		o := &batch[i]
+		c := s.pending[o.HotelID]
+		if c == nil {
+			c = &offerColumns{}
+			s.pending[o.HotelID] = c
+		}
+		s.appendOffer(c, o)
+	}
+}
+
+// appendOffer encodes one offer into c. Caller holds buildMu.
+func (s *MemoryStorage) appendOffer(c *offerColumns, o *models.Offer) {
+	outDep := packTime(o.DepartureDate)
+	inDep := packTime(o.ReturnDate)
+	c.price = append(c.price, uint32(math.Round(o.Price*100)))
+	c.outDep = append(c.outDep, outDep)
+	c.inDep = append(c.inDep, inDep)
+	c.outArrDelta = append(c.outArrDelta, packDelta(outDep, packTime(o.OutboundArrivalDateTime)))
+	c.inArrDelta = append(c.inArrDelta, packDelta(inDep, packTime(o.InboundArrivalDateTime)))
+	c.duration = append(c.duration, clampUint8(o.Duration()))
+	c.countAdults = append(c.countAdults, clampUint8(o.CountAdults))
+	c.countChildren = append(c.countChildren, clampUint8(o.CountChildren))
+	c.outDepAirport = append(c.outDepAirport, s.airports.encode(o.OutboundDepartureAirport))
+	c.outArrAirport = append(c.outArrAirport, s.airports.encode(o.OutboundArrivalAirport))
+	c.inDepAirport = append(c.inDepAirport, s.airports.encode(o.InboundDepartureAirport))
+	c.inArrAirport = append(c.inArrAirport, s.airports.encode(o.InboundArrivalAirport))
+	c.mealType = append(c.mealType, s.mealTypes.encode(o.MealType))
+	c.roomType = append(c.roomType, s.roomTypes.encode(o.RoomType))
+	c.oceanView = append(c.oceanView, o.OceanView)
+}
+
+// Build sorts the pending rows per hotel by price and freezes the index.
+func (s *MemoryStorage) Build() {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+	if s.built {
+		return
+	}
+	start := time.Now()
+
+	ids := make([]int, 0, len(s.pending))
+	total := 0
+	for id, c := range s.pending {
+		ids = append(ids, id)
+		total += len(c.price)
+	}
+	sort.Ints(ids)
+
+	s.cols = makeOfferColumns(total)
+	duplicates := 0
+	depSet := make(map[uint16]struct{})
+	for _, id := range ids {
+		c := s.pending[id]
+		perm := make([]int, len(c.price))
+		for i := range perm {
+			perm[i] = i
+		}
+		sort.SliceStable(perm, func(a, b int) bool {
+			pa, pb := c.price[perm[a]], c.price[perm[b]]
+			if pa != pb {
+				return pa < pb
+			}
+			return c.outDep[perm[a]] < c.outDep[perm[b]]
+		})
+		from := len(s.cols.price)
+		// an offer id (fingerprint) names exactly one row: keep the cheapest, as the importer does
+		seen := make(map[rowKey]struct{}, len(perm))
+		for _, i := range perm {
+			k := c.key(i)
+			if _, dup := seen[k]; dup {
+				duplicates++
+				continue
+			}
+			seen[k] = struct{}{}
+			s.cols.appendRow(c, i)
+			o := s.decodeRow(id, len(s.cols.price)-1)
+			s.cols.fingerprint = append(s.cols.fingerprint, o.Fingerprint())
+			depSet[c.outDepAirport[i]] = struct{}{}
+		}
+		s.ranges[id] = rowRange{start: from, end: len(s.cols.price)}
+		// release the per-hotel buffers early
+		delete(s.pending, id)
+	}
+	s.pending = nil
+
+	s.departureAirports = make([]string, 0, len(depSet))
+	for code := range depSet {
+		if v := s.airports.values[code]; v != "" {
+			s.departureAirports = append(s.departureAirports, v)
+		}
+	}
+	sort.Strings(s.departureAirports)
+	s.built = true
+	s.builtAt = time.Now()
+	s.duplicates = duplicates
+	log.Printf("memory: index built in %s; offers=%d, duplicates=%d, hotels=%d, airports=%d", time.Since(start), total-duplicates, duplicates, len(ids), len(s.departureAirports))
+}
+
+// rowKey holds every column of a row except the price, i.e. what models.Offer.Fingerprint hashes
+type rowKey struct {
+	outDep, inDep                                            uint32
+	outArrDelta, inArrDelta                                  uint16
+	countAdults, countChildren                               uint8
+	outDepAirport, outArrAirport, inDepAirport, inArrAirport uint16
+	mealType, roomType                                       uint16
+	oceanView                                                bool
+}
+
+func (c *offerColumns) key(i int) rowKey {
+	return rowKey{
+		outDep: c.outDep[i], inDep: c.inDep[i],
+		outArrDelta: c.outArrDelta[i], inArrDelta: c.inArrDelta[i],
+		countAdults: c.countAdults[i], countChildren: c.countChildren[i],
+		outDepAirport: c.outDepAirport[i], outArrAirport: c.outArrAirport[i],
+		inDepAirport: c.inDepAirport[i], inArrAirport: c.inArrAirport[i],
+		mealType: c.mealType[i], roomType: c.roomType[i],
+		oceanView: c.oceanView[i],
+	}
+}
+
+func makeOfferColumns(n int) offerColumns {
+	return offerColumns{
+		price:         make([]uint32, 0, n),
+		outDep:        make([]uint32, 0, n),
+		inDep:         make([]uint32, 0, n),
+		outArrDelta:   make([]uint16, 0, n),
+		inArrDelta:    make([]uint16, 0, n),
+		duration:      make([]uint8, 0, n),
+		countAdults:   make([]uint8, 0, n),
+		countChildren: make([]uint8, 0, n),
+		outDepAirport: make([]uint16, 0, n),
+		outArrAirport: make([]uint16, 0, n),
+		inDepAirport:  make([]uint16, 0, n),
+		inArrAirport:  make([]uint16, 0, n),
+		mealType:      make([]uint16, 0, n),
+		roomType:      make([]uint16, 0, n),
+		oceanView:     make([]bool, 0, n),
+		fingerprint:   make([]int64, 0, n),
+	}
+}
+
+// appendRow copies row i of src to the end of c
+func (c *offerColumns) appendRow(src *offerColumns, i int) {
+	c.price = append(c.price, src.price[i])
+	c.outDep = append(c.outDep, src.outDep[i])
+	c.inDep = append(c.inDep, src.inDep[i])
+	c.outArrDelta = append(c.outArrDelta, src.outArrDelta[i])
+	c.inArrDelta = append(c.inArrDelta, src.inArrDelta[i])
+	c.duration = append(c.duration, src.duration[i])
+	c.countAdults = append(c.countAdults, src.countAdults[i])
+	c.countChildren = append(c.countChildren, src.countChildren[i])
+	c.outDepAirport = append(c.outDepAirport, src.outDepAirport[i])
+	c.outArrAirport = append(c.outArrAirport, src.outArrAirport[i])
+	c.inDepAirport = append(c.inDepAirport, src.inDepAirport[i])
+	c.inArrAirport = append(c.inArrAirport, src.inArrAirport[i])
+	c.mealType = append(c.mealType, src.mealType[i])
+	c.roomType = append(c.roomType, src.roomType[i])
+	c.oceanView = append(c.oceanView, src.oceanView[i])
+}
+
+// LoadFromScylla fills the storage by scanning the offers table partition by partition.
+// Hotels without a row in the hotels table are skipped.
+func (s *MemoryStorage) LoadFromScylla(session *gocql.Session) error {
+	start := time.Now()
+	maxParallel := getEnvInt("MEMORY_LOAD_PARALLEL", 8)
+	log.Printf("memory: loading offers from scylla; hotels=%d, parallel=%d", len(s.hotels), maxParallel)
+	sem := make(chan struct{}, maxParallel)
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		firstErr error
+	)
+	for _, h := range s.hotels {
+		wg.Add(1)
+		sem <- struct{}{}
+		hotelID := h.ID
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			iter := session.Query(offersSelect, hotelID).Consistency(gocql.One).PageSize(5000).Iter()
+			batch := make([]models.Offer, 0, 5000)
+			for {
+				o, ok := scanOffer(iter)
+				if !ok {
+					break
+				}
+				batch = append(batch, o)
+				if len(batch) == cap(batch) {
+					s.AddOffers(batch)
+					batch = batch[:0]
+				}
+			}
+			s.AddOffers(batch)
+			if err := iter.Close(); err != nil {
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("scan offers of hotel %d: %w", hotelID, err)
+				}
+				errMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	log.Printf("memory: offers loaded from scylla in %s", time.Since(start))
+	return nil
+}
+
+// GetHotel returns a hotel by ID
+func (s *MemoryStorage) GetHotel(ctx context.Context, hotelID int) (*models.Hotel, error) {
+	h, ok := s.hotelByID[hotelID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &h, nil
+}
+
+// GetAllHotels returns all hotels ordered by ID
+func (s *MemoryStorage) GetAllHotels(ctx context.Context) ([]models.Hotel, error) {
+	out := make([]models.Hotel, len(s.hotels))
+	copy(out, s.hotels)
+	return out, nil
+}
+
+// GetOffersByHotel returns all matching offers of a hotel ordered by price
+func (s *MemoryStorage) GetOffersByHotel(ctx context.Context, hotelID int, params models.SearchParams) ([]models.Offer, error) {
+	r, ok := s.ranges[hotelID]
+	if !ok {
+		return nil, nil
+	}
+	f, possible := s.compileFilter(params)
+	if !possible {
+		return nil, nil
+	}
+	r = f.narrow(&s.cols, r)
+	var res []models.Offer
+	for i := r.start; i < r.end; i++ {
+		if (i-r.start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if f.match(&s.cols, i) {
+			res = append(res, s.offerAt(hotelID, i))
+		}
+	}
+	return res, nil
+}
+
+// GetOffersPageByHotel returns up to limit matching offers of a hotel; the cursor is a row
+// position within the hotel's range. The whole range is scanned, so TotalEstimate is exact.
+func (s *MemoryStorage) GetOffersPageByHotel(ctx context.Context, hotelID int, params models.SearchParams, limit int, cursor string) (*models.OffersPage, error) {
+	cur, err := decodePageCursor(cursor, hotelID, params)
+	if err != nil || cur.State != nil {
+		return nil, ErrInvalidCursor
+	}
+	page := &models.OffersPage{Items: []models.Offer{}}
+	hr, ok := s.ranges[hotelID]
+	if !ok {
+		return page, nil
+	}
+	f, possible := s.compileFilter(params)
+	if !possible {
+		return page, nil
+	}
+	r := f.narrow(&s.cols, hr)
+	for i := r.start; i < r.end; i++ {
+		if (i-r.start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !f.match(&s.cols, i) {
+			continue
+		}
+		page.TotalEstimate++
+		pos := i - hr.start
+		if pos < cur.Skip || page.NextCursor != "" {
+			continue
+		}
+		if limit > 0 && len(page.Items) == limit {
+			page.NextCursor = encodePageCursor(pageCursor{HotelID: hotelID, Skip: pos, Filter: cur.Filter})
+			continue
+		}
+		page.Items = append(page.Items, s.offerAt(hotelID, i))
+	}
+	return page, nil
+}
+
+// GetOffer resolves an offer id via the fingerprint column of its hotel's rows; Build keeps one
+// row per id
+func (s *MemoryStorage) GetOffer(ctx context.Context, offerID string) (*models.Offer, error) {
+	hotelID, fingerprint, err := models.ParseOfferID(offerID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	r, ok := s.ranges[hotelID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	for i, fp := range s.cols.fingerprint[r.start:r.end] {
+		if fp == fingerprint {
+			o := s.offerAt(hotelID, r.start+i)
+			return &o, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// GetHotelsWithBestOffers returns hotels with their cheapest matching offer
+func (s *MemoryStorage) GetHotelsWithBestOffers(ctx context.Context, params models.SearchParams) (results []models.HotelWithBestOffer, err error) {
+	start := time.Now()
+	defer func() { s.searchLatency.observe(time.Since(start), err) }()
+	f, possible := s.compileFilter(params)
+	if !possible {
+		return []models.HotelWithBestOffer{}, nil
+	}
+
+	// best[i] is the row index of the cheapest match of s.hotels[i], or -1; counts[i] its number of matches
+	best := make([]int, len(s.hotels))
+	counts := make([]int, len(s.hotels))
+	workers := runtime.GOMAXPROCS(0)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				hi := int(next.Add(1) - 1)
+				if hi >= len(s.hotels) || ctx.Err() != nil {
+					return
+				}
+				best[hi] = -1
+				h := &s.hotels[hi]
+				r, ok := s.ranges[h.ID]
+				if !ok || !h.Matches(params) {
+					continue
+				}
+				r = f.narrow(&s.cols, r)
+				// rows are ordered by price, the first match is the cheapest
+				n := 0
+				for i := r.start; i < r.end; i++ {
+					if (i-r.start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+						return
+					}
+					if f.match(&s.cols, i) {
+						if n == 0 {
+							best[hi] = i
+						}
+						n++
+					}
+				}
+				counts[hi] = n
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results = make([]models.HotelWithBestOffer, 0, len(s.hotels))
+	for hi, row := range best {
+		if row < 0 {
+			continue
+		}
+		h := s.hotels[hi]
+		offer := s.offerAt(h.ID, row)
+		results = append(results, models.HotelWithBestOffer{Hotel: h, BestOffer: &offer, CountAvailableOffers: counts[hi]})
+	}
+	log.Printf("memory: best offers search took %s; hotels=%d", time.Since(start), len(results))
+	return results, nil
+}
+
+// ScanOffers decodes the matching rows of one or all hotels; workers reuse their offer buffers
+func (s *MemoryStorage) ScanOffers(ctx context.Context, params models.SearchParams, hotelID int, visit func(hotel *models.Hotel, offers []models.Offer)) error {
+	hotels := s.hotels
+	if hotelID != 0 {
+		hi := sort.Search(len(s.hotels), func(i int) bool { return s.hotels[i].ID >= hotelID })
+		if hi == len(s.hotels) || s.hotels[hi].ID != hotelID {
+			return ErrNotFound
+		}
+		hotels = s.hotels[hi : hi+1]
+	}
+	f, possible := s.compileFilter(params)
+	if !possible {
+		return nil
+	}
+	workers := min(runtime.GOMAXPROCS(0), len(hotels))
+	var (
+		next atomic.Int64
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var buf []models.Offer
+			for {
+				hi := int(next.Add(1) - 1)
+				if hi >= len(hotels) || ctx.Err() != nil {
+					return
+				}
+				h := &hotels[hi]
+				r, ok := s.ranges[h.ID]
+				if !ok || !h.Matches(params) {
+					continue
+				}
+				r = f.narrow(&s.cols, r)
+				buf = buf[:0]
+				for i := r.start; i < r.end; i++ {
+					if (i-r.start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+						return
+					}
+					if f.match(&s.cols, i) {
+						buf = append(buf, s.offerAt(h.ID, i))
+					}
+				}
+				if len(buf) > 0 {
+					mu.Lock()
+					visit(h, buf)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// GetFacets computes the search facets in the encoded domain, one hotel per task
+func (s *MemoryStorage) GetFacets(ctx context.Context, params models.SearchParams) (*models.SearchFacets, error) {
+	var collector facetCollector
+	f, possible := s.compileFacetFilter(params)
+	if !possible {
+		return collector.result(), nil
+	}
+	workers := runtime.GOMAXPROCS(0)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			acc := s.newMemHotelFacets()
+			for {
+				hi := int(next.Add(1) - 1)
+				if hi >= len(s.hotels) || ctx.Err() != nil {
+					return
+				}
+				h := &s.hotels[hi]
+				r, ok := s.ranges[h.ID]
+				if !ok {
+					continue
+				}
+				var starsMiss models.FacetMask
+				if !h.Matches(params) {
+					starsMiss = starsMiss.With(models.FacetStars)
+				}
+				acc.reset()
+				r = f.narrow(&s.cols, r)
+				for i := r.start; i < r.end; i++ {
+					if (i-r.start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+						return
+					}
+					if core, misses := f.misses(&s.cols, i); core {
+						acc.add(&s.cols, i, misses|starsMiss)
+					}
+				}
+				hf := acc.decode(s, h)
+				collector.merge(&hf)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return collector.result(), nil
+}
+
+// GetStats returns simple stats about the loaded index
+func (s *MemoryStorage) GetStats(ctx context.Context) (map[string]interface{}, error) {
+	withOffers := 0
+	for _, h := range s.hotels {
+		if r, ok := s.ranges[h.ID]; ok && r.end > r.start {
+			withOffers++
+		}
+	}
+	return map[string]interface{}{
+		"hotels":             len(s.hotels),
+		"offers":             len(s.cols.price),
+		"hotels_with_offers": withOffers,
+		"duplicate_offers":   s.duplicates,
+		"backend":            "memory",
+		"search_latency":     s.searchLatency.snapshot(),
+	}, nil
+}
+
+// DataVersion is the time of Build; the index never changes afterwards
+func (s *MemoryStorage) DataVersion(ctx context.Context) (time.Time, error) {
+	return s.builtAt, nil
+}
+
+// GetAvailableDepartureAirports returns the outbound departure airports seen during Build
+func (s *MemoryStorage) GetAvailableDepartureAirports(ctx context.Context) ([]string, error) {
+	out := make([]string, len(s.departureAirports))
+	copy(out, s.departureAirports)
+	return out, nil
+}
+
+// offerAt decodes row i into a models.Offer including its id
+func (s *MemoryStorage) offerAt(hotelID, i int) models.Offer {
+	o := s.decodeRow(hotelID, i)
+	o.ID = models.FormatOfferID(hotelID, s.cols.fingerprint[i])
+	return o
+}
+
+// decodeRow is offerAt without the offer id
+func (s *MemoryStorage) decodeRow(hotelID, i int) models.Offer {
+	c := &s.cols
+	outDep := c.outDep[i]
+	inDep := c.inDep[i]
+	return models.Offer{
+		HotelID:                  hotelID,
+		DepartureDate:            unpackTime(outDep),
+		ReturnDate:               unpackTime(inDep),
+		CountAdults:              int(c.countAdults[i]),
+		CountChildren:            int(c.countChildren[i]),
+		Price:                    float64(c.price[i]) / 100,
+		InboundDepartureAirport:  s.airports.values[c.inDepAirport[i]],
+		InboundArrivalAirport:    s.airports.values[c.inArrAirport[i]],
+		InboundArrivalDateTime:   unpackTime(inDep + uint32(c.inArrDelta[i])),
+		OutboundDepartureAirport: s.airports.values[c.outDepAirport[i]],
+		OutboundArrivalAirport:   s.airports.values[c.outArrAirport[i]],
+		OutboundArrivalDateTime:  unpackTime(outDep + uint32(c.outArrDelta[i])),
+		MealType:                 s.mealTypes.values[c.mealType[i]],
+		OceanView:                c.oceanView[i],
+		RoomType:                 s.roomTypes.values[c.roomType[i]],
+	}
+}
+
+// memFilter is models.SearchParams translated to the encoded column domain.
+type memFilter struct {
+	airports      []bool // indexed by airport code; nil matches all
+	mealTypes     []bool // indexed by meal type code; nil matches all
+	roomTypes     []bool // indexed by room type code; nil matches all
+	minOutDep     uint32
+	maxOutDep     uint32
+	maxInDep      uint32
+	minPrice      uint32
+	maxPrice      uint32
+	countAdults   uint8
+	countChildren uint8
+	minDuration   uint8
+	maxDuration   uint8
+	oceanView     int8 // -1 matches all, otherwise 0/1
+	// price bounds per person and per night in Euro, only checked if unitPrices is set
+	unitPrices                 bool
+	minPerPerson, maxPerPerson float64
+	minPerNight, maxPerNight   float64
+}
+
+// centEpsilon absorbs the float error of a Euro bound times 100
+const centEpsilon = 1e-6
+
+// compileFilter translates params; possible is false if no row can match.
+func (s *MemoryStorage) compileFilter(params models.SearchParams) (f memFilter, possible bool) {
+	f.maxOutDep = math.MaxUint32
+	f.maxInDep = math.MaxUint32
+	f.maxPrice = math.MaxUint32
+	f.maxDuration = math.MaxUint8
+	f.oceanView = -1
+	var ok bool
+	if f.airports, ok = s.airports.codeSet(params.DepartureAirports); !ok {
+		return f, false
+	}
+	if f.mealTypes, ok = s.mealTypes.codeSet(params.MealTypes); !ok {
+		return f, false
+	}
+	if f.roomTypes, ok = s.roomTypes.codeSet(params.RoomTypes); !ok {
+		return f, false
+	}
+	if params.OceanView != nil {
+		f.oceanView = 0
+		if *params.OceanView {
+			f.oceanView = 1
+		}
+	}
+	// bounds in cents; the epsilon keeps bounds on a cent whose float product lies just beside it
+	// (2.3*100 = 229.99999999999997) on that cent, as Offer.Matches compares them
+	if params.MinPrice > 0 {
+		f.minPrice = clampUint32(int64(math.Ceil(params.MinPrice*100 - centEpsilon)))
+	}
+	if params.MaxPrice > 0 {
+		f.maxPrice = clampUint32(int64(math.Floor(params.MaxPrice*100 + centEpsilon)))
+		if f.maxPrice < f.minPrice {
+			return f, false
+		}
+	}
+	if params.HasUnitPriceFilters() {
+		f.unitPrices = true
+		f.minPerPerson, f.maxPerPerson = params.MinPricePerPerson, math.Inf(1)
+		if params.MaxPricePerPerson > 0 {
+			f.maxPerPerson = params.MaxPricePerPerson
+		}
+		f.minPerNight, f.maxPerNight = params.MinPricePerNight, math.Inf(1)
+		if params.MaxPricePerNight > 0 {
+			f.maxPerNight = params.MaxPricePerNight
+		}
+	}
+	from, until := params.DepartureWindow()
+	if !from.IsZero() {
+		// outDep*60 >= from  <=>  outDep >= ceil(from/60)
+		sec := ceilUnix(from)
+		if sec > 0 {
+			f.minOutDep = clampUint32((sec + 59) / 60)
+		}
+	}
+	if !until.IsZero() {
+		// outDep*60 < until  <=>  outDep <= ceil(until/60) - 1
+		sec := ceilUnix(until)
+		if sec <= 0 {
+			return f, false
+		}
+		f.maxOutDep = clampUint32((sec+59)/60 - 1)
+	}
+	if !params.LatestReturnDate.IsZero() {
+		// inDep*60 <= latest  <=>  inDep <= floor(latest/60)
+		sec := params.LatestReturnDate.Unix()
+		if sec < 0 {
+			return f, false
+		}
+		f.maxInDep = clampUint32(sec / 60)
+	}
+	minDuration, maxDuration := params.DurationBounds()
+	if params.CountAdults < 0 || params.CountAdults > math.MaxUint8 ||
+		params.CountChildren < 0 || params.CountChildren > math.MaxUint8 ||
+		minDuration < 0 || minDuration > math.MaxUint8 || maxDuration < 0 {
+		return f, false
+	}
+	f.countAdults = uint8(params.CountAdults)
+	f.countChildren = uint8(params.CountChildren)
+	f.minDuration = uint8(minDuration)
+	if maxDuration != 0 {
+		f.maxDuration = clampUint8(maxDuration)
+	}
+	return f, true
+}
+
+// match mirrors models.Offer.Matches on the encoded row i
+func (f *memFilter) match(c *offerColumns, i int) bool {
+	if f.countAdults != 0 && c.countAdults[i] != f.countAdults {
+		return false
+	}
+	if f.countChildren != 0 && c.countChildren[i] != f.countChildren {
+		return false
+	}
+	if c.duration[i] < f.minDuration || c.duration[i] > f.maxDuration {
+		return false
+	}
+	if c.outDep[i] < f.minOutDep || c.outDep[i] > f.maxOutDep || c.inDep[i] > f.maxInDep {
+		return false
+	}
+	if f.airports != nil && !f.airports[c.outDepAirport[i]] {
+		return false
+	}
+	if c.price[i] < f.minPrice || c.price[i] > f.maxPrice || (f.unitPrices && !f.matchUnitPrices(c, i)) {
+		return false
+	}
+	if f.mealTypes != nil && !f.mealTypes[c.mealType[i]] {
+		return false
+	}
+	if f.roomTypes != nil && !f.roomTypes[c.roomType[i]] {
+		return false
+	}
+	if f.oceanView >= 0 && c.oceanView[i] != (f.oceanView == 1) {
+		return false
+	}
+	return true
+}
+
+// matchUnitPrices mirrors the per-person and per-night checks of models.Offer.Matches on row i;
+// it divides the Euro price of the decoded offer so that both compare the same floats
+func (f *memFilter) matchUnitPrices(c *offerColumns, i int) bool {
+	price := float64(c.price[i]) / 100
+	perPerson := price / float64(max(int(c.countAdults[i])+int(c.countChildren[i]), 1))
+	perNight := price / float64(max(int(c.duration[i]), 1))
+	return perPerson >= f.minPerPerson && perPerson <= f.maxPerPerson &&
+		perNight >= f.minPerNight && perNight <= f.maxPerNight
+}
+
+// compileFacetFilter is compileFilter without short-circuiting on unknown airports, meal or room
+// types: such a filter only rules out the rows for the other facets, not for its own.
+func (s *MemoryStorage) compileFacetFilter(params models.SearchParams) (f memFilter, possible bool) {
+	core := params
+	core.DepartureAirports, core.MealTypes, core.RoomTypes = nil, nil, nil
+	if f, possible = s.compileFilter(core); !possible {
+		return f, false
+	}
+	f.airports, _ = s.airports.codeSet(params.DepartureAirports)
+	f.mealTypes, _ = s.mealTypes.codeSet(params.MealTypes)
+	f.roomTypes, _ = s.roomTypes.codeSet(params.RoomTypes)
+	return f, true
+}
+
+// misses mirrors models.Offer.FacetMisses on the encoded row i
+func (f *memFilter) misses(c *offerColumns, i int) (core bool, misses models.FacetMask) {
+	if f.countAdults != 0 && c.countAdults[i] != f.countAdults {
+		return false, 0
+	}
+	if f.countChildren != 0 && c.countChildren[i] != f.countChildren {
+		return false, 0
+	}
+	if c.outDep[i] < f.minOutDep || c.outDep[i] > f.maxOutDep || c.inDep[i] > f.maxInDep {
+		return false, 0
+	}
+	if c.price[i] < f.minPrice || c.price[i] > f.maxPrice || (f.unitPrices && !f.matchUnitPrices(c, i)) {
+		return false, 0
+	}
+	if f.airports != nil && !f.airports[c.outDepAirport[i]] {
+		misses = misses.With(models.FacetDepartureAirport)
+	}
+	if f.mealTypes != nil && !f.mealTypes[c.mealType[i]] {
+		misses = misses.With(models.FacetMealType)
+	}
+	if f.roomTypes != nil && !f.roomTypes[c.roomType[i]] {
+		misses = misses.With(models.FacetRoomType)
+	}
+	if f.oceanView >= 0 && c.oceanView[i] != (f.oceanView == 1) {
+		misses = misses.With(models.FacetOceanView)
+	}
+	if c.duration[i] < f.minDuration || c.duration[i] > f.maxDuration {
+		misses = misses.With(models.FacetDuration)
+	}
+	return true, misses
+}
+
+// memHotelFacets accumulates one hotel's facets by code instead of by string, so the
+// scan stays allocation free. Each slot holds the cheapest price in cents, noPrice if unseen.
+type memHotelFacets struct {
+	airports  []uint32
+	mealTypes []uint32
+	roomTypes []uint32
+	oceanView [2]uint32
+	stars     uint32
+	duration  [math.MaxUint8 + 1]uint32
+}
+
+const noPrice = math.MaxUint32
+
+func (s *MemoryStorage) newMemHotelFacets() *memHotelFacets {
+	return &memHotelFacets{
+		airports:  make([]uint32, len(s.airports.values)),
+		mealTypes: make([]uint32, len(s.mealTypes.values)),
+		roomTypes: make([]uint32, len(s.roomTypes.values)),
+	}
+}
+
+func (a *memHotelFacets) reset() {
+	for _, slots := range [][]uint32{a.airports, a.mealTypes, a.roomTypes, a.oceanView[:], a.duration[:]} {
+		for k := range slots {
+			slots[k] = noPrice
+		}
+	}
+	a.stars = noPrice
+}
+
+// add mirrors hotelFacets.add for row i
+func (a *memHotelFacets) add(c *offerColumns, i int, misses models.FacetMask) {
+	all := misses == 0
+	if !all && bits.OnesCount8(uint8(misses)) > 1 {
+		return
+	}
+	p := c.price[i]
+	if all || misses.Has(models.FacetDepartureAirport) {
+		a.airports[c.outDepAirport[i]] = min(a.airports[c.outDepAirport[i]], p)
+	}
+	if all || misses.Has(models.FacetMealType) {
+		a.mealTypes[c.mealType[i]] = min(a.mealTypes[c.mealType[i]], p)
+	}
+	if all || misses.Has(models.FacetRoomType) {
+		a.roomTypes[c.roomType[i]] = min(a.roomTypes[c.roomType[i]], p)
+	}
+	if all || misses.Has(models.FacetOceanView) {
+		ov := 0
+		if c.oceanView[i] {
+			ov = 1
+		}
+		a.oceanView[ov] = min(a.oceanView[ov], p)
+	}
+	if all || misses.Has(models.FacetStars) {
+		a.stars = min(a.stars, p)
+	}
+	if all || misses.Has(models.FacetDuration) {
+		a.duration[c.duration[i]] = min(a.duration[c.duration[i]], p)
+	}
+}
+
+// decode converts the accumulated codes of hotel h to facet values
+func (a *memHotelFacets) decode(s *MemoryStorage, h *models.Hotel) hotelFacets {
+	var hf hotelFacets
+	put := func(f models.Facet, value string, p uint32) {
+		if p != noPrice {
+			hf.addValue(f, value, float64(p)/100)
+		}
+	}
+	for code, p := range a.airports {
+		put(models.FacetDepartureAirport, s.airports.values[code], p)
+	}
+	for code, p := range a.mealTypes {
+		put(models.FacetMealType, s.mealTypes.values[code], p)
+	}
+	for code, p := range a.roomTypes {
+		put(models.FacetRoomType, s.roomTypes.values[code], p)
+	}
+	put(models.FacetOceanView, "false", a.oceanView[0])
+	put(models.FacetOceanView, "true", a.oceanView[1])
+	put(models.FacetStars, starsFacetValue(h.Stars), a.stars)
+	for d, p := range a.duration {
+		put(models.FacetDuration, strconv.Itoa(d), p)
+	}
+	return hf
+}
+
+// narrow shrinks a hotel's row range to the rows within the price bounds (rows are ordered by price)
+func (f *memFilter) narrow(c *offerColumns, r rowRange) rowRange {
+	if f.minPrice > 0 {
+		r.start += sort.Search(r.end-r.start, func(k int) bool { return c.price[r.start+k] >= f.minPrice })
+	}
+	if f.maxPrice < math.MaxUint32 {
+		r.end = r.start + sort.Search(r.end-r.start, func(k int) bool { return c.price[r.start+k] > f.maxPrice })
+	}
+	return r
+}
+
+// --- packing helpers ---
+
+// ceilUnix is t in Unix seconds, rounded up if t has a fraction of a second
+func ceilUnix(t time.Time) int64 {
+	if t.Nanosecond() > 0 {
+		return t.Unix() + 1
+	}
+	return t.Unix()
+}
+
+func packTime(t time.Time) uint32 {
+	if t.IsZero() {
+		return 0
+	}
+	return clampUint32(t.Unix() / 60)
+}
+
+// unpackTime returns the UTC instant; the source offset is not kept (times are output in UTC).
+func unpackTime(m uint32) time.Time {
+	if m == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(m)*60, 0).UTC()
+}
+
+func packDelta(from, to uint32) uint16 {
+	if to <= from {
+		return 0
+	}
+	if d := to - from; d < math.MaxUint16 {
+		return uint16(d)
+	}
+	return math.MaxUint16
+}
+
+func clampUint8(v int) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > math.MaxUint8 {
+		return math.MaxUint8
+	}
+	return uint8(v)
+}
+
+func clampUint32(v int64) uint32 {
+	if v < 0 {
+		return 0
+	}
+	if v > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(v)
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// filterTestStorage holds one hotel with offers covering every filtered column, including prices
+// and departure times close to the filter bounds used in TestMemFilterMatchesOffer
+func filterTestStorage() *MemoryStorage {
+	s := NewMemoryStorage([]models.Hotel{{ID: 1, Name: "Test", Stars: 4}})
+	var batch []models.Offer
+	n := 0
+	for _, cents := range []int{7, 115, 230, 1999, 9999, 10000, 10001, 45050, 99999} {
+		for _, day := range []time.Time{
+			time.Date(2025, 3, 29, 23, 0, 0, 0, time.UTC),
+			time.Date(2025, 8, 14, 21, 59, 0, 0, time.UTC),
+			time.Date(2025, 8, 14, 22, 0, 0, 0, time.UTC),
+			time.Date(2025, 8, 15, 6, 30, 0, 0, time.UTC),
+		} {
+			for _, nights := range []int{0, 3, 7, 14} {
+				for _, party := range [][2]int{{1, 0}, {2, 0}, {2, 1}} {
+					for _, airport := range []string{"FRA", "MUC"} {
+						for _, meal := range []string{"", "halfboard"} {
+							for _, ocean := range []bool{false, true} {
+								// spread departures over a few minutes; rows that still share an offer id
+								// collapse to the cheapest in Build
+								dep := day.Add(time.Duration(n%7) * time.Minute)
+								ret := dep.AddDate(0, 0, nights)
+								n++
+								batch = append(batch, models.Offer{
+									HotelID:                  1,
+									DepartureDate:            dep,
+									ReturnDate:               ret,
+									CountAdults:              party[0],
+									CountChildren:            party[1],
+									Price:                    float64(cents) / 100,
+									OutboundDepartureAirport: airport,
+									OutboundArrivalAirport:   "PMI",
+									OutboundArrivalDateTime:  dep.Add(2 * time.Hour),
+									InboundDepartureAirport:  "PMI",
+									InboundArrivalAirport:    airport,
+									InboundArrivalDateTime:   ret.Add(2 * time.Hour),
+									MealType:                 meal,
+									RoomType:                 []string{"double", "suite"}[n%2],
+									OceanView:                ocean,
+								})
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	s.AddOffers(batch)
+	s.Build()
+	return s
+}
+
+func TestMemFilterMatchesOffer(t *testing.T) {
+	s := filterTestStorage()
+	yes, no := true, false
+	at := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		name   string
+		params models.SearchParams
+	}{
+		{name: "no filter"},
+		{name: "airport", params: models.SearchParams{DepartureAirports: []string{"MUC"}}},
+		{name: "airports", params: models.SearchParams{DepartureAirports: []string{"MUC", "FRA"}}},
+		{name: "unknown airport", params: models.SearchParams{DepartureAirports: []string{"XXX"}}},
+		{name: "known and unknown airport", params: models.SearchParams{DepartureAirports: []string{"XXX", "FRA"}}},
+		{name: "adults", params: models.SearchParams{CountAdults: 2}},
+		{name: "children", params: models.SearchParams{CountChildren: 1}},
+		{name: "too many adults", params: models.SearchParams{CountAdults: 300}},
+		{name: "duration", params: models.SearchParams{Duration: 7}},
+		{name: "same-day duration", params: models.SearchParams{MinDuration: 0, MaxDuration: 1}},
+		{name: "duration range", params: models.SearchParams{MinDuration: 3, MaxDuration: 7}},
+		{name: "min duration", params: models.SearchParams{MinDuration: 8}},
+		{name: "huge max duration", params: models.SearchParams{MaxDuration: 1000}},
+		{name: "earliest departure on a minute", params: models.SearchParams{EarliestDepartureDate: at("2025-08-14T22:00:00Z")}},
+		{name: "earliest departure between minutes", params: models.SearchParams{EarliestDepartureDate: at("2025-08-14T21:59:30Z")}},
+		{name: "earliest departure one nanosecond later", params: models.SearchParams{EarliestDepartureDate: at("2025-08-14T22:00:00.000000001Z")}},
+		{name: "flex days between minutes", params: models.SearchParams{EarliestDepartureDate: at("2025-08-13T22:00:00.5Z"), FlexDays: 1}},
+		{name: "flex days", params: models.SearchParams{EarliestDepartureDate: at("2025-08-13T22:00:00Z"), FlexDays: 1}},
+		{name: "flex days before data", params: models.SearchParams{EarliestDepartureDate: at("1970-01-01T00:00:00Z"), FlexDays: 1}},
+		{name: "latest return end of day", params: models.SearchParams{LatestReturnDate: at("2025-08-21T21:59:59.999999999Z")}},
+		{name: "latest return on a minute", params: models.SearchParams{LatestReturnDate: at("2025-08-21T22:03:00Z")}},
+		{name: "latest return before epoch", params: models.SearchParams{LatestReturnDate: at("1969-12-31T23:59:59Z")}},
+		{name: "meal type", params: models.SearchParams{MealTypes: []string{"halfboard"}}},
+		{name: "empty meal type", params: models.SearchParams{MealTypes: []string{""}}},
+		{name: "room type", params: models.SearchParams{RoomTypes: []string{"suite", "single"}}},
+		{name: "ocean view", params: models.SearchParams{OceanView: &yes}},
+		{name: "no ocean view", params: models.SearchParams{OceanView: &no}},
+		{name: "min price on a row", params: models.SearchParams{MinPrice: 99.99}},
+		{name: "max price on a row", params: models.SearchParams{MaxPrice: 100}},
+		{name: "max price between cents", params: models.SearchParams{MaxPrice: 100.005}},
+		{name: "min price between cents", params: models.SearchParams{MinPrice: 99.995}},
+		// bounds whose float value lies just below or above the cent (2.3*100 = 229.99999999999997)
+		{name: "inexact max price", params: models.SearchParams{MaxPrice: 2.30}},
+		{name: "inexact min price", params: models.SearchParams{MinPrice: 2.30}},
+		{name: "inexact max price small", params: models.SearchParams{MaxPrice: 0.07}},
+		{name: "inexact min price small", params: models.SearchParams{MinPrice: 0.07}},
+		{name: "inexact price range", params: models.SearchParams{MinPrice: 1.15, MaxPrice: 1.15}},
+		{name: "inexact price per person", params: models.SearchParams{MinPricePerPerson: 1.15, MaxPricePerPerson: 1.15}},
+		{name: "inexact price per night", params: models.SearchParams{MinPricePerNight: 0.07, MaxPricePerNight: 2.30}},
+		{name: "price range on rows", params: models.SearchParams{MinPrice: 19.99, MaxPrice: 450.5}},
+		{name: "empty price range", params: models.SearchParams{MinPrice: 100.002, MaxPrice: 100.008}},
+		{name: "price per person", params: models.SearchParams{MinPricePerPerson: 33.33, MaxPricePerPerson: 50}},
+		{name: "price per person on a row", params: models.SearchParams{MaxPricePerPerson: 49.995}},
+		{name: "price per night", params: models.SearchParams{MinPricePerNight: 14.285, MaxPricePerNight: 150.17}},
+		{name: "price per night on a row", params: models.SearchParams{MaxPricePerNight: 33.33}},
+		{name: "combined", params: models.SearchParams{
+			DepartureAirports: []string{"FRA"}, EarliestDepartureDate: at("2025-08-14T22:00:00Z"),
+			LatestReturnDate: at("2025-08-29T21:59:59Z"), CountAdults: 2, MinDuration: 3, MaxDuration: 14,
+			MealTypes: []string{"halfboard"}, OceanView: &yes, MaxPrice: 1000, MaxPricePerPerson: 250,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, possible := s.compileFilter(tt.params)
+			r := s.ranges[1]
+			for i := r.start; i < r.end; i++ {
+				o := s.decodeRow(1, i)
+				want := o.Matches(tt.params)
+				got := possible && f.match(&s.cols, i)
+				if got != want {
+					t.Errorf("row %+v: memFilter %v, Offer.Matches %v", o, got, want)
+				}
+			}
+			// narrow may only drop rows that do not match
+			if possible {
+				nr := f.narrow(&s.cols, r)
+				for i := r.start; i < r.end; i++ {
+					if (i < nr.start || i >= nr.end) && f.match(&s.cols, i) {
+						t.Errorf("narrow dropped matching row %d", i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBuildKeepsCheapestRowPerOfferID(t *testing.T) {
+	s := NewMemoryStorage([]models.Hotel{{ID: 1, Name: "Test", Stars: 4}})
+	same := testOffer(1, 300, "breakfast")
+	cheaper, pricier := same, same
+	cheaper.Price, pricier.Price = 250, 280
+	other := testOffer(1, 100, "breakfast")
+	s.AddOffers([]models.Offer{same, cheaper, pricier, other})
+	s.Build()
+
+	offers, err := s.GetOffersByHotel(context.Background(), 1, models.SearchParams{})
+	if err != nil {
+		t.Fatalf("offers: %v", err)
+	}
+	var prices []float64
+	for _, o := range offers {
+		prices = append(prices, o.Price)
+	}
+	if !reflect.DeepEqual(prices, []float64{100, 250}) {
+		t.Errorf("prices %v, want [100 250]: one row per offer id, the cheapest", prices)
+	}
+	o, err := s.GetOffer(context.Background(), same.StableID())
+	if err != nil {
+		t.Fatalf("get offer: %v", err)
+	}
+	if o.Price != 250 {
+		t.Errorf("offer %s costs %v, want the cheapest row 250", o.ID, o.Price)
+	}
+	stats, err := s.GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if stats["duplicate_offers"] != 2 || stats["offers"] != 2 {
+		t.Errorf("stats %v, want 2 offers and 2 duplicate_offers", stats)
+	}
+}
+
+func TestMemoryGetOffer(t *testing.T) {
+	s := filterTestStorage()
+	ctx := context.Background()
+	r := s.ranges[1]
+	for i := r.start; i < r.end; i++ {
+		want := s.offerAt(1, i)
+		// the fingerprint column agrees with fingerprinting the decoded row
+		if want.ID != want.StableID() {
+			t.Fatalf("row %d: id %s, want %s", i, want.ID, want.StableID())
+		}
+		got, err := s.GetOffer(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("get %s: %v", want.ID, err)
+		}
+		if !reflect.DeepEqual(*got, want) {
+			t.Fatalf("get %s: %+v, want %+v", want.ID, *got, want)
+		}
+	}
+
+	o := s.offerAt(1, r.start)
+	for _, id := range []string{
+		models.FormatOfferID(1, s.cols.fingerprint[r.start]+1),
+		models.FormatOfferID(2, s.cols.fingerprint[r.start]),
+		"not-an-id",
+		o.ID + "0",
+	} {
+		if _, err := s.GetOffer(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("get %s: got %v, want ErrNotFound", id, err)
+		}
+	}
+}
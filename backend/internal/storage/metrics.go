@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyRecorder keeps the most recent search durations in a ring buffer
+// so that GetStats can report percentiles without an external metrics system.
+type latencyRecorder struct {
+	mu       sync.Mutex
+	samples  []time.Duration
+	next     int
+	full     bool
+	total    int64
+	failures int64
+}
+
+func newLatencyRecorder(size int) *latencyRecorder {
+	if size <= 0 {
+		size = 1024
+	}
+	return &latencyRecorder{samples: make([]time.Duration, size)}
+}
+
+// observe records one search; failed searches are counted but kept out of the percentiles
+func (r *latencyRecorder) observe(d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.total++
+	if err != nil {
+		r.failures++
+		return
+	}
+	r.samples[r.next] = d
+	r.next++
+	if r.next == len(r.samples) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// snapshot returns count, failures and p50/p95/p99/max in milliseconds over the retained window
+func (r *latencyRecorder) snapshot() map[string]interface{} {
+	r.mu.Lock()
+	n := r.next
+	if r.full {
+		n = len(r.samples)
+	}
+	window := make([]time.Duration, n)
+	copy(window, r.samples[:n])
+	total, failures := r.total, r.failures
+	r.mu.Unlock()
+
+	out := map[string]interface{}{
+		"count":    total,
+		"failures": failures,
+		"window":   n,
+	}
+	if n == 0 {
+		return out
+	}
+	sort.Slice(window, func(i, j int) bool { return window[i] < window[j] })
+	pct := func(p float64) float64 {
+		idx := int(p*float64(n)+0.5) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= n {
+			idx = n - 1
+		}
+		return float64(window[idx].Microseconds()) / 1000
+	}
+	out["p50_ms"] = pct(0.50)
+	out["p95_ms"] = pct(0.95)
+	out["p99_ms"] = pct(0.99)
+	out["max_ms"] = float64(window[n-1].Microseconds()) / 1000
+	return out
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"sort"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// MultiRoomBestOffers returns every hotel with a multi-room package for rooms (see
+// models.CombineRooms), its cheapest package as best offer and the number of packages as count.
+// Packages are built from scanRooms, which reads the candidate offers one hotel at a time.
+func MultiRoomBestOffers(ctx context.Context, s Storage, params models.SearchParams, rooms []models.Room) ([]models.HotelWithBestOffer, error) {
+	var results []models.HotelWithBestOffer
+	err := scanRooms(ctx, s, params, rooms, 0, func(hotel *models.Hotel, offers []models.Offer) {
+		packages := models.CombineRooms(offers, rooms, params)
+		if len(packages) == 0 {
+			return
+		}
+		results = append(results, models.HotelWithBestOffer{Hotel: *hotel, BestOffer: &packages[0], CountAvailableOffers: len(packages)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// MultiRoomOffersPage returns up to limit (0 = all) multi-room packages of a hotel in price order,
+// starting at cursor. The packages are rebuilt for every page; the total is exact.
+func MultiRoomOffersPage(ctx context.Context, s Storage, hotelID int, params models.SearchParams, rooms []models.Room, limit int, cursor string) (*models.OffersPage, error) {
+	roomsKey := models.FormatRooms(rooms)
+	c, err := decodeRoomsPageCursor(cursor, hotelID, roomsKey, params)
+	if err != nil {
+		return nil, err
+	}
+
+	packages := []models.Offer{}
+	err = scanRooms(ctx, s, params, rooms, hotelID, func(_ *models.Hotel, offers []models.Offer) {
+		packages = models.CombineRooms(offers, rooms, params)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.OffersPage{Items: []models.Offer{}, TotalEstimate: len(packages)}
+	if c.Skip >= len(packages) {
+		return page, nil
+	}
+	end := len(packages)
+	if limit > 0 && c.Skip+limit < end {
+		end = c.Skip + limit
+		page.NextCursor = encodePageCursor(pageCursor{HotelID: hotelID, Skip: end, Rooms: roomsKey, Filter: c.Filter})
+	}
+	page.Items = packages[c.Skip:end]
+	return page, nil
+}
+
+// scanRooms calls visit once per hotel (in id order) with the candidate offers of all rooms. Every
+// scan pins the travellers of one room party (see models.SearchParams.RoomScanParams) and stays
+// bounded where the backend requires it. For hotelID 0 a first scan of one party only collects the
+// candidate hotels; each of them is then scanned per party on its own, so at most one hotel's
+// offers are held at a time.
+func scanRooms(ctx context.Context, s Storage, params models.SearchParams, rooms []models.Room, hotelID int, visit func(hotel *models.Hotel, offers []models.Offer)) error {
+	var parties []models.Room
+	seen := make(map[models.Room]bool, len(rooms))
+	for _, r := range rooms {
+		if !seen[r] {
+			seen[r] = true
+			parties = append(parties, r)
+		}
+	}
+	if len(parties) == 0 {
+		return nil
+	}
+
+	ids := []int{hotelID}
+	if hotelID == 0 {
+		// a hotel without offers for the first party cannot hold a package
+		ids = ids[:0]
+		err := s.ScanOffers(ctx, params.RoomScanParams(parties[0]), 0, func(hotel *models.Hotel, _ []models.Offer) {
+			ids = append(ids, hotel.ID)
+		})
+		if err != nil {
+			return err
+		}
+		sort.Ints(ids)
+	}
+
+	var offers []models.Offer
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var hotel models.Hotel
+		offers = offers[:0]
+		complete := true
+		for _, r := range parties {
+			found := false
+			err := s.ScanOffers(ctx, params.RoomScanParams(r), id, func(h *models.Hotel, o []models.Offer) {
+				hotel, found = *h, true
+				offers = append(offers, o...)
+			})
+			if err != nil {
+				return err
+			}
+			if !found {
+				// no offer for this party, so no package either
+				complete = false
+				break
+			}
+		}
+		if complete {
+			visit(&hotel, offers)
+		}
+	}
+	return nil
+}
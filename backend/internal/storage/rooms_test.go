@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+// partyOffer is a 7-night offer of hotel for adults/children with departure at hour on 1 August
+func partyOffer(hotel, adults, children, hour int, price float64) models.Offer {
+	o := testOffer(hotel, price, "breakfast")
+	o.DepartureDate = time.Date(2025, 8, 1, hour, 0, 0, 0, time.UTC)
+	o.ReturnDate = o.DepartureDate.AddDate(0, 0, 7)
+	o.OutboundArrivalDateTime = o.DepartureDate.Add(2 * time.Hour)
+	o.InboundArrivalDateTime = o.ReturnDate.Add(2 * time.Hour)
+	o.CountAdults, o.CountChildren = adults, children
+	return o
+}
+
+func roomsTestStorage() *MemoryStorage {
+	s := NewMemoryStorage([]models.Hotel{
+		{ID: 1, Name: "Eins", Stars: 3},
+		{ID: 2, Name: "Zwei", Stars: 4},
+		{ID: 3, Name: "Drei", Stars: 5},
+		{ID: 4, Name: "Vier", Stars: 4},
+	})
+	s.AddOffers([]models.Offer{
+		// one package
+		partyOffer(1, 2, 0, 6, 500), partyOffer(1, 2, 1, 6, 700),
+		// no offer for the second room
+		partyOffer(2, 2, 0, 6, 400),
+		// two flights, two packages; the 2:0 room at 9 o'clock has no partner
+		partyOffer(3, 2, 0, 6, 900), partyOffer(3, 2, 1, 6, 1000),
+		partyOffer(3, 2, 0, 8, 600), partyOffer(3, 2, 1, 8, 800),
+		partyOffer(3, 2, 0, 9, 100),
+		// no offer for the first room
+		partyOffer(4, 2, 1, 6, 300),
+	})
+	s.Build()
+	return s
+}
+
+func TestMultiRoomBestOffers(t *testing.T) {
+	s := roomsTestStorage()
+	rooms := []models.Room{{Adults: 2}, {Adults: 2, Children: 1}}
+
+	tests := []struct {
+		name   string
+		params models.SearchParams
+		rooms  []models.Room
+		// want maps hotel id to best package price and package count, in hotel id order
+		want [][3]float64
+	}{
+		{name: "two rooms", rooms: rooms, want: [][3]float64{{1, 1200, 1}, {3, 1400, 2}}},
+		{name: "room order", rooms: []models.Room{{Adults: 2, Children: 1}, {Adults: 2}}, want: [][3]float64{{1, 1200, 1}, {3, 1400, 2}}},
+		{name: "package price filter", rooms: rooms, params: models.SearchParams{MinPrice: 1300}, want: [][3]float64{{3, 1400, 2}}},
+		{name: "hotel filter", rooms: rooms, params: models.SearchParams{MinStars: 4}, want: [][3]float64{{3, 1400, 2}}},
+		{name: "one room", rooms: []models.Room{{Adults: 2}}, want: [][3]float64{{1, 500, 1}, {2, 400, 1}, {3, 100, 3}}},
+		{name: "identical rooms", rooms: []models.Room{{Adults: 2}, {Adults: 2}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := MultiRoomBestOffers(context.Background(), s, tt.params, tt.rooms)
+			if err != nil {
+				t.Fatalf("best offers: %v", err)
+			}
+			var got [][3]float64
+			for _, r := range results {
+				got = append(got, [3]float64{float64(r.Hotel.ID), r.BestOffer.Price, float64(r.CountAvailableOffers)})
+				if len(r.BestOffer.Rooms) != len(tt.rooms) {
+					t.Errorf("hotel %d: %d rooms, want %d", r.Hotel.ID, len(r.BestOffer.Rooms), len(tt.rooms))
+				}
+				for _, o := range r.BestOffer.Rooms {
+					if o.HotelID != r.Hotel.ID {
+						t.Errorf("hotel %d: package holds an offer of hotel %d", r.Hotel.ID, o.HotelID)
+					}
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiRoomOffersPage(t *testing.T) {
+	s := roomsTestStorage()
+	rooms := []models.Room{{Adults: 2}, {Adults: 2, Children: 1}}
+
+	first, err := MultiRoomOffersPage(context.Background(), s, 3, models.SearchParams{}, rooms, 1, "")
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if first.TotalEstimate != 2 || len(first.Items) != 1 || first.Items[0].Price != 1400 || first.NextCursor == "" {
+		t.Fatalf("first page %+v, want the 1400 package of 2 and a cursor", first)
+	}
+	second, err := MultiRoomOffersPage(context.Background(), s, 3, models.SearchParams{}, rooms, 1, first.NextCursor)
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	if len(second.Items) != 1 || second.Items[0].Price != 1900 || second.NextCursor != "" {
+		t.Errorf("second page %+v, want the last package 1900", second)
+	}
+
+	empty, err := MultiRoomOffersPage(context.Background(), s, 4, models.SearchParams{}, rooms, 1, "")
+	if err != nil {
+		t.Fatalf("hotel without package: %v", err)
+	}
+	if empty.TotalEstimate != 0 || len(empty.Items) != 0 {
+		t.Errorf("hotel 4 page %+v, want no packages", empty)
+	}
+}
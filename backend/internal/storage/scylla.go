@@ -1,8 +1,11 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"sort"
 	"strconv"
@@ -72,17 +75,6 @@ func getEnvInt(key string, def int) int {
 	return def
 }
 
-// Storage defines the methods our handlers need. Implemented by ScyllaStorage.
-type Storage interface {
-	GetHotelsWithBestOffers(params models.SearchParams) []models.HotelWithBestOffer
-	GetOffersByHotel(hotelID int, params models.SearchParams) []models.Offer
-	GetHotel(hotelID int) (*models.Hotel, bool)
-	GetAllHotels() []models.Hotel
-	GetStats() map[string]interface{}
-	// GetAvailableDepartureAirports returns unique outbound departure airport codes across all offers
-	GetAvailableDepartureAirports() []string
-}
-
 // ScyllaStorage implements Storage backed by ScyllaDB.
 type ScyllaStorage struct {
 	session *gocql.Session
@@ -92,38 +84,78 @@ type ScyllaStorage struct {
 	airportsCacheAt    time.Time
 	airportsCacheTTL   time.Duration
 	airportsCacheMutex sync.RWMutex
+
+	// best-offer search fan-out
+	searchParallel int
+	searchTimeout  time.Duration
+	searchLatency  *latencyRecorder
+	// countLimit caps the offers counted per hotel; 0 counts all (reads every matching row)
+	countLimit int
+
+	// offers_by_search partition catalog (see scylla_search.go)
+	searchTables searchTableCatalog
+	// offer_rollups availability (see scylla_rollups.go)
+	rollups rollupState
+	// loc is LOCAL_TIMEZONE; rollup weeks start on Mondays in loc (see models.DepartureWeek)
+	loc *time.Location
 }
 
-func NewScyllaStorage(session *gocql.Session) *ScyllaStorage {
-	s := &ScyllaStorage{session: session}
+// NewScyllaStorage creates the Scylla storage; loc must be the LOCAL_TIMEZONE the offers were
+// imported with, as it defines the rollup weeks.
+func NewScyllaStorage(session *gocql.Session, loc *time.Location) *ScyllaStorage {
+	s := &ScyllaStorage{session: session, loc: loc}
 	// configure TTL via env, default 1h
 	ttl := getEnvInt("AIRPORTS_CACHE_TTL_MINUTES", 60)
 	s.airportsCacheTTL = time.Duration(ttl) * time.Minute
 	log.Printf("airports: cache TTL=%s, scan parallelism=%d", s.airportsCacheTTL, getEnvInt("AIRPORTS_SCAN_PARALLEL", 8))
+	// search fan-out: parallel partition scans, bounded by a per-search deadline
+	s.searchParallel = getEnvInt("SEARCH_SCAN_PARALLEL", 16)
+	s.searchTimeout = time.Duration(getEnvInt("SEARCH_TIMEOUT_MS", 5000)) * time.Millisecond
+	s.searchLatency = newLatencyRecorder(getEnvInt("SEARCH_LATENCY_WINDOW", 1024))
+	s.countLimit = max(getEnvInt("SEARCH_COUNT_LIMIT", 1000), 0)
+	log.Printf("search: scan parallelism=%d, timeout=%s, count limit=%d", s.searchParallel, s.searchTimeout, s.countLimit)
+	s.searchTables.enabled = !strings.EqualFold(getEnv("SCYLLA_SEARCH_TABLES", "true"), "false")
+	s.searchTables.ttl = time.Duration(getEnvInt("SEARCH_TABLES_CACHE_TTL_MINUTES", 60)) * time.Minute
+	s.rollups.enabled = !strings.EqualFold(getEnv("SCYLLA_ROLLUPS", "true"), "false")
+	if s.rollups.enabled {
+		go s.probeRollups(context.Background())
+	}
+	if s.searchTables.enabled {
+		go func() {
+			if err := s.refreshSearchTableCatalog(context.Background()); err != nil {
+				log.Printf("search tables: warm-up failed, falling back to hotel partitions: %v", err)
+			}
+		}()
+	}
 	// warm cache in background (non-blocking) on startup
 	log.Printf("airports: starting background warm-up")
 	go func() {
-		_ = s.refreshAirportsCache()
+		if _, err := s.refreshAirportsCache(context.Background()); err != nil {
+			log.Printf("airports: warm-up failed: %v", err)
+		}
 	}()
 	return s
 }
 
 // GetHotel returns a hotel by ID
-func (s *ScyllaStorage) GetHotel(hotelID int) (*models.Hotel, bool) {
+func (s *ScyllaStorage) GetHotel(ctx context.Context, hotelID int) (*models.Hotel, error) {
 	var h models.Hotel
 	var starsF32 float32
 	q := `SELECT hotelid, hotelname, hotelstars FROM hotels WHERE hotelid = ?`
-	if err := s.session.Query(q, hotelID).Consistency(gocql.One).Scan(&h.ID, &h.Name, &starsF32); err != nil {
-		return nil, false
+	if err := s.session.Query(q, hotelID).WithContext(ctx).Consistency(gocql.One).Scan(&h.ID, &h.Name, &starsF32); err != nil {
+		if errors.Is(err, gocql.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, classifyErr(fmt.Errorf("get hotel %d: %w", hotelID, err))
 	}
 	h.Stars = float64(starsF32)
-	return &h, true
+	return &h, nil
 }
 
 // GetAllHotels returns all hotels distinct (small table)
-func (s *ScyllaStorage) GetAllHotels() []models.Hotel {
+func (s *ScyllaStorage) GetAllHotels(ctx context.Context) ([]models.Hotel, error) {
 	q := `SELECT hotelid, hotelname, hotelstars FROM hotels`
-	iter := s.session.Query(q).Consistency(gocql.One).Iter()
+	iter := s.session.Query(q).WithContext(ctx).Consistency(gocql.One).Iter()
 	var (
 		id       int
 		name     string
@@ -133,16 +165,18 @@ func (s *ScyllaStorage) GetAllHotels() []models.Hotel {
 	for iter.Scan(&id, &name, &starsF32) {
 		res = append(res, models.Hotel{ID: id, Name: name, Stars: float64(starsF32)})
 	}
-	_ = iter.Close()
+	if err := iter.Close(); err != nil {
+		return nil, classifyErr(fmt.Errorf("list hotels: %w", err))
+	}
 	// keep deterministic order
 	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
-	return res
+	return res, nil
 }
 
 // GetOffersByHotel fetches offers for a hotel and applies filters client-side for non-key attrs
-func (s *ScyllaStorage) GetOffersByHotel(hotelID int, params models.SearchParams) []models.Offer {
-	// Base: partition by hotel, rely on clustering by price ASC
-	iter := s.offersIterByHotel(hotelID)
+func (s *ScyllaStorage) GetOffersByHotel(ctx context.Context, hotelID int, params models.SearchParams) ([]models.Offer, error) {
+	// Base: partition by hotel, rely on clustering by price ASC; a price range narrows the clustering slice
+	iter := s.offersIterByHotelFor(ctx, hotelID, params)
 	var (
 		o   models.Offer
 		res []models.Offer
@@ -158,68 +192,405 @@ func (s *ScyllaStorage) GetOffersByHotel(hotelID int, params models.SearchParams
 			res = append(res, o)
 		}
 	}
-	_ = iter.Close()
-	return res
+	if err := iter.Close(); err != nil {
+		return nil, classifyErr(fmt.Errorf("scan offers of hotel %d: %w", hotelID, err))
+	}
+	return res, nil
 }
 
-// GetHotelsWithBestOffers returns hotels with their cheapest matching offer
-func (s *ScyllaStorage) GetHotelsWithBestOffers(params models.SearchParams) []models.HotelWithBestOffer {
-	hotels := s.GetAllHotels()
-	println("Found", len(hotels), "hotels, searching for best offers...")
-	results := make([]models.HotelWithBestOffer, 0, len(hotels))
-	for _, h := range hotels {
-		// Scan partition ordered by price (clustering), stop at first match
-		iter := s.offersIterByHotel(h.ID)
+// GetOffersPageByHotel returns up to limit matching offers of a hotel, resuming at cursor.
+// Offers are filtered client-side, so a Scylla page may hold more or fewer matches than limit;
+// the cursor therefore carries the page state plus the number of rows already consumed from it.
+// If more pages follow, the first page extrapolates the partition's row count by the match ratio
+// of its scanned rows; the cursor carries that TotalEstimate, so the partition is counted once.
+func (s *ScyllaStorage) GetOffersPageByHotel(ctx context.Context, hotelID int, params models.SearchParams, limit int, cursor string) (*models.OffersPage, error) {
+	cur, err := decodePageCursor(cursor, hotelID, params)
+	if err != nil {
+		return nil, err
+	}
+	return collectOffersPage(params, limit, cur, func(state []byte) ([]models.Offer, []byte, error) {
+		iter := s.offersQueryByHotelFor(ctx, hotelID, params).PageSize(offersPageSize).PageState(state).Iter()
+		var rows []models.Offer
+		for {
+			o, ok := scanOffer(iter)
+			if !ok {
+				break
+			}
+			rows = append(rows, o)
+		}
+		next := iter.PageState()
+		if err := iter.Close(); err != nil {
+			return nil, nil, classifyErr(fmt.Errorf("scan offers of hotel %d: %w", hotelID, err))
+		}
+		return rows, next, nil
+	}, func(scanned, matched int) (int, error) {
+		cond, args := priceRestriction(params)
+		var rows int
+		if err := s.session.Query(`SELECT COUNT(*) FROM offers WHERE hotelid = ?`+cond, append([]interface{}{hotelID}, args...)...).
+			WithContext(ctx).Consistency(gocql.One).Scan(&rows); err != nil {
+			return 0, classifyErr(fmt.Errorf("count offers of hotel %d: %w", hotelID, err))
+		}
+		if scanned == 0 {
+			return 0, nil
+		}
+		return int(math.Round(float64(rows) * float64(matched) / float64(scanned))), nil
+	})
+}
+
+// GetOffer resolves an offer id. offerid is the last clustering column, so the lookup filters
+// within the single hotel partition.
+func (s *ScyllaStorage) GetOffer(ctx context.Context, offerID string) (*models.Offer, error) {
+	hotelID, fingerprint, err := models.ParseOfferID(offerID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	// offer_ids names the current row of the offer; rows left behind by a repricing are ignored
+	var (
+		price  float64
+		outDep time.Time
+	)
+	err = s.session.Query(`SELECT price, outbounddeparturedatetime FROM offer_ids WHERE hotelid = ? AND offerid = ?`, hotelID, fingerprint).
+		WithContext(ctx).Consistency(gocql.One).Scan(&price, &outDep)
+	switch {
+	case errors.Is(err, gocql.ErrNotFound), err != nil && strings.Contains(strings.ToLower(err.Error()), "unconfigured table"):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, classifyErr(fmt.Errorf("get offer %s: %w", offerID, err))
+	}
+	iter := s.session.Query(offersSelect+` AND price = ? AND outbounddeparturedatetime = ? AND offerid = ?`, hotelID, price, outDep, fingerprint).
+		WithContext(ctx).Consistency(gocql.One).Iter()
+	offer, ok := scanOffer(iter)
+	if err := iter.Close(); err != nil {
+		return nil, classifyErr(fmt.Errorf("get offer %s: %w", offerID, err))
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &offer, nil
+}
+
+// GetHotelsWithBestOffers returns hotels with their cheapest matching offer.
+// Hotel partitions are scanned by a bounded worker pool (SEARCH_SCAN_PARALLEL); the whole
+// search is bounded by SEARCH_TIMEOUT_MS and stops as soon as ctx is cancelled.
+func (s *ScyllaStorage) GetHotelsWithBestOffers(ctx context.Context, params models.SearchParams) (results []models.HotelWithBestOffer, err error) {
+	start := time.Now()
+	defer func() { s.searchLatency.observe(time.Since(start), err) }()
+	ctx, cancel := context.WithTimeout(ctx, s.searchTimeout)
+	defer cancel()
+
+	hotels, err := s.GetAllHotels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hotels = filterHotels(hotels, params)
+	hotelsTook := time.Since(start)
+
+	// best[i] holds the cheapest match of hotels[i], counts[i] its number of matches
+	var (
+		best   []*models.Offer
+		counts []int
+	)
+	strategy := "hotel partitions"
+	if version, ok := s.rollupVersion(ctx, params); ok {
+		strategy = "rollups"
+		best, counts, err = s.bestOffersFromRollups(ctx, hotels, version, params)
+	} else if parts, ok := s.searchTablePartitions(ctx, params); ok {
+		strategy = fmt.Sprintf("search table (%d partitions)", len(parts))
+		best, counts, err = s.bestOffersFromSearchTable(ctx, hotels, parts, params)
+	} else {
+		best, counts, err = s.bestOffersFromHotelPartitions(ctx, hotels, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results = make([]models.HotelWithBestOffer, 0, len(hotels))
+	for i, offer := range best {
+		if offer == nil {
+			continue
+		}
+		results = append(results, models.HotelWithBestOffer{
+			Hotel:                hotels[i],
+			BestOffer:            offer,
+			CountAvailableOffers: counts[i],
+			CountCapped:          s.countCapped(counts[i]),
+		})
+	}
+
+	log.Printf("search: best offers in %s (hotels %s, scan %s via %s); hotels=%d, matched=%d, parallel=%d",
+		time.Since(start), hotelsTook, time.Since(start)-hotelsTook, strategy, len(hotels), len(results), s.searchParallel)
+	return results, nil
+}
+
+// countCapped reports whether counting stopped at the count limit, i.e. count is a lower bound
+func (s *ScyllaStorage) countCapped(count int) bool {
+	return s.countLimit > 0 && count >= s.countLimit
+}
+
+// bestOffersFromHotelPartitions scans the hotel partitions (ordered by price): the first match is
+// the hotel's best offer; the scan goes on only to count matches and stops at the count limit
+func (s *ScyllaStorage) bestOffersFromHotelPartitions(ctx context.Context, hotels []models.Hotel, params models.SearchParams) ([]*models.Offer, []int, error) {
+	// each worker writes only its own slot
+	best := make([]*models.Offer, len(hotels))
+	counts := make([]int, len(hotels))
+	err := forEachParallel(ctx, len(hotels), s.searchParallel, func(ctx context.Context, i int) error {
+		h := hotels[i]
+		iter := s.offersIterByHotelFor(ctx, h.ID, params)
 		for {
 			offer, ok := scanOffer(iter)
 			if !ok {
 				break
 			}
-			if offer.Matches(params) {
-				results = append(results, models.HotelWithBestOffer{Hotel: h, BestOffer: &offer})
+			if !offer.Matches(params) {
+				continue
+			}
+			if best[i] == nil {
+				o := offer
+				best[i] = &o
+			}
+			counts[i]++
+			if s.countCapped(counts[i]) {
 				break
 			}
 		}
-		_ = iter.Close()
+		if err := iter.Close(); err != nil {
+			return classifyErr(fmt.Errorf("scan offers of hotel %d: %w", h.ID, err))
+		}
+		return nil
+	})
+	return best, counts, err
+}
+
+// GetFacets derives the facets of the search from the offers_by_search partitions it needs (see
+// facetPartitions); searches that cannot be bounded that way fail with ErrUnboundedSearch instead
+// of reading every hotel partition. Hotels are not pre-filtered by stars, because the stars facet
+// excludes its own filter.
+func (s *ScyllaStorage) GetFacets(ctx context.Context, params models.SearchParams) (*models.SearchFacets, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, s.searchTimeout)
+	defer cancel()
+
+	hotels, err := s.GetAllHotels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parts, ok := s.facetPartitions(ctx, params)
+	if !ok {
+		return nil, ErrUnboundedSearch
+	}
+	index := make(map[int]*models.Hotel, len(hotels))
+	for i := range hotels {
+		index[hotels[i].ID] = &hotels[i]
 	}
 
-	println("Found", len(results), "hotels with best offers")
+	// a hotel's offers can span several partitions: merge its facets before counting it
+	var mu sync.Mutex
+	perHotel := make(map[int]*hotelFacets)
+	err = forEachParallel(ctx, len(parts), s.searchParallel, func(ctx context.Context, pi int) error {
+		p := parts[pi]
+		cond, args := priceRestriction(params)
+		iter := s.session.Query(offersBySearchSelect+cond, append([]interface{}{p.airport, p.adults, p.children, p.duration}, args...)...).
+			WithContext(ctx).Consistency(gocql.One).Iter()
+		local := make(map[int]*hotelFacets)
+		for {
+			offer, ok := scanOffer(iter)
+			if !ok {
+				break
+			}
+			h, known := index[offer.HotelID]
+			if !known {
+				continue
+			}
+			hf := local[h.ID]
+			if hf == nil {
+				hf = &hotelFacets{}
+				local[h.ID] = hf
+			}
+			addOfferFacets(hf, &offer, h, params)
+		}
+		if err := iter.Close(); err != nil {
+			return classifyErr(fmt.Errorf("scan offers_by_search %s/%d/%d/%d: %w", p.airport, p.adults, p.children, p.duration, err))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for id, hf := range local {
+			if cur := perHotel[id]; cur != nil {
+				cur.mergeFrom(hf)
+			} else {
+				perHotel[id] = hf
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var collector facetCollector
+	for _, hf := range perHotel {
+		collector.merge(hf)
+	}
+	log.Printf("scylla: facets took %s; partitions=%d, hotels=%d", time.Since(start), len(parts), len(perHotel))
+	return collector.result(), nil
+}
+
+// ScanOffers reads the matching offers of one hotel partition, or for hotelID 0 the
+// offers_by_search partitions covering params (see searchTablePartitions); a scan over all hotels
+// that cannot be bounded that way fails with ErrUnboundedSearch instead of reading every offer.
+func (s *ScyllaStorage) ScanOffers(ctx context.Context, params models.SearchParams, hotelID int, visit func(hotel *models.Hotel, offers []models.Offer)) error {
+	ctx, cancel := context.WithTimeout(ctx, s.searchTimeout)
+	defer cancel()
 
-	// sort by cheapest price
-	sort.Slice(results, func(i, j int) bool {
-		if results[i].BestOffer == nil {
-			return false
+	if hotelID == 0 {
+		return s.scanSearchTable(ctx, params, visit)
+	}
+	h, err := s.GetHotel(ctx, hotelID)
+	if err != nil {
+		return err
+	}
+	if !h.Matches(params) {
+		return nil
+	}
+	iter := s.offersIterByHotelFor(ctx, h.ID, params)
+	var offers []models.Offer
+	for {
+		offer, ok := scanOffer(iter)
+		if !ok {
+			break
 		}
-		if results[j].BestOffer == nil {
-			return true
+		if offer.Matches(params) {
+			offers = append(offers, offer)
 		}
-		return results[i].BestOffer.Price < results[j].BestOffer.Price
+	}
+	if err := iter.Close(); err != nil {
+		return classifyErr(fmt.Errorf("scan offers of hotel %d: %w", h.ID, err))
+	}
+	if len(offers) > 0 {
+		visit(h, offers)
+	}
+	return nil
+}
+
+// scanSearchTable is ScanOffers over all hotels: the offers_by_search partitions are read in
+// parallel, then each hotel's matches (possibly from several partitions) are visited in price order
+func (s *ScyllaStorage) scanSearchTable(ctx context.Context, params models.SearchParams, visit func(hotel *models.Hotel, offers []models.Offer)) error {
+	parts, ok := s.searchTablePartitions(ctx, params)
+	if !ok {
+		return ErrUnboundedSearch
+	}
+	all, err := s.GetAllHotels(ctx)
+	if err != nil {
+		return err
+	}
+	hotels := filterHotels(all, params)
+	index := make(map[int]int, len(hotels))
+	for i, h := range hotels {
+		index[h.ID] = i
+	}
+
+	var mu sync.Mutex
+	offers := make([][]models.Offer, len(hotels))
+	err = forEachParallel(ctx, len(parts), s.searchParallel, func(ctx context.Context, pi int) error {
+		p := parts[pi]
+		cond, args := priceRestriction(params)
+		iter := s.session.Query(offersBySearchSelect+cond, append([]interface{}{p.airport, p.adults, p.children, p.duration}, args...)...).
+			WithContext(ctx).Consistency(gocql.One).Iter()
+		local := make(map[int][]models.Offer)
+		for {
+			offer, ok := scanOffer(iter)
+			if !ok {
+				break
+			}
+			if hi, known := index[offer.HotelID]; known && offer.Matches(params) {
+				local[hi] = append(local[hi], offer)
+			}
+		}
+		if err := iter.Close(); err != nil {
+			return classifyErr(fmt.Errorf("scan offers_by_search %s/%d/%d/%d: %w", p.airport, p.adults, p.children, p.duration, err))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for hi, matches := range local {
+			offers[hi] = append(offers[hi], matches...)
+		}
+		return nil
 	})
-	return results
+	if err != nil {
+		return err
+	}
+	for hi := range hotels {
+		if len(offers[hi]) == 0 {
+			continue
+		}
+		sort.SliceStable(offers[hi], func(a, b int) bool {
+			oa, ob := &offers[hi][a], &offers[hi][b]
+			if oa.Price != ob.Price {
+				return oa.Price < ob.Price
+			}
+			return oa.DepartureDate.Before(ob.DepartureDate)
+		})
+		visit(&hotels[hi], offers[hi])
+	}
+	return nil
+}
+
+// DataVersion reads the completion time of the last offers import from import_status.
+// Keyspaces without that table (never imported with a current importer) report zero.
+func (s *ScyllaStorage) DataVersion(ctx context.Context) (time.Time, error) {
+	var finished time.Time
+	err := s.session.Query(`SELECT finishedat FROM import_status WHERE name = 'offers'`).WithContext(ctx).Consistency(gocql.One).Scan(&finished)
+	switch {
+	case err == nil:
+		return finished, nil
+	case errors.Is(err, gocql.ErrNotFound), strings.Contains(strings.ToLower(err.Error()), "unconfigured table"):
+		return time.Time{}, nil
+	}
+	return time.Time{}, classifyErr(fmt.Errorf("read import status: %w", err))
 }
 
 // GetStats returns simple stats. Note: COUNT(*) on large tables can be expensive.
-func (s *ScyllaStorage) GetStats() map[string]interface{} {
+func (s *ScyllaStorage) GetStats(ctx context.Context) (map[string]interface{}, error) {
 	stats := map[string]interface{}{}
 	var hotelsCount int64
-	if err := s.session.Query(`SELECT COUNT(*) FROM hotels`).Scan(&hotelsCount); err == nil {
-		stats["hotels"] = hotelsCount
+	if err := s.session.Query(`SELECT COUNT(*) FROM hotels`).WithContext(ctx).Scan(&hotelsCount); err != nil {
+		return nil, classifyErr(fmt.Errorf("count hotels: %w", err))
 	}
+	stats["hotels"] = hotelsCount
+	// COUNT(*) over ~90M offers regularly exceeds the server timeout; report it as unavailable instead of failing
 	var offersCount int64
-	if err := s.session.Query(`SELECT COUNT(*) FROM offers`).Scan(&offersCount); err == nil {
+	if err := s.session.Query(`SELECT COUNT(*) FROM offers`).WithContext(ctx).Scan(&offersCount); err == nil {
 		stats["offers"] = offersCount
+	} else if ctx.Err() != nil {
+		return nil, ctx.Err()
+	} else {
+		log.Printf("stats: count offers failed: %v", err)
 	}
 	// hotels_with_offers: cheap per-partition existence check
-	hotels := s.GetAllHotels()
+	hotels, err := s.GetAllHotels(ctx)
+	if err != nil {
+		return nil, err
+	}
 	withOffers := 0
 	for _, h := range hotels {
 		var price float64
-		if err := s.session.Query(`SELECT price FROM offers WHERE hotelid = ? LIMIT 1`, h.ID).Consistency(gocql.One).Scan(&price); err == nil {
+		err := s.session.Query(`SELECT price FROM offers WHERE hotelid = ? LIMIT 1`, h.ID).WithContext(ctx).Consistency(gocql.One).Scan(&price)
+		switch {
+		case err == nil:
 			withOffers++
+		case !errors.Is(err, gocql.ErrNotFound):
+			return nil, classifyErr(fmt.Errorf("probe offers of hotel %d: %w", h.ID, err))
 		}
 	}
 	stats["hotels_with_offers"] = withOffers
-	return stats
+	// rows the last import dropped for a cheaper row with the same offer id (see cmd/import-offers)
+	var duplicates int64
+	if err := s.session.Query(`SELECT rowcount FROM import_status WHERE name = 'offer_duplicates'`).WithContext(ctx).
+		Consistency(gocql.One).Scan(&duplicates); err == nil {
+		stats["duplicate_offers"] = duplicates
+	} else if !errors.Is(err, gocql.ErrNotFound) && !strings.Contains(strings.ToLower(err.Error()), "unconfigured table") {
+		return nil, classifyErr(fmt.Errorf("read import_status: %w", err))
+	}
+	stats["search_latency"] = s.searchLatency.snapshot()
+	return stats, nil
 }
 
 // --- Helpers & small utilities ---
@@ -228,13 +599,52 @@ func (s *ScyllaStorage) GetStats() map[string]interface{} {
 const offersSelect = `SELECT hotelid, outbounddeparturedatetime, inbounddeparturedatetime, countadults, countchildren, price, inbounddepartureairport, inboundarrivalairport, inboundarrivaldatetime, outbounddepartureairport, outboundarrivalairport, outboundarrivaldatetime, mealtype, oceanview, roomtype FROM offers WHERE hotelid = ?`
 
 // offersIterByHotel creates an iterator over offers for a given hotel id with consistent settings
-func (s *ScyllaStorage) offersIterByHotel(hotelID int) *gocql.Iter {
-	return s.session.Query(offersSelect, hotelID).Consistency(gocql.One).Iter()
+func (s *ScyllaStorage) offersIterByHotel(ctx context.Context, hotelID int) *gocql.Iter {
+	return s.session.Query(offersSelect, hotelID).WithContext(ctx).Consistency(gocql.One).Iter()
+}
+
+// offersIterByHotelFor is offersIterByHotel restricted to the price range of params
+func (s *ScyllaStorage) offersIterByHotelFor(ctx context.Context, hotelID int, params models.SearchParams) *gocql.Iter {
+	return s.offersQueryByHotelFor(ctx, hotelID, params).Iter()
+}
+
+// offersQueryByHotelFor builds the query behind offersIterByHotelFor, e.g. for manual paging
+func (s *ScyllaStorage) offersQueryByHotelFor(ctx context.Context, hotelID int, params models.SearchParams) *gocql.Query {
+	cond, args := priceRestriction(params)
+	return s.session.Query(offersSelect+cond, append([]interface{}{hotelID}, args...)...).WithContext(ctx).Consistency(gocql.One)
+}
+
+// priceRestriction turns the price range of params into a CQL slice on the price clustering column
+func priceRestriction(params models.SearchParams) (string, []interface{}) {
+	var (
+		cond string
+		args []interface{}
+	)
+	if params.MinPrice > 0 {
+		cond += ` AND price >= ?`
+		args = append(args, params.MinPrice)
+	}
+	if params.MaxPrice > 0 {
+		cond += ` AND price <= ?`
+		args = append(args, params.MaxPrice)
+	}
+	return cond, args
+}
+
+// filterHotels drops hotels that fail the hotel-level filters of params (minimum stars)
+func filterHotels(hotels []models.Hotel, params models.SearchParams) []models.Hotel {
+	out := hotels[:0:0]
+	for i := range hotels {
+		if hotels[i].Matches(params) {
+			out = append(out, hotels[i])
+		}
+	}
+	return out
 }
 
 // GetAvailableDepartureAirports collects distinct outbound departure airport codes from all offers.
 // Caches result in-memory with TTL and warms it on startup. Uses minimal projection and parallel scan per hotel.
-func (s *ScyllaStorage) GetAvailableDepartureAirports() []string {
+func (s *ScyllaStorage) GetAvailableDepartureAirports(ctx context.Context) ([]string, error) {
 	// fast path: valid cache
 	s.airportsCacheMutex.RLock()
 	cached := s.airportsCache
@@ -246,41 +656,50 @@ func (s *ScyllaStorage) GetAvailableDepartureAirports() []string {
 		// return a copy to avoid external mutation and ensure non-nil
 		out := make([]string, len(cached))
 		copy(out, cached)
-		return out
+		return out, nil
 	}
 
 	// refresh cache (synchronously for first call or expired)
-	if res := s.refreshAirportsCache(); len(res) > 0 {
-		return res
+	res, err := s.refreshAirportsCache(ctx)
+	if err == nil && len(res) > 0 {
+		return res, nil
 	}
-	// fallback to previous cached slice (may be empty)
+	// fallback to previous cached slice; without one the refresh error is all we have
 	if len(cached) == 0 {
-		return []string{}
+		if err != nil {
+			return nil, err
+		}
+		return []string{}, nil
+	}
+	if err != nil {
+		log.Printf("airports: refresh failed, serving stale cache: %v", err)
 	}
 	out := make([]string, len(cached))
 	copy(out, cached)
-	return out
+	return out, nil
 }
 
 // refreshAirportsCache recomputes the airports list and updates the cache. Returns the fresh list.
-func (s *ScyllaStorage) refreshAirportsCache() []string {
+// On error the cache is left untouched.
+func (s *ScyllaStorage) refreshAirportsCache(ctx context.Context) ([]string, error) {
 	start := time.Now()
 	// gather hotels (small table)
-	hotels := s.GetAllHotels()
+	hotels, err := s.GetAllHotels(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if len(hotels) == 0 {
 		log.Printf("airports: no hotels found; cache set empty (took %s)", time.Since(start))
 		s.airportsCacheMutex.Lock()
 		s.airportsCache = []string{}
 		s.airportsCacheAt = time.Now()
 		s.airportsCacheMutex.Unlock()
-		return []string{}
+		return []string{}, nil
 	}
 
 	// parallel scan per hotel with bounded concurrency
 	maxParallel := getEnvInt("AIRPORTS_SCAN_PARALLEL", 8)
 	log.Printf("airports: refreshing cache; hotels=%d, parallel=%d", len(hotels), maxParallel)
-	sem := make(chan struct{}, maxParallel)
-	var wg sync.WaitGroup
 	set := make(map[string]struct{})
 	var mu sync.Mutex
 
@@ -288,27 +707,26 @@ func (s *ScyllaStorage) refreshAirportsCache() []string {
 	const q = `SELECT outbounddepartureairport FROM offers WHERE hotelid = ?`
 
 	usedFallback := false
-	for _, h := range hotels {
-		wg.Add(1)
-		sem <- struct{}{}
-		hotelID := h.ID
-		go func() {
-			defer wg.Done()
-			defer func() { <-sem }()
-			iter := s.session.Query(q, hotelID).Consistency(gocql.One).Iter()
-			var code string
-			for iter.Scan(&code) {
-				if code == "" {
-					continue
-				}
-				mu.Lock()
-				set[code] = struct{}{}
-				mu.Unlock()
+	err = forEachParallel(ctx, len(hotels), maxParallel, func(ctx context.Context, i int) error {
+		h := hotels[i]
+		iter := s.session.Query(q, h.ID).WithContext(ctx).Consistency(gocql.One).Iter()
+		var code string
+		for iter.Scan(&code) {
+			if code == "" {
+				continue
 			}
-			_ = iter.Close()
-		}()
+			mu.Lock()
+			set[code] = struct{}{}
+			mu.Unlock()
+		}
+		if err := iter.Close(); err != nil {
+			return classifyErr(fmt.Errorf("scan airports of hotel %d: %w", h.ID, err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	wg.Wait()
 
 	// to slice & sort
 	res := make([]string, 0, len(set))
@@ -318,7 +736,9 @@ func (s *ScyllaStorage) refreshAirportsCache() []string {
 	// Fallback: if nothing found with minimal projection, do a full scan via offers iterator
 	if len(res) == 0 {
 		usedFallback = true
-		res = s.collectAirportsByScanningOffers(hotels)
+		if res, err = s.collectAirportsByScanningOffers(ctx, hotels); err != nil {
+			return nil, err
+		}
 	}
 	sort.Strings(res)
 
@@ -332,14 +752,14 @@ func (s *ScyllaStorage) refreshAirportsCache() []string {
 	} else {
 		log.Printf("airports: cache ready in %s; airports=%d", time.Since(start), len(res))
 	}
-	return res
+	return res, nil
 }
 
 // collectAirportsByScanningOffers falls back to reading full rows via scanOffer.
-func (s *ScyllaStorage) collectAirportsByScanningOffers(hotels []models.Hotel) []string {
+func (s *ScyllaStorage) collectAirportsByScanningOffers(ctx context.Context, hotels []models.Hotel) ([]string, error) {
 	set := make(map[string]struct{})
 	for _, h := range hotels {
-		iter := s.offersIterByHotel(h.ID)
+		iter := s.offersIterByHotel(ctx, h.ID)
 		for {
 			offer, ok := scanOffer(iter)
 			if !ok {
@@ -349,13 +769,75 @@ func (s *ScyllaStorage) collectAirportsByScanningOffers(hotels []models.Hotel) [
 				set[offer.OutboundDepartureAirport] = struct{}{}
 			}
 		}
-		_ = iter.Close()
+		if err := iter.Close(); err != nil {
+			return nil, classifyErr(fmt.Errorf("scan offers of hotel %d: %w", h.ID, err))
+		}
 	}
 	out := make([]string, 0, len(set))
 	for k := range set {
 		out = append(out, k)
 	}
-	return out
+	return out, nil
+}
+
+// forEachParallel runs fn for i in [0, n) on at most parallel goroutines. The first error
+// cancels the remaining work and is returned; a cancelled ctx stops dispatching new work.
+func forEachParallel(ctx context.Context, n, parallel int, fn func(ctx context.Context, i int) error) error {
+	if parallel <= 0 {
+		parallel = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sem := make(chan struct{}, parallel)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+dispatch:
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, i); err != nil {
+				fail(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		// prefer the caller's deadline/cancellation over the derived driver error
+		if err := context.Cause(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// classifyErr marks driver-side timeouts with ErrTimeout so callers can tell them from outages
+func classifyErr(err error) error {
+	var (
+		readTimeout  *gocql.RequestErrReadTimeout
+		writeTimeout *gocql.RequestErrWriteTimeout
+	)
+	if errors.Is(err, gocql.ErrTimeoutNoResponse) || errors.As(err, &readTimeout) || errors.As(err, &writeTimeout) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
 }
 
 // scanOffer reads the next row from iter and converts it to models.Offer
@@ -371,7 +853,7 @@ func scanOffer(iter *gocql.Iter) (models.Offer, bool) {
 	if !iter.Scan(&hotelid, &outDep, &inDep, &ca, &cc, &price, &inDepAirport, &inArrAirport, &inArr, &outDepAirport, &outArrAirport, &outArr, &mealType, &oceanView, &roomType) {
 		return models.Offer{}, false
 	}
-	return models.Offer{
+	o := models.Offer{
 		HotelID:                  hotelid,
 		DepartureDate:            outDep,
 		ReturnDate:               inDep,
@@ -387,7 +869,9 @@ func scanOffer(iter *gocql.Iter) (models.Offer, bool) {
 		MealType:                 mealType,
 		OceanView:                oceanView,
 		RoomType:                 roomType,
-	}, true
+	}
+	o.ID = o.StableID()
+	return o, true
 }
 
 // parseConsistency maps string to gocql.Consistency with sane default
@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+// rollupState tracks whether searches may use the offer_rollups table built by cmd/import-offers.
+type rollupState struct {
+	enabled bool
+}
+
+// rollupRow is one row of offer_rollups: min price and count for a hotel, departure airport,
+// party, duration and departure week (see models.DepartureWeek).
+type rollupRow struct {
+	airport  string
+	adults   int
+	children int
+	duration int
+	week     time.Time
+	minPrice float64
+	count    int64
+}
+
+// rollupWeek identifies a rollup row within one hotel
+type rollupWeek struct {
+	airport  string
+	adults   int
+	children int
+	duration int
+	week     int64
+}
+
+func (r rollupRow) key() rollupWeek {
+	return rollupWeek{r.airport, r.adults, r.children, r.duration, r.week.Unix()}
+}
+
+func offerRollupWeek(o *models.Offer, loc *time.Location) rollupWeek {
+	return rollupWeek{o.OutboundDepartureAirport, o.CountAdults, o.CountChildren, o.Duration(), models.DepartureWeek(o.DepartureDate, loc).Unix()}
+}
+
+// weekClass tells how a rollup week relates to the searched date range
+type weekClass int
+
+const (
+	// weekExcluded: no offer of the week can match the dates
+	weekExcluded weekClass = iota
+	// weekInterior: every offer of the week matches the dates
+	weekInterior
+	// weekEdge: some offers may match, raw offers decide
+	weekEdge
+)
+
+// returnSlack bounds the inbound departure relative to the outbound departure plus the duration in
+// days. Duration is measured between arrivals; flight times and the day rounding add less than two days.
+const returnSlack = 2 * 24 * time.Hour
+
+// probeRollups logs whether offer_rollups holds a complete import
+func (s *ScyllaStorage) probeRollups(ctx context.Context) {
+	version, err := s.readRollupVersion(ctx)
+	switch {
+	case err != nil:
+		log.Printf("rollups: offer_rollups unavailable: %v", err)
+	case version.IsZero():
+		log.Printf("rollups: no complete offer_rollups import, run cmd/import-offers to build it")
+	default:
+		log.Printf("rollups: offer_rollups of import %s available, best-offer searches answer from rollups first", version.Format(time.RFC3339))
+	}
+}
+
+// readRollupVersion reads the import version whose rollups are complete. cmd/import-offers records
+// it in import_status (name 'offer_rollups', finishedat) after writing all rollups of that import,
+// and only then deletes older versions. Zero if no import has completed its rollups.
+func (s *ScyllaStorage) readRollupVersion(ctx context.Context) (time.Time, error) {
+	var version time.Time
+	err := s.session.Query(`SELECT finishedat FROM import_status WHERE name = 'offer_rollups'`).WithContext(ctx).Consistency(gocql.One).Scan(&version)
+	switch {
+	case err == nil:
+		return version, nil
+	case errors.Is(err, gocql.ErrNotFound), strings.Contains(strings.ToLower(err.Error()), "unconfigured table"):
+		return time.Time{}, nil
+	}
+	return time.Time{}, classifyErr(fmt.Errorf("read rollup version: %w", err))
+}
+
+// rollupVersion returns the rollup version to answer params with; ok is false if the rollups
+// cannot answer them. Meal, room, ocean view and price filters are not part of the rollup key,
+// so min prices and counts would be wrong.
+func (s *ScyllaStorage) rollupVersion(ctx context.Context, params models.SearchParams) (version time.Time, ok bool) {
+	if !s.rollups.enabled || params.HasOfferAttributeFilters() {
+		return time.Time{}, false
+	}
+	version, err := s.readRollupVersion(ctx)
+	if err != nil {
+		log.Printf("rollups: %v", err)
+		return time.Time{}, false
+	}
+	return version, !version.IsZero()
+}
+
+// matches checks the non-date search fields against the rollup dimensions, like models.Offer.Matches
+func (r rollupRow) matches(params models.SearchParams) bool {
+	if len(params.DepartureAirports) > 0 {
+		found := false
+		for _, a := range params.DepartureAirports {
+			if a == r.airport {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	minDuration, maxDuration := params.DurationBounds()
+	return (params.CountAdults == 0 || r.adults == params.CountAdults) &&
+		(params.CountChildren == 0 || r.children == params.CountChildren) &&
+		(minDuration == 0 || r.duration >= minDuration) &&
+		(maxDuration == 0 || r.duration <= maxDuration)
+}
+
+// classify decides whether the offers of a rollup week match the searched dates
+func (r rollupRow) classify(params models.SearchParams) weekClass {
+	weekEnd := r.week.AddDate(0, 0, 7) // departures are in [week, weekEnd)
+	from, until := params.DepartureWindow()
+	latest := params.LatestReturnDate
+	if !from.IsZero() && !weekEnd.After(from) {
+		return weekExcluded
+	}
+	if !until.IsZero() && !r.week.Before(until) {
+		return weekExcluded
+	}
+	if !latest.IsZero() && r.week.After(latest) {
+		return weekExcluded
+	}
+	interior := true
+	if !from.IsZero() && r.week.Before(from) {
+		interior = false
+	}
+	if !until.IsZero() && weekEnd.After(until) {
+		interior = false
+	}
+	if !latest.IsZero() {
+		latestReturn := weekEnd.Add(time.Duration(r.duration)*24*time.Hour + returnSlack)
+		if latestReturn.After(latest) {
+			interior = false
+		}
+	}
+	if interior {
+		return weekInterior
+	}
+	return weekEdge
+}
+
+// bestOffersFromRollups answers a best-offer search per hotel from offer_rollups first:
+// hotels without candidate weeks are skipped without touching raw offers, the raw partition is
+// read starting at the smallest candidate min price, and the offer count is the sum of all
+// interior weeks plus the exactly counted matches of edge weeks.
+func (s *ScyllaStorage) bestOffersFromRollups(ctx context.Context, hotels []models.Hotel, version time.Time, params models.SearchParams) ([]*models.Offer, []int, error) {
+	best := make([]*models.Offer, len(hotels))
+	counts := make([]int, len(hotels))
+	err := forEachParallel(ctx, len(hotels), s.searchParallel, func(ctx context.Context, i int) error {
+		offer, count, err := s.bestOfferFromRollups(ctx, hotels[i].ID, version, params)
+		if err != nil {
+			return err
+		}
+		best[i], counts[i] = offer, count
+		return nil
+	})
+	return best, counts, err
+}
+
+func (s *ScyllaStorage) bestOfferFromRollups(ctx context.Context, hotelID int, version time.Time, params models.SearchParams) (*models.Offer, int, error) {
+	rows, err := s.rollupRows(ctx, hotelID, version, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		lower         float64
+		haveCandidate bool
+		interiorCount int
+		edges         = map[rollupWeek]struct{}{}
+	)
+	for _, r := range rows {
+		if !r.matches(params) {
+			continue
+		}
+		class := r.classify(params)
+		if class == weekExcluded {
+			continue
+		}
+		if !haveCandidate || r.minPrice < lower {
+			lower = r.minPrice
+		}
+		haveCandidate = true
+		if class == weekInterior {
+			interiorCount += int(r.count)
+		} else {
+			edges[r.key()] = struct{}{}
+		}
+	}
+	if !haveCandidate {
+		return nil, 0, nil
+	}
+
+	// Raw offers from the lowest candidate price on: the first match is the cheapest.
+	// Without edge weeks the count is complete at that point; otherwise keep scanning to count edge
+	// matches until the count limit is reached.
+	iter := s.session.Query(offersSelect+` AND price >= ?`, hotelID, lower).WithContext(ctx).Consistency(gocql.One).Iter()
+	var (
+		best      *models.Offer
+		edgeCount int
+	)
+	for {
+		offer, ok := scanOffer(iter)
+		if !ok {
+			break
+		}
+		if !offer.Matches(params) {
+			continue
+		}
+		if best == nil {
+			o := offer
+			best = &o
+		}
+		if _, edge := edges[offerRollupWeek(&offer, s.loc)]; edge {
+			edgeCount++
+		}
+		if len(edges) == 0 || s.countCapped(interiorCount+edgeCount) {
+			break
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, 0, classifyErr(fmt.Errorf("scan offers of hotel %d: %w", hotelID, err))
+	}
+	if best == nil {
+		return nil, 0, nil
+	}
+	// interior weeks alone are counted exactly; edge weeks only up to the count limit
+	if len(edges) > 0 && s.countCapped(interiorCount+edgeCount) {
+		return best, max(interiorCount, s.countLimit), nil
+	}
+	return best, interiorCount + edgeCount, nil
+}
+
+// rollupRows loads the rollup rows of a hotel and import version, restricting the clustering prefix
+// as far as params pin it
+func (s *ScyllaStorage) rollupRows(ctx context.Context, hotelID int, version time.Time, params models.SearchParams) ([]rollupRow, error) {
+	q := `SELECT outbounddepartureairport, countadults, countchildren, duration, departureweek, minprice, offercount FROM offer_rollups WHERE hotelid = ? AND importversion = ?`
+	args := []interface{}{hotelID, version}
+	if len(params.DepartureAirports) > 0 {
+		q += ` AND outbounddepartureairport IN ?`
+		args = append(args, params.DepartureAirports)
+		if params.CountAdults != 0 {
+			q += ` AND countadults = ?`
+			args = append(args, params.CountAdults)
+			if params.CountChildren != 0 {
+				q += ` AND countchildren = ?`
+				args = append(args, params.CountChildren)
+				if duration, ok := params.ExactDuration(); ok {
+					q += ` AND duration = ?`
+					args = append(args, duration)
+				}
+			}
+		}
+	}
+	iter := s.session.Query(q, args...).WithContext(ctx).Consistency(gocql.One).Iter()
+	var (
+		r    rollupRow
+		rows []rollupRow
+	)
+	for iter.Scan(&r.airport, &r.adults, &r.children, &r.duration, &r.week, &r.minPrice, &r.count) {
+		// the date column holds the calendar day of the week start; the week begins at midnight in loc
+		r.week = time.Date(r.week.Year(), r.week.Month(), r.week.Day(), 0, 0, 0, 0, s.loc)
+		rows = append(rows, r)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, classifyErr(fmt.Errorf("load rollups of hotel %d: %w", hotelID, err))
+	}
+	return rows, nil
+}
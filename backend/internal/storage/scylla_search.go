@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+// searchPartition is the partition key of the denormalized offers_by_search table.
+type searchPartition struct {
+	airport  string
+	adults   int
+	children int
+	duration int
+}
+
+// searchTableCatalog caches the partition keys of offers_by_search. It tells a search which
+// partitions exist (e.g. which children counts to read when countChildren is unset) and whether
+// the table is populated at all; an empty catalog makes searches fall back to hotel partitions.
+// The catalog belongs to the DataVersion it was loaded for and is reloaded once a newer import
+// has finished, so partitions added by a re-import are never missed.
+type searchTableCatalog struct {
+	enabled bool
+	ttl     time.Duration
+
+	mu         sync.RWMutex
+	partitions []searchPartition
+	loadedAt   time.Time
+	version    time.Time
+}
+
+// offersBySearchSelect uses the same column order as offersSelect so scanOffer can read both
+const offersBySearchSelect = `SELECT hotelid, outbounddeparturedatetime, inbounddeparturedatetime, countadults, countchildren, price, inbounddepartureairport, inboundarrivalairport, inboundarrivaldatetime, outbounddepartureairport, outboundarrivalairport, outboundarrivaldatetime, mealtype, oceanview, roomtype FROM offers_by_search WHERE outbounddepartureairport = ? AND countadults = ? AND countchildren = ? AND duration = ?`
+
+// searchTablePartitions returns the offers_by_search partitions covering params. ok is false when
+// the search does not pin departure airports and adults, leaves the duration open-ended, or the
+// table is unavailable.
+func (s *ScyllaStorage) searchTablePartitions(ctx context.Context, params models.SearchParams) (parts []searchPartition, ok bool) {
+	minDuration, maxDuration := params.DurationBounds()
+	return s.selectSearchPartitions(ctx, params, func(p searchPartition, airport bool) bool {
+		return airport && p.duration >= minDuration && p.duration <= maxDuration
+	})
+}
+
+// facetPartitions returns the offers_by_search partitions the facets of params need. Each facet
+// ignores its own filter, so besides the searched partitions it covers the other airports with the
+// searched durations and the other durations from the searched airports. Same ok as
+// searchTablePartitions.
+func (s *ScyllaStorage) facetPartitions(ctx context.Context, params models.SearchParams) (parts []searchPartition, ok bool) {
+	minDuration, maxDuration := params.DurationBounds()
+	return s.selectSearchPartitions(ctx, params, func(p searchPartition, airport bool) bool {
+		return airport || (p.duration >= minDuration && p.duration <= maxDuration)
+	})
+}
+
+// selectSearchPartitions returns the catalog partitions of the searched party for which keep is
+// true; airport tells keep whether the partition's airport is one of the searched ones.
+func (s *ScyllaStorage) selectSearchPartitions(ctx context.Context, params models.SearchParams, keep func(p searchPartition, airport bool) bool) (parts []searchPartition, ok bool) {
+	if _, maxDuration := params.DurationBounds(); !s.searchTables.enabled || len(params.DepartureAirports) == 0 || params.CountAdults == 0 || maxDuration == 0 {
+		return nil, false
+	}
+
+	version, err := s.DataVersion(ctx)
+	if err != nil {
+		// without the data version the catalog may be stale; read the hotel partitions instead
+		log.Printf("search tables: %v", err)
+		return nil, false
+	}
+	s.searchTables.mu.RLock()
+	catalog, loadedAt, loadedVersion := s.searchTables.partitions, s.searchTables.loadedAt, s.searchTables.version
+	s.searchTables.mu.RUnlock()
+	if loadedAt.IsZero() || time.Since(loadedAt) > s.searchTables.ttl || !version.Equal(loadedVersion) {
+		if err := s.refreshSearchTableCatalog(ctx); err != nil {
+			log.Printf("search tables: catalog refresh failed: %v", err)
+		}
+		s.searchTables.mu.RLock()
+		catalog, loadedVersion = s.searchTables.partitions, s.searchTables.version
+		s.searchTables.mu.RUnlock()
+	}
+	if len(catalog) == 0 || !version.Equal(loadedVersion) {
+		return nil, false
+	}
+
+	airports := make(map[string]struct{}, len(params.DepartureAirports))
+	for _, a := range params.DepartureAirports {
+		airports[a] = struct{}{}
+	}
+	for _, p := range catalog {
+		// countChildren == 0 means "any", read every children partition that exists
+		if p.adults != params.CountAdults || (params.CountChildren != 0 && p.children != params.CountChildren) {
+			continue
+		}
+		_, airport := airports[p.airport]
+		if keep(p, airport) {
+			parts = append(parts, p)
+		}
+	}
+	// an empty list is a valid answer: the catalog is complete, so nothing matches
+	return parts, true
+}
+
+// refreshSearchTableCatalog reloads the partition keys of offers_by_search and records the
+// DataVersion read before the scan. On error the previous catalog is kept.
+func (s *ScyllaStorage) refreshSearchTableCatalog(ctx context.Context) error {
+	start := time.Now()
+	version, err := s.DataVersion(ctx)
+	if err != nil {
+		return err
+	}
+	iter := s.session.Query(`SELECT DISTINCT outbounddepartureairport, countadults, countchildren, duration FROM offers_by_search`).
+		WithContext(ctx).Consistency(gocql.One).PageSize(5000).Iter()
+	var (
+		p     searchPartition
+		parts []searchPartition
+	)
+	for iter.Scan(&p.airport, &p.adults, &p.children, &p.duration) {
+		parts = append(parts, p)
+	}
+	if err := iter.Close(); err != nil {
+		return classifyErr(fmt.Errorf("load offers_by_search partitions: %w", err))
+	}
+	s.searchTables.mu.Lock()
+	s.searchTables.partitions = parts
+	s.searchTables.loadedAt = time.Now()
+	s.searchTables.version = version
+	s.searchTables.mu.Unlock()
+	log.Printf("search tables: catalog ready in %s; partitions=%d", time.Since(start), len(parts))
+	return nil
+}
+
+// bestOffersFromSearchTable reads the given offers_by_search partitions in parallel and keeps the
+// cheapest match per hotel. Rows are clustered by price, so the first match of a hotel within a
+// partition is its cheapest there; the partitions are disjoint, so their match counts add up.
+func (s *ScyllaStorage) bestOffersFromSearchTable(ctx context.Context, hotels []models.Hotel, parts []searchPartition, params models.SearchParams) ([]*models.Offer, []int, error) {
+	index := make(map[int]int, len(hotels))
+	for i, h := range hotels {
+		index[h.ID] = i
+	}
+	best := make([]*models.Offer, len(hotels))
+	counts := make([]int, len(hotels))
+	var mu sync.Mutex
+
+	err := forEachParallel(ctx, len(parts), s.searchParallel, func(ctx context.Context, pi int) error {
+		p := parts[pi]
+		cond, args := priceRestriction(params)
+		iter := s.session.Query(offersBySearchSelect+cond, append([]interface{}{p.airport, p.adults, p.children, p.duration}, args...)...).
+			WithContext(ctx).Consistency(gocql.One).Iter()
+		// per partition: first match and number of matches per hotel index. Hotels share the
+		// partition, so the scan ends early only once every hotel has reached the count limit.
+		first := make(map[int]models.Offer)
+		matched := make(map[int]int)
+		capped := 0
+		for capped < len(hotels) {
+			offer, ok := scanOffer(iter)
+			if !ok {
+				break
+			}
+			hi, known := index[offer.HotelID]
+			if !known || s.countCapped(matched[hi]) || !offer.Matches(params) {
+				continue
+			}
+			if _, seen := first[hi]; !seen {
+				first[hi] = offer
+			}
+			matched[hi]++
+			if s.countCapped(matched[hi]) {
+				capped++
+			}
+		}
+		if err := iter.Close(); err != nil {
+			return classifyErr(fmt.Errorf("scan offers_by_search %s/%d/%d/%d: %w", p.airport, p.adults, p.children, p.duration, err))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for hi, offer := range first {
+			if best[hi] == nil || offer.Price < best[hi].Price {
+				o := offer
+				best[hi] = &o
+			}
+			counts[hi] += matched[hi]
+			if s.countCapped(counts[hi]) {
+				counts[hi] = s.countLimit
+			}
+		}
+		return nil
+	})
+	return best, counts, err
+}
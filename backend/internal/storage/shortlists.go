@@ -0,0 +1,306 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+
+	"github.com/gocql/gocql"
+)
+
+// ShortlistStore persists user shortlists. Items are keyed by (listID, item ID); saving an item
+// with an existing ID replaces it. DeleteItem and UpdateNote return ErrNotFound for unknown items.
+type ShortlistStore interface {
+	ListItems(ctx context.Context, listID string) ([]models.ShortlistItem, error)
+	GetItem(ctx context.Context, listID, itemID string) (*models.ShortlistItem, error)
+	SaveItem(ctx context.Context, listID string, item models.ShortlistItem) error
+	UpdateNote(ctx context.Context, listID, itemID, note string) error
+	DeleteItem(ctx context.Context, listID, itemID string) error
+}
+
+var (
+	_ ShortlistStore = (*ScyllaShortlistStore)(nil)
+	_ ShortlistStore = (*MemoryShortlistStore)(nil)
+)
+
+// shortlistItemsTableCQL is kept in sync with infra/scylla/schema.cql
+const shortlistItemsTableCQL = `CREATE TABLE IF NOT EXISTS shortlist_items (
+	listid text,
+	itemid text,
+	hotelid int,
+	offer text,
+	note text,
+	savedprice double,
+	addedat timestamp,
+	PRIMARY KEY ((listid), itemid)
+)`
+
+// ScyllaShortlistStore stores shortlists in the shortlist_items table, one partition per list.
+// The offer snapshot is kept as JSON so the table does not have to follow the offers schema.
+type ScyllaShortlistStore struct {
+	session *gocql.Session
+}
+
+// NewScyllaShortlistStore creates the shortlist_items table if needed
+func NewScyllaShortlistStore(session *gocql.Session) (*ScyllaShortlistStore, error) {
+	if err := session.Query(shortlistItemsTableCQL).Exec(); err != nil {
+		return nil, fmt.Errorf("create shortlist_items: %w", err)
+	}
+	return &ScyllaShortlistStore{session: session}, nil
+}
+
+const shortlistItemSelect = `SELECT itemid, hotelid, offer, note, savedprice, addedat FROM shortlist_items WHERE listid = ?`
+
+func (s *ScyllaShortlistStore) ListItems(ctx context.Context, listID string) ([]models.ShortlistItem, error) {
+	iter := s.session.Query(shortlistItemSelect, listID).WithContext(ctx).Iter()
+	items := []models.ShortlistItem{}
+	for {
+		item, ok, err := scanShortlistItem(iter)
+		if err != nil {
+			iter.Close()
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		items = append(items, item)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, classifyErr(fmt.Errorf("list shortlist %s: %w", listID, err))
+	}
+	sortShortlistItems(items)
+	return items, nil
+}
+
+func (s *ScyllaShortlistStore) GetItem(ctx context.Context, listID, itemID string) (*models.ShortlistItem, error) {
+	iter := s.session.Query(shortlistItemSelect+` AND itemid = ?`, listID, itemID).WithContext(ctx).Iter()
+	item, ok, err := scanShortlistItem(iter)
+	if cerr := iter.Close(); err == nil && cerr != nil {
+		err = classifyErr(fmt.Errorf("get shortlist item %s/%s: %w", listID, itemID, cerr))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &item, nil
+}
+
+func (s *ScyllaShortlistStore) SaveItem(ctx context.Context, listID string, item models.ShortlistItem) error {
+	var offer string
+	if item.Offer != nil {
+		b, err := json.Marshal(item.Offer)
+		if err != nil {
+			return err
+		}
+		offer = string(b)
+	}
+	err := s.session.Query(`INSERT INTO shortlist_items (listid, itemid, hotelid, offer, note, savedprice, addedat) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		listID, item.ID, item.HotelID, offer, item.Note, item.SavedPrice, item.AddedAt).WithContext(ctx).Exec()
+	if err != nil {
+		return classifyErr(fmt.Errorf("save shortlist item %s/%s: %w", listID, item.ID, err))
+	}
+	return nil
+}
+
+func (s *ScyllaShortlistStore) UpdateNote(ctx context.Context, listID, itemID, note string) error {
+	applied, err := s.session.Query(`UPDATE shortlist_items SET note = ? WHERE listid = ? AND itemid = ? IF EXISTS`, note, listID, itemID).
+		WithContext(ctx).ScanCAS()
+	if err != nil {
+		return classifyErr(fmt.Errorf("update shortlist item %s/%s: %w", listID, itemID, err))
+	}
+	if !applied {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *ScyllaShortlistStore) DeleteItem(ctx context.Context, listID, itemID string) error {
+	applied, err := s.session.Query(`DELETE FROM shortlist_items WHERE listid = ? AND itemid = ? IF EXISTS`, listID, itemID).
+		WithContext(ctx).ScanCAS()
+	if err != nil {
+		return classifyErr(fmt.Errorf("delete shortlist item %s/%s: %w", listID, itemID, err))
+	}
+	if !applied {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// scanShortlistItem reads the next row selected by shortlistItemSelect
+func scanShortlistItem(iter *gocql.Iter) (models.ShortlistItem, bool, error) {
+	var (
+		item  models.ShortlistItem
+		offer string
+	)
+	if !iter.Scan(&item.ID, &item.HotelID, &offer, &item.Note, &item.SavedPrice, &item.AddedAt) {
+		return item, false, nil
+	}
+	if offer != "" {
+		item.Offer = &models.Offer{}
+		if err := json.Unmarshal([]byte(offer), item.Offer); err != nil {
+			return item, false, fmt.Errorf("decode shortlist item %s: %w", item.ID, err)
+		}
+	}
+	return item, true, nil
+}
+
+// MemoryShortlistStore keeps shortlists in process memory; they are lost on restart.
+// Used with the memory backend or when the Scylla table is unavailable.
+type MemoryShortlistStore struct {
+	mu    sync.RWMutex
+	lists map[string]map[string]models.ShortlistItem
+}
+
+// NewMemoryShortlistStore creates an empty in-memory store
+func NewMemoryShortlistStore() *MemoryShortlistStore {
+	return &MemoryShortlistStore{lists: make(map[string]map[string]models.ShortlistItem)}
+}
+
+func (s *MemoryShortlistStore) ListItems(ctx context.Context, listID string) ([]models.ShortlistItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	items := make([]models.ShortlistItem, 0, len(s.lists[listID]))
+	for _, item := range s.lists[listID] {
+		items = append(items, item)
+	}
+	sortShortlistItems(items)
+	return items, nil
+}
+
+func (s *MemoryShortlistStore) GetItem(ctx context.Context, listID, itemID string) (*models.ShortlistItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.lists[listID][itemID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &item, nil
+}
+
+func (s *MemoryShortlistStore) SaveItem(ctx context.Context, listID string, item models.ShortlistItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lists[listID] == nil {
+		s.lists[listID] = make(map[string]models.ShortlistItem)
+	}
+	s.lists[listID][item.ID] = item
+	return nil
+}
+
+func (s *MemoryShortlistStore) UpdateNote(ctx context.Context, listID, itemID, note string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.lists[listID][itemID]
+	if !ok {
+		return ErrNotFound
+	}
+	item.Note = note
+	s.lists[listID][itemID] = item
+	return nil
+}
+
+func (s *MemoryShortlistStore) DeleteItem(ctx context.Context, listID, itemID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lists[listID][itemID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.lists[listID], itemID)
+	return nil
+}
+
+// sortShortlistItems orders items by the time they were added, oldest first
+func sortShortlistItems(items []models.ShortlistItem) {
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].AddedAt.Equal(items[j].AddedAt) {
+			return items[i].AddedAt.Before(items[j].AddedAt)
+		}
+		return items[i].ID < items[j].ID
+	})
+}
+
+// cheapestOffer returns the hotel's cheapest offer, nil if it has none
+func cheapestOffer(ctx context.Context, s Storage, hotelID int) (*models.Offer, error) {
+	page, err := s.GetOffersPageByHotel(ctx, hotelID, models.SearchParams{}, 1, "")
+	if err != nil || len(page.Items) == 0 {
+		return nil, err
+	}
+	return &page.Items[0], nil
+}
+
+// NewShortlistItem builds the item for an existing hotel (offer nil) or one of its offers,
+// pricing it from the current data. Returns ErrNotFound if the offer does not exist anymore.
+func NewShortlistItem(ctx context.Context, s Storage, hotelID int, offer *models.Offer, note string) (*models.ShortlistItem, error) {
+	item := &models.ShortlistItem{HotelID: hotelID, Note: note, AddedAt: time.Now().UTC()}
+	if offer == nil {
+		item.ID = models.ShortlistHotelItemID(hotelID)
+		cheapest, err := cheapestOffer(ctx, s, hotelID)
+		if err != nil {
+			return nil, err
+		}
+		if cheapest != nil {
+			item.SavedPrice = cheapest.Price
+		}
+		return item, nil
+	}
+	current, err := s.GetOffer(ctx, offer.StableID())
+	if err != nil {
+		return nil, err
+	}
+	item.ID = current.ID
+	item.Offer = current
+	item.SavedPrice = current.Price
+	return item, nil
+}
+
+// RefreshShortlistItems fills Hotel, CurrentPrice and Status of items from the current data
+func RefreshShortlistItems(ctx context.Context, s Storage, items []models.ShortlistItem) error {
+	return forEachParallel(ctx, len(items), 8, func(ctx context.Context, i int) error {
+		item := &items[i]
+		hotel, err := s.GetHotel(ctx, item.HotelID)
+		if errors.Is(err, ErrNotFound) {
+			item.Status = models.ShortlistStatusUnavailable
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		item.Hotel = hotel
+
+		var current *models.Offer
+		if item.Offer != nil {
+			current, err = s.GetOffer(ctx, item.Offer.StableID())
+			if errors.Is(err, ErrNotFound) {
+				err = nil
+			}
+		} else {
+			current, err = cheapestOffer(ctx, s, item.HotelID)
+		}
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			item.Status = models.ShortlistStatusUnavailable
+			return nil
+		}
+		price := current.Price
+		item.CurrentPrice = &price
+		switch {
+		case price > item.SavedPrice:
+			item.Status = models.ShortlistStatusPriceIncrease
+		case price < item.SavedPrice:
+			item.Status = models.ShortlistStatusPriceDecrease
+		default:
+			item.Status = models.ShortlistStatusAvailable
+		}
+		return nil
+	})
+}
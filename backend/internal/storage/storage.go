@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"holiday-coding-challenge/backend/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested entity does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrTimeout wraps backend timeouts that are not caused by the caller's context.
+	ErrTimeout = errors.New("storage timeout")
+	// ErrUnboundedSearch is returned when a search would have to read every offer, e.g. on Scylla
+	// without departure airports, adults and duration pinning the offers_by_search partitions.
+	ErrUnboundedSearch = errors.New("search too broad")
+)
+
+// Storage defines the methods our handlers need. Implemented by ScyllaStorage and MemoryStorage.
+// All methods honour ctx cancellation and deadlines; failures are returned, never swallowed.
+type Storage interface {
+	// GetHotelsWithBestOffers returns every hotel with a matching offer, in no particular order
+	// (see models.SelectHotels for sorting and paging)
+	GetHotelsWithBestOffers(ctx context.Context, params models.SearchParams) ([]models.HotelWithBestOffer, error)
+	// GetFacets returns per-value hotel counts and min prices of the search, each facet ignoring its own
+	// filter. ErrUnboundedSearch if the backend cannot bound the search (see ScyllaStorage.GetFacets).
+	GetFacets(ctx context.Context, params models.SearchParams) (*models.SearchFacets, error)
+	GetOffersByHotel(ctx context.Context, hotelID int, params models.SearchParams) ([]models.Offer, error)
+	// GetOffersPageByHotel returns up to limit (0 = all) matching offers of a hotel starting at the
+	// opaque cursor ("" = first page). Returns ErrInvalidCursor for cursors it did not hand out.
+	GetOffersPageByHotel(ctx context.Context, hotelID int, params models.SearchParams, limit int, cursor string) (*models.OffersPage, error)
+	// ScanOffers calls visit once per hotel with its matching offers in price order; hotelID 0 scans
+	// every hotel passing the hotel filters (ErrUnboundedSearch if the backend cannot bound that scan,
+	// see ScyllaStorage.ScanOffers). Hotels are scanned in parallel, visit calls are serialized.
+	// offers is only valid during the call.
+	ScanOffers(ctx context.Context, params models.SearchParams, hotelID int, visit func(hotel *models.Hotel, offers []models.Offer)) error
+	// GetOffer resolves a models.Offer id (see Offer.StableID) to the offer's current version;
+	// ErrNotFound if the id is malformed or the offer no longer exists
+	GetOffer(ctx context.Context, offerID string) (*models.Offer, error)
+	// GetHotel returns ErrNotFound if no hotel with hotelID exists
+	GetHotel(ctx context.Context, hotelID int) (*models.Hotel, error)
+	GetAllHotels(ctx context.Context) ([]models.Hotel, error)
+	GetStats(ctx context.Context) (map[string]interface{}, error)
+	// DataVersion identifies the loaded offer data: the finish time of the last offers import
+	// (zero if unknown). Caches derived from offers are valid as long as it does not change.
+	DataVersion(ctx context.Context) (time.Time, error)
+	// GetAvailableDepartureAirports returns unique outbound departure airport codes across all offers
+	GetAvailableDepartureAirports(ctx context.Context) ([]string, error)
+}
+
+var (
+	_ Storage = (*ScyllaStorage)(nil)
+	_ Storage = (*MemoryStorage)(nil)
+)